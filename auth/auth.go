@@ -10,11 +10,13 @@ import (
 	"math"
 	"math/big"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/go-jose/go-jose/v4"
 	"github.com/go-jose/go-jose/v4/jwt"
 	"github.com/iPopcorn/investment-manager/config"
+	"github.com/iPopcorn/investment-manager/util"
 )
 
 var max = big.NewInt(math.MaxInt64)
@@ -54,10 +56,16 @@ func GetApiKey() (*APIKey, error) {
 		return nil, err
 	}
 
+	if config.ApiKeyPath == "" {
+		configDir, _ := util.GetConfigDir()
+
+		return nil, fmt.Errorf("API key path not set, add api_key_path to %s or API_KEY_PATH to .env\n", filepath.Join(configDir, "config.yaml"))
+	}
+
 	apiKeyJsonFile, err := os.Open(config.ApiKeyPath)
 
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("API key not found at %s, check api_key_path or API_KEY_PATH\n%v\n", config.ApiKeyPath, err)
 	}
 
 	defer apiKeyJsonFile.Close()
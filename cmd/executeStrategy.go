@@ -7,7 +7,7 @@ import (
 )
 
 var executeStrategyCmd = &cobra.Command{
-	Use:   "execute-strategy portfolio strategy currency",
+	Use:   "execute-strategy portfolio strategy [currency]",
 	Short: "Execute a specified trading strategy against a given portfolio",
 	Long: `Execute a trading strategy against a given portfolio. 
 If no portfolio is given, an error is thrown. 
@@ -15,9 +15,42 @@ Use 'portfolio' command to see list of portfolios.
 Strategy and currency are not case sensitive.
 Supported strategies:
 HODL
-Supported currencies:
-ETH
-example: 'execute-strategy test hodl eth'`,
+DCA
+REBALANCE
+GRID
+Any currency the exchange has a product for can be traded, e.g. BTC, ETH or SOL,
+unless the server limits them with allowed_currencies in config.yaml.
+Strategies trade with the portfolio's cash currency, e.g. ETH-USD in a USD portfolio,
+use --quote-currency to pick one when the portfolio holds several.
+HODL re-prices orders that expire before they are filled,
+use --max-attempts and --max-drift to limit how many orders it places
+and how far (in percent) the price can rise from the first quote.
+example: 'execute-strategy test hodl eth --max-attempts 10 --max-drift 0.5'
+HODL and DCA buy with post-only limit GTD orders unless --order-type is one of
+market_market_ioc, sor_limit_ioc, limit_limit_gtc or limit_limit_gtd.
+example: 'execute-strategy test hodl eth --order-type market_market_ioc'
+HODL can split its buy into --slices orders spread evenly over --duration,
+each priced at the best bid when it is placed.
+example: 'execute-strategy test hodl eth --slices 12 --duration 6h'
+DCA spends --amount every --interval until --budget is spent or --buys buys are made.
+The server saves progress after each buy and resumes the plan when restarted.
+example: 'execute-strategy test dca eth --amount 25 --interval 168h --buys 12'
+REBALANCE takes no currency, it trades every asset that is more than --tolerance
+percentage points away from its --target weight. Cash is whatever is left over.
+Use --preview to print the trades without placing them.
+example: 'execute-strategy test rebalance --target BTC=60 --target ETH=30 --target GBP=10 --tolerance 2 --preview'
+GRID spreads --capital over --levels evenly spaced prices from --lower to --upper,
+buying below the current price and selling above it. When an order fills the
+opposite order is placed one level away.
+example: 'execute-strategy test grid eth --lower 2000 --upper 3000 --levels 11 --capital 500'
+Any strategy that holds a currency can be protected with --stop-loss and --take-profit
+(absolute prices) or --stop-loss-percent and --take-profit-percent (percent from the
+average cost). The server sells the position when the best bid crosses a threshold.
+The thresholds are shown by the 'portfolio-details' command.
+example: 'execute-strategy test hodl eth --stop-loss-percent 10 --take-profit 4000'
+--trailing-stop keeps a stop-limit sell order the given percent below the highest
+price seen, moving it up as the price rises.
+example: 'execute-strategy test hodl eth --trailing-stop 8'`,
 	RunE: nil,
 }
 
@@ -26,6 +59,29 @@ func init() {
 
 	executeStrategyHandler := handlers.ExecuteStrategyHandlerFactory(internalHttpClient)
 	executeStrategyCmd.RunE = executeStrategyHandler
+	executeStrategyCmd.Flags().Int("max-attempts", 0, "Max number of orders to place before giving up (default 5)")
+	executeStrategyCmd.Flags().Float64("max-drift", 0, "Max percent the price can rise from the first quote (default 1)")
+
+	executeStrategyCmd.Flags().String("quote-currency", "", "Fiat currency to trade with (default the portfolio's cash currency)")
+	executeStrategyCmd.Flags().String("order-type", "", "HODL/DCA: order type to buy with (default limit_limit_gtd)")
+	executeStrategyCmd.Flags().Int("slices", 0, "HODL: split the buy into this many orders (TWAP)")
+	executeStrategyCmd.Flags().String("duration", "", "HODL: time to spread the slices over, e.g. 6h")
+	executeStrategyCmd.Flags().Float64("amount", 0, "DCA: fiat to spend on each buy")
+	executeStrategyCmd.Flags().String("interval", "", "DCA: time between buys, e.g. 24h")
+	executeStrategyCmd.Flags().Float64("budget", 0, "DCA: stop once this much fiat is spent")
+	executeStrategyCmd.Flags().Int("buys", 0, "DCA: stop after this many buys")
+	executeStrategyCmd.Flags().StringSlice("target", nil, "REBALANCE: target weight as ASSET=PERCENT, repeat for each asset")
+	executeStrategyCmd.Flags().Float64("tolerance", 0, "REBALANCE: percentage points an asset can drift from its target")
+	executeStrategyCmd.Flags().Bool("preview", false, "REBALANCE: print the planned trades without placing them")
+	executeStrategyCmd.Flags().Float64("lower", 0, "GRID: lowest price level")
+	executeStrategyCmd.Flags().Float64("upper", 0, "GRID: highest price level")
+	executeStrategyCmd.Flags().Int("levels", 0, "GRID: number of price levels, including lower and upper")
+	executeStrategyCmd.Flags().Float64("capital", 0, "GRID: fiat spread evenly over the levels")
+	executeStrategyCmd.Flags().Float64("stop-loss", 0, "Sell the position if the price falls to this price")
+	executeStrategyCmd.Flags().Float64("stop-loss-percent", 0, "Sell the position if the price falls this percent below its average cost")
+	executeStrategyCmd.Flags().Float64("take-profit", 0, "Sell the position if the price rises to this price")
+	executeStrategyCmd.Flags().Float64("take-profit-percent", 0, "Sell the position if the price rises this percent above its average cost")
+	executeStrategyCmd.Flags().Float64("trailing-stop", 0, "Sell the position if the price falls this percent below the highest price seen")
 
 	rootCmd.AddCommand(executeStrategyCmd)
 }
@@ -0,0 +1,33 @@
+package cmd
+
+import (
+	"github.com/iPopcorn/investment-manager/handlers"
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/spf13/cobra"
+)
+
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Show the journal of strategy decisions, orders and transfers",
+	Long: `Show what the server has recorded about every strategy decision, order and transfer, oldest first.
+Strategy decisions include the best bid/ask, available funds and commission estimate the order was sized from,
+orders and transfers include the exchange's response.
+Events: strategy_decision, order_placed, order_failed, funds_transferred, transfer_failed
+Use --replay to show everything recorded for each entry.
+example: 'journal --portfolio test --since 24h'
+example: 'journal --event order_failed --product eth-gbp --replay'`,
+	RunE: nil,
+}
+
+func init() {
+	internalHttpClient := infrastructure.GetDefaultInvestmentManagerInternalHttpClient()
+
+	journalCmd.RunE = handlers.JournalHandlerFactory(internalHttpClient)
+	journalCmd.Flags().String("portfolio", "", "Only show entries for the portfolio with this name")
+	journalCmd.Flags().String("event", "", "Only show entries of this event")
+	journalCmd.Flags().String("product", "", "Only show entries for this product, e.g. eth-gbp")
+	journalCmd.Flags().String("since", "", "Only show entries from this long ago, e.g. 24h, or since an RFC3339 timestamp")
+	journalCmd.Flags().Bool("replay", false, "Show the inputs, order and exchange response of each entry")
+
+	rootCmd.AddCommand(journalCmd)
+}
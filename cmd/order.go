@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"github.com/iPopcorn/investment-manager/handlers"
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/spf13/cobra"
+)
+
+var orderCmd = &cobra.Command{
+	Use:     "order",
+	Aliases: []string{"orders"},
+	Short:   "Place, list and cancel orders",
+}
+
+var orderPlaceCmd = &cobra.Command{
+	Use:   "place portfolio product side size",
+	Short: "Place a single order after previewing it",
+	Long: `Place a single buy or sell order in the given portfolio.
+Refer to the portfolio by name, names are case sensitive.
+Product and side are not case sensitive, side is buy or sell.
+Size is in the base currency unless --quote is given.
+The order is previewed first, showing the order total, commission, slippage
+and any warnings from the exchange. It is only placed once you confirm,
+use --yes to skip the confirmation.
+Supported order types:
+market_market_ioc (default)
+sor_limit_ioc
+limit_limit_gtc
+limit_limit_gtd (requires --expires)
+stop_limit_stop_limit_gtc
+stop_limit_stop_limit_gtd (requires --expires)
+Every order type except market needs --limit-price, stop-limit orders also need --stop-price.
+example: 'order place test eth-gbp buy 25 --quote'
+example: 'order place test eth-gbp sell 0.1 --order-type limit_limit_gtc --limit-price 3000 --post-only'`,
+	RunE: nil,
+}
+
+var orderListCmd = &cobra.Command{
+	Use:   "list portfolio",
+	Short: "List the open orders in a portfolio",
+	Long: `List the open orders in the given portfolio, as reported by the exchange.
+Orders placed by the portfolio's strategy are labelled with the strategy name,
+offers the strategy has in state that are no longer open on the exchange are listed too.
+example: 'orders list test'`,
+	RunE: nil,
+}
+
+var orderCancelCmd = &cobra.Command{
+	Use:   "cancel portfolio [order-id...]",
+	Short: "Cancel open orders in a portfolio",
+	Long: `Cancel the given orders, or every open order in the portfolio with --all.
+Cancelled orders are moved to the strategy's closed offers.
+A HODL or DCA buy that is cancelled is not re-priced.
+example: 'orders cancel test 0000-000000-000000'
+example: 'orders cancel test --all'`,
+	RunE: nil,
+}
+
+func init() {
+	internalHttpClient := infrastructure.GetDefaultInvestmentManagerInternalHttpClient()
+
+	orderPlaceCmd.RunE = handlers.PlaceOrderHandlerFactory(internalHttpClient)
+	orderPlaceCmd.Flags().String("order-type", "", "Order type to place (default market_market_ioc)")
+	orderPlaceCmd.Flags().Bool("quote", false, "Size is in the quote currency, market buys only")
+	orderPlaceCmd.Flags().String("limit-price", "", "Limit price, required by every order type except market")
+	orderPlaceCmd.Flags().String("stop-price", "", "Stop price, required by stop-limit orders")
+	orderPlaceCmd.Flags().String("expires", "", "Time until a GTD order expires, e.g. 24h")
+	orderPlaceCmd.Flags().Bool("post-only", false, "Only place the limit order if it adds liquidity")
+	orderPlaceCmd.Flags().BoolP("yes", "y", false, "Place the order without asking for confirmation")
+
+	orderListCmd.RunE = handlers.ListOrdersHandlerFactory(internalHttpClient)
+
+	orderCancelCmd.RunE = handlers.CancelOrdersHandlerFactory(internalHttpClient)
+	orderCancelCmd.Flags().Bool("all", false, "Cancel every open order in the portfolio")
+
+	orderCmd.AddCommand(orderPlaceCmd)
+	orderCmd.AddCommand(orderListCmd)
+	orderCmd.AddCommand(orderCancelCmd)
+	rootCmd.AddCommand(orderCmd)
+}
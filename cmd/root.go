@@ -6,6 +6,8 @@ package cmd
 import (
 	"os"
 
+	"github.com/iPopcorn/investment-manager/config"
+	"github.com/iPopcorn/investment-manager/util"
 	"github.com/spf13/cobra"
 )
 
@@ -47,6 +49,30 @@ func init() {
 	// will be global for your application.
 
 	// rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.investment-manager.yaml)")
+	rootCmd.PersistentFlags().String("data-dir", "", "Directory the server keeps state in (default $"+util.DataDirEnvVar+", then $XDG_DATA_HOME/investment-manager)")
+	rootCmd.PersistentFlags().String("config-dir", "", "Directory config.yaml and .env are read from (default $"+util.ConfigDirEnvVar+", then $XDG_CONFIG_HOME/investment-manager)")
+	rootCmd.PersistentFlags().String("profile", "", "Profile in config.yaml to use (default $"+config.ProfileEnvVar+", then the profile named in config.yaml)")
+	rootCmd.PersistentFlags().String("server", "", "Address of the server (default server_address in config.yaml, then "+config.DefaultServerAddress+")")
+	rootCmd.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		dataDir, _ := cmd.Flags().GetString("data-dir")
+		configDir, _ := cmd.Flags().GetString("config-dir")
+		profile, _ := cmd.Flags().GetString("profile")
+		util.SetDataDir(dataDir)
+		util.SetConfigDir(configDir)
+		config.SetProfile(profile)
+
+		cfg, err := config.GetConfig()
+
+		if err != nil {
+			return err
+		}
+
+		if server, _ := cmd.Flags().GetString("server"); server != "" {
+			cfg.ServerAddress = server
+		}
+
+		return nil
+	}
 
 	// Cobra also supports local flags, which will only run
 	// when this action is called directly.
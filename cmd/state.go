@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"github.com/iPopcorn/investment-manager/handlers"
+	"github.com/spf13/cobra"
+)
+
+var stateCmd = &cobra.Command{
+	Use:   "state",
+	Short: "Manage the server's saved state",
+}
+
+var stateMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Upgrade the state file to the current schema version",
+	Long: `Run every migration the state file hasn't had yet, so it can be read by this version.
+The server does this itself when it starts, this lets you check first.
+The old file is kept next to it as <file>.v<version>.bak.
+Give a .db file to migrate an SQLite state database.
+Use --dry-run to list what would change without writing anything.
+example: 'state migrate --dry-run'
+example: 'state migrate --file paper-state.json'`,
+	RunE: handlers.HandleMigrateState,
+}
+
+func init() {
+	stateMigrateCmd.Flags().Bool("dry-run", false, "Report what would change without writing anything")
+	stateMigrateCmd.Flags().String("file", "state.json", "State file in the data directory to migrate, see --data-dir")
+
+	stateCmd.AddCommand(stateMigrateCmd)
+	rootCmd.AddCommand(stateCmd)
+}
@@ -14,7 +14,9 @@ An error is thrown if incorrect arguments given.
 Refer to the portfolios by name.
 Names are case sensitive.
 Use 'portfolio' command to see list of portfolios.
-example: 'transfer-funds default test 10'`,
+The amount is in the sender's cash currency unless --currency is given.
+example: 'transfer-funds default test 10'
+example: 'transfer-funds default test 10 --currency usd'`,
 	RunE: nil,
 }
 
@@ -22,6 +24,7 @@ func init() {
 	internalHttpClient := infrastructure.GetDefaultInvestmentManagerInternalHttpClient()
 	transferFundsHandler := handlers.TransferFundsHandlerFactory(internalHttpClient)
 	transferFundsCommand.RunE = transferFundsHandler
+	transferFundsCommand.Flags().String("currency", "", "Currency to transfer (default the sender's cash currency)")
 
 	rootCmd.AddCommand(transferFundsCommand)
 }
@@ -1,25 +1,96 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
 	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
 )
 
+const (
+	DefaultServerAddress = "127.0.0.1:5000"
+	ConfigFilename       = "config.yaml"
+	ProfileEnvVar        = "INVESTMENT_MANAGER_PROFILE"
+)
+
+// Config is layered, each layer overrides the one before it:
+// defaults, config.yaml, the selected profile in config.yaml, .env and the environment, then flags.
+// Flags are applied by the server and CLI on the Config returned by GetConfig.
 type Config struct {
-	ApiKeyPath    string
-	isInitialized bool
+	Profile             string        `yaml:"-"` // Name of the profile in use, empty when there isn't one
+	ApiKeyPath          string        `yaml:"api_key_path"`
+	ServerAddress       string        `yaml:"server_address"` // Address the server listens on and the CLI sends requests to
+	Exchange            string        `yaml:"exchange"`       // Exchange the server trades against, "coinbase" or "paper"
+	PaperFunds          float64       `yaml:"paper_funds"`    // Starting fiat balance of the paper portfolio
+	PaperCurrency       string        `yaml:"paper_currency"`
+	AllowedCurrencies   []string      `yaml:"allowed_currencies"` // Currencies strategies can trade, every currency with a product is allowed when empty
+	StateBackend        string        `yaml:"state_backend"`      // Where state is stored, "json" or "sqlite"
+	MakerCommissionRate float64       `yaml:"maker_commission_rate"`
+	TakerCommissionRate float64       `yaml:"taker_commission_rate"`
+	OrderExpiry         time.Duration `yaml:"order_expiry"` // How long GTD limit orders rest before they expire
+	ReconcileInterval   time.Duration `yaml:"reconcile_interval"`
+	ProtectionInterval  time.Duration `yaml:"protection_interval"`
+	ProductCacheTTL     time.Duration `yaml:"product_cache_ttl"`
+	isInitialized       bool
 }
 
-var config = Config{
-	ApiKeyPath:    "",
-	isInitialized: false,
+// configFile is config.yaml, settings at the top level apply to every profile.
+type configFile struct {
+	Config   `yaml:",inline"`
+	Profile  string               `yaml:"profile"` // Profile used when none is given by flag or environment
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+type profileFile struct {
+	Config `yaml:",inline"`
+}
+
+var (
+	configMu    sync.Mutex
+	config      = GetDefaultConfig()
+	profileFlag string
+)
+
+func GetDefaultConfig() Config {
+	return Config{
+		ServerAddress:       DefaultServerAddress,
+		Exchange:            "coinbase",
+		PaperFunds:          1000,
+		PaperCurrency:       "GBP",
+		StateBackend:        "json",
+		MakerCommissionRate: types.MakerCommissionRate,
+		TakerCommissionRate: types.TakerCommissionRate,
+		OrderExpiry:         types.DefaultOrderExpiry,
+		ReconcileInterval:   time.Second * 30,
+		ProtectionInterval:  time.Second * 30,
+		ProductCacheTTL:     time.Hour,
+	}
+}
+
+// SetProfile selects a profile from config.yaml, e.g. from a --profile flag. It has to be called before GetConfig.
+func SetProfile(profile string) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
+	profileFlag = profile
 }
 
 func GetConfig() (*Config, error) {
+	configMu.Lock()
+	defer configMu.Unlock()
+
 	if !config.isInitialized {
 		err := initConfig()
 		if err != nil {
@@ -30,45 +101,277 @@ func GetConfig() (*Config, error) {
 	return &config, nil
 }
 
+// GetOrderSettings returns the commission rates and expiry strategies place orders with.
+// The rates start at the defaults, so a configured rate is used as it is, even 0.
+func (c *Config) GetOrderSettings() types.OrderSettings {
+	makerCommissionRate := c.MakerCommissionRate
+	takerCommissionRate := c.TakerCommissionRate
+
+	return types.OrderSettings{
+		MakerCommissionRate: &makerCommissionRate,
+		TakerCommissionRate: &takerCommissionRate,
+		OrderExpiry:         c.OrderExpiry,
+	}
+}
+
 func initConfig() error {
-	pathToWorkingDir, err := os.Getwd()
+	configDir, err := util.GetConfigDir()
 
 	if err != nil {
-		fmt.Printf("Could not get working directory")
 		return err
 	}
 
-	projectRootDir := "investment-manager"
-	pathTokens := strings.Split(pathToWorkingDir, "/")
-	var pathToEnvFile string
+	err = loadEnvFile(configDir)
 
-	for _, token := range pathTokens {
-		if token == "" {
-			continue
+	if err != nil {
+		return err
+	}
+
+	loaded, err := loadConfig(configDir, profileFlag)
+
+	if err != nil {
+		return err
+	}
+
+	config = loaded
+	config.isInitialized = true
+	return nil
+}
+
+// loadConfig layers config.yaml in configDir and the environment over the defaults.
+// The profile is the one given, then $INVESTMENT_MANAGER_PROFILE, then the profile named in config.yaml.
+func loadConfig(configDir, profile string) (Config, error) {
+	location := "loadConfig()\n"
+	file := configFile{Config: GetDefaultConfig()}
+	pathToConfigFile := filepath.Join(configDir, ConfigFilename)
+	data, err := os.ReadFile(pathToConfigFile)
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf(location+"Failed to read %s\n%v\n", pathToConfigFile, err)
+	}
+
+	if err == nil {
+		err = decodeStrict(data, &file)
+
+		if err != nil {
+			return Config{}, fmt.Errorf(location+"Invalid %s\n%v\n", pathToConfigFile, err)
 		}
+	}
 
-		pathToEnvFile += "/" + token
+	if profile == "" {
+		profile = os.Getenv(ProfileEnvVar)
+	}
 
-		if token == projectRootDir {
-			break
+	if profile == "" {
+		profile = file.Profile
+	}
+
+	cfg := file.Config
+
+	if profile != "" {
+		node, ok := file.Profiles[profile]
+
+		if !ok {
+			return Config{}, fmt.Errorf(location+"Profile %q not found in %s, available profiles: %s\n", profile, pathToConfigFile, strings.Join(getProfileNames(file.Profiles), ", "))
 		}
+
+		profileData, err := yaml.Marshal(&node)
+
+		if err != nil {
+			return Config{}, err
+		}
+
+		selected := profileFile{Config: cfg}
+		err = decodeStrict(profileData, &selected)
+
+		if err != nil {
+			return Config{}, fmt.Errorf(location+"Invalid profile %q in %s\n%v\n", profile, pathToConfigFile, err)
+		}
+
+		cfg = selected.Config
+		cfg.Profile = profile
 	}
 
-	pathToEnvFile += "/.env"
+	err = applyEnv(&cfg)
 
-	err = godotenv.Load(pathToEnvFile)
+	if err != nil {
+		return Config{}, err
+	}
+
+	err = cfg.Validate()
 
 	if err != nil {
-		fmt.Printf("Error loading .env\n%v\n", err)
-		return err
+		return Config{}, fmt.Errorf(location+"%v", err)
 	}
 
-	config.ApiKeyPath = os.Getenv("API_KEY_PATH")
+	// A relative key path is kept beside the config
+	if cfg.ApiKeyPath != "" && !filepath.IsAbs(cfg.ApiKeyPath) {
+		cfg.ApiKeyPath = filepath.Join(configDir, cfg.ApiKeyPath)
+	}
 
-	if config.ApiKeyPath == "" {
-		return errors.New("API Key Path Not Set")
+	for i, currency := range cfg.AllowedCurrencies {
+		cfg.AllowedCurrencies[i] = strings.ToUpper(strings.TrimSpace(currency))
+	}
+
+	return cfg, nil
+}
+
+// Validate checks settings that would otherwise only fail once the server is trading.
+// It's called again by the server after flags are applied.
+func (c *Config) Validate() error {
+	c.StateBackend = strings.ToLower(c.StateBackend)
+
+	if c.StateBackend != "json" && c.StateBackend != "sqlite" {
+		return fmt.Errorf("Unsupported state backend: %q, expected json or sqlite\n", c.StateBackend)
+	}
+
+	if c.Exchange != "coinbase" && c.Exchange != "paper" {
+		return fmt.Errorf("Unsupported exchange: %q, expected coinbase or paper\n", c.Exchange)
+	}
+
+	if c.ServerAddress == "" {
+		return errors.New("Server address is empty")
+	}
+
+	if c.MakerCommissionRate < 0 || c.MakerCommissionRate >= 1 || c.TakerCommissionRate < 0 || c.TakerCommissionRate >= 1 {
+		return fmt.Errorf("Commission rates must be a fraction between 0 and 1, e.g. 0.006 for 0.6%%\nGiven: maker %v, taker %v\n", c.MakerCommissionRate, c.TakerCommissionRate)
+	}
+
+	if c.OrderExpiry < time.Minute {
+		return fmt.Errorf("Order expiry must be at least a minute\nGiven: %v\n", c.OrderExpiry)
+	}
+
+	if c.ReconcileInterval <= 0 || c.ProtectionInterval <= 0 || c.ProductCacheTTL <= 0 {
+		return errors.New("Reconcile interval, protection interval and product cache TTL must be positive")
+	}
+
+	return nil
+}
+
+// envOverrides are the environment variables, or .env entries, that override config.yaml.
+var envOverrides = map[string]func(cfg *Config, value string) error{
+	"API_KEY_PATH":   func(cfg *Config, value string) error { cfg.ApiKeyPath = value; return nil },
+	"SERVER_ADDRESS": func(cfg *Config, value string) error { cfg.ServerAddress = value; return nil },
+	"EXCHANGE":       func(cfg *Config, value string) error { cfg.Exchange = strings.ToLower(value); return nil },
+	"STATE_BACKEND":  func(cfg *Config, value string) error { cfg.StateBackend = value; return nil },
+	"PAPER_CURRENCY": func(cfg *Config, value string) error { cfg.PaperCurrency = value; return nil },
+	// e.g. ALLOWED_CURRENCIES=BTC,ETH,SOL
+	"ALLOWED_CURRENCIES": func(cfg *Config, value string) error {
+		cfg.AllowedCurrencies = nil
+
+		for _, currency := range strings.Split(value, ",") {
+			if strings.TrimSpace(currency) != "" {
+				cfg.AllowedCurrencies = append(cfg.AllowedCurrencies, currency)
+			}
+		}
+
+		return nil
+	},
+	"PAPER_FUNDS":           parseFloatEnv(func(cfg *Config) *float64 { return &cfg.PaperFunds }),
+	"MAKER_COMMISSION_RATE": parseFloatEnv(func(cfg *Config) *float64 { return &cfg.MakerCommissionRate }),
+	"TAKER_COMMISSION_RATE": parseFloatEnv(func(cfg *Config) *float64 { return &cfg.TakerCommissionRate }),
+	"ORDER_EXPIRY":          parseDurationEnv(func(cfg *Config) *time.Duration { return &cfg.OrderExpiry }),
+	"RECONCILE_INTERVAL":    parseDurationEnv(func(cfg *Config) *time.Duration { return &cfg.ReconcileInterval }),
+	"PROTECTION_INTERVAL":   parseDurationEnv(func(cfg *Config) *time.Duration { return &cfg.ProtectionInterval }),
+	"PRODUCT_CACHE_TTL":     parseDurationEnv(func(cfg *Config) *time.Duration { return &cfg.ProductCacheTTL }),
+}
+
+func applyEnv(cfg *Config) error {
+	for name, override := range envOverrides {
+		value := strings.TrimSpace(os.Getenv(name))
+
+		if value == "" {
+			continue
+		}
+
+		err := override(cfg, value)
+
+		if err != nil {
+			return fmt.Errorf("Invalid %s: %q\n%v\n", name, value, err)
+		}
+	}
+
+	return nil
+}
+
+func parseFloatEnv(field func(cfg *Config) *float64) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := strconv.ParseFloat(value, 64)
+
+		if err != nil {
+			return err
+		}
+
+		*field(cfg) = parsed
+
+		return nil
+	}
+}
+
+func parseDurationEnv(field func(cfg *Config) *time.Duration) func(cfg *Config, value string) error {
+	return func(cfg *Config, value string) error {
+		parsed, err := time.ParseDuration(value)
+
+		if err != nil {
+			return err
+		}
+
+		*field(cfg) = parsed
+
+		return nil
+	}
+}
+
+// decodeStrict decodes YAML into out, failing on keys it doesn't know so typos aren't silently ignored.
+func decodeStrict(data []byte, out any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(out)
+
+	// An empty file or profile has nothing to decode
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+
+	return err
+}
+
+func getProfileNames(profiles map[string]yaml.Node) []string {
+	names := []string{}
+
+	for name := range profiles {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// loadEnvFile loads the first .env found in the config directory then the working directory.
+// Variables already in the environment win. .env is optional, everything in it can be set in config.yaml instead.
+func loadEnvFile(configDir string) error {
+	searched := []string{filepath.Join(configDir, ".env")}
+
+	if workingDir, err := os.Getwd(); err == nil {
+		searched = append(searched, filepath.Join(workingDir, ".env"))
+	}
+
+	for _, pathToEnvFile := range searched {
+		_, err := os.Stat(pathToEnvFile)
+
+		if err != nil {
+			continue
+		}
+
+		err = godotenv.Load(pathToEnvFile)
+
+		if err != nil {
+			return fmt.Errorf("Error loading %s\n%v\n", pathToEnvFile, err)
+		}
+
+		return nil
 	}
 
-	config.isInitialized = true
 	return nil
 }
@@ -0,0 +1,172 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadConfig(t *testing.T) {
+	const configFile = `
+profile: live
+exchange: coinbase
+allowed_currencies: [btc, eth]
+order_expiry: 10m
+profiles:
+  live:
+    api_key_path: live_key.json
+  paper:
+    exchange: paper
+    server_address: 127.0.0.1:5001
+    paper_funds: 500
+    taker_commission_rate: 0.0025
+`
+
+	setup := func(t *testing.T, contents string) string {
+		// Keep the environment running the tests out of the layers
+		for name := range envOverrides {
+			t.Setenv(name, "")
+		}
+
+		t.Setenv(ProfileEnvVar, "")
+
+		configDir := t.TempDir()
+
+		if contents != "" {
+			err := os.WriteFile(filepath.Join(configDir, ConfigFilename), []byte(contents), 0666)
+
+			if err != nil {
+				t.Fatalf("Failed to write config\n%v\n", err)
+			}
+		}
+
+		return configDir
+	}
+
+	t.Run("Uses the defaults without a config file", func(t *testing.T) {
+		configDir := setup(t, "")
+
+		cfg, err := loadConfig(configDir, "")
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if !reflect.DeepEqual(cfg, GetDefaultConfig()) {
+			t.Errorf("Expected the defaults\nActual: %+v", cfg)
+		}
+	})
+
+	t.Run("Uses the profile named in the file", func(t *testing.T) {
+		configDir := setup(t, configFile)
+
+		cfg, err := loadConfig(configDir, "")
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if cfg.Profile != "live" || cfg.ApiKeyPath != filepath.Join(configDir, "live_key.json") {
+			t.Errorf("Expected the live profile with its key beside the config\nActual: %q %q", cfg.Profile, cfg.ApiKeyPath)
+		}
+
+		if !reflect.DeepEqual(cfg.AllowedCurrencies, []string{"BTC", "ETH"}) || cfg.OrderExpiry != time.Minute*10 {
+			t.Errorf("Expected top level settings to apply\nActual: %v %v", cfg.AllowedCurrencies, cfg.OrderExpiry)
+		}
+	})
+
+	t.Run("Layers the given profile over the file and the environment over both", func(t *testing.T) {
+		configDir := setup(t, configFile)
+		t.Setenv("PAPER_FUNDS", "250")
+
+		cfg, err := loadConfig(configDir, "paper")
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if cfg.Exchange != "paper" || cfg.ServerAddress != "127.0.0.1:5001" || cfg.TakerCommissionRate != 0.0025 {
+			t.Errorf("Expected the paper profile to override the file\nActual: %+v", cfg)
+		}
+
+		if cfg.PaperFunds != 250 {
+			t.Errorf("Expected PAPER_FUNDS to override the profile, paper funds: %v", cfg.PaperFunds)
+		}
+
+		if cfg.OrderExpiry != time.Minute*10 || cfg.MakerCommissionRate != GetDefaultConfig().MakerCommissionRate {
+			t.Errorf("Expected settings the profile doesn't set to be kept\nActual: %+v", cfg)
+		}
+
+		orderSettings := cfg.GetOrderSettings()
+
+		if orderSettings.CommissionRate(true) != 0.0025 {
+			t.Errorf("Expected the taker rate from the profile, got %v", orderSettings.CommissionRate(true))
+		}
+	})
+
+	t.Run("Keeps a commission rate of 0", func(t *testing.T) {
+		configDir := setup(t, "maker_commission_rate: 0\n")
+
+		cfg, err := loadConfig(configDir, "")
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		orderSettings := cfg.GetOrderSettings()
+
+		if orderSettings.CommissionRate(false) != 0 || orderSettings.CommissionRate(true) != GetDefaultConfig().TakerCommissionRate {
+			t.Errorf("Expected a maker rate of 0 and the default taker rate, got %v and %v", orderSettings.CommissionRate(false), orderSettings.CommissionRate(true))
+		}
+	})
+
+	t.Run("Selects a profile from the environment", func(t *testing.T) {
+		configDir := setup(t, configFile)
+		t.Setenv(ProfileEnvVar, "paper")
+
+		cfg, err := loadConfig(configDir, "")
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if cfg.Profile != "paper" {
+			t.Errorf("Expected the paper profile, got %q", cfg.Profile)
+		}
+	})
+
+	t.Run("Fails for a profile that isn't in the file", func(t *testing.T) {
+		configDir := setup(t, configFile)
+
+		_, err := loadConfig(configDir, "staging")
+
+		if err == nil || !strings.Contains(err.Error(), "live, paper") {
+			t.Errorf("Expected an error listing the profiles\nActual: %v", err)
+		}
+	})
+
+	t.Run("Fails for unknown or invalid settings", func(t *testing.T) {
+		for _, contents := range []string{
+			"exchnage: paper\n",
+			"profiles:\n  paper:\n    exchnage: paper\n",
+			"state_backend: postgres\n",
+			"order_expiry: soon\n",
+		} {
+			configDir := setup(t, contents)
+			profile := ""
+
+			if strings.HasPrefix(contents, "profiles") {
+				profile = "paper"
+			}
+
+			_, err := loadConfig(configDir, profile)
+
+			if err == nil {
+				t.Errorf("Expected an error for %q", contents)
+			}
+		}
+	})
+}
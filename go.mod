@@ -6,7 +6,9 @@ require (
 	github.com/fossoreslp/go-uuid-v4 v1.0.0
 	github.com/go-jose/go-jose/v4 v4.0.0
 	github.com/joho/godotenv v1.5.1
+	github.com/mattn/go-sqlite3 v1.14.22
 	github.com/spf13/cobra v1.8.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
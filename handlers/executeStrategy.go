@@ -3,7 +3,9 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/iPopcorn/investment-manager/infrastructure"
 	"github.com/iPopcorn/investment-manager/types"
@@ -14,32 +16,98 @@ func ExecuteStrategyHandlerFactory(client *infrastructure.InvestmentManagerInter
 	return func(cmd *cobra.Command, args []string) error {
 		fmt.Printf("ExecuteStrategy called\nargs: %v", args)
 
+		if len(args) == 2 && strings.ToUpper(args[1]) == string(types.REBALANCE) {
+			return rebalance(cmd, args[0], client)
+		}
+
 		if len(args) != 3 {
 			return fmt.Errorf("Expected 3 args, received %d args", len(args))
 		}
 
-		portfolio := args[0]
-		strategy := args[1]
-		currency := args[2]
+		request := &types.ExecuteStrategyRequest{
+			Portfolio:     args[0],
+			MaxAttempts:   getIntFlag(cmd, "max-attempts"),
+			MaxPriceDrift: getFloatFlag(cmd, "max-drift"),
+			OrderType:     types.OrderType(strings.ToLower(getStringFlag(cmd, "order-type"))),
+			QuoteCurrency: strings.ToUpper(getStringFlag(cmd, "quote-currency")),
+		}
+
+		request.Protection = getProtection(cmd)
+
+		if slices := getIntFlag(cmd, "slices"); slices > 0 {
+			request.TWAP = &types.TWAPPlan{
+				Slices:   slices,
+				Duration: getStringFlag(cmd, "duration"),
+			}
+		}
+
+		if trailingStopPercent := getFloatFlag(cmd, "trailing-stop"); trailingStopPercent > 0 {
+			request.TrailingStop = &types.TrailingStop{Percent: trailingStopPercent}
+		}
+
+		if strings.ToUpper(args[1]) == string(types.DCA) {
+			request.DCA = &types.DCAPlan{
+				AmountPerBuy: getDecimalFlag(cmd, "amount"),
+				Interval:     getStringFlag(cmd, "interval"),
+				TotalBudget:  getDecimalFlag(cmd, "budget"),
+				NumberOfBuys: getIntFlag(cmd, "buys"),
+			}
+		}
+
+		if strings.ToUpper(args[1]) == string(types.GRID) {
+			request.Grid = &types.GridPlan{
+				LowerPrice: getDecimalFlag(cmd, "lower"),
+				UpperPrice: getDecimalFlag(cmd, "upper"),
+				Levels:     getIntFlag(cmd, "levels"),
+				Capital:    getDecimalFlag(cmd, "capital"),
+			}
+		}
 
-		return executeStrategy(portfolio, strategy, currency, client)
+		return executeStrategy(request, args[1], args[2], client)
 	}
 }
 
-func executeStrategy(portfolio, strategy, currency string, client *infrastructure.InvestmentManagerInternalHttpClient) error {
-	if strings.ToUpper(strategy) != string(types.HODL) {
-		return fmt.Errorf("Invalid strategy\nGiven: %q Expected: %q\n", strategy, string(types.HODL))
+func executeStrategy(request *types.ExecuteStrategyRequest, strategy, currency string, client *infrastructure.InvestmentManagerInternalHttpClient) error {
+	strategyName := types.StrategyName(strings.ToUpper(strategy))
+
+	if request.TWAP != nil {
+		if strategyName != types.HODL {
+			return fmt.Errorf("--slices is only supported by the HODL strategy\n")
+		}
+
+		_, err := time.ParseDuration(request.TWAP.Duration)
+
+		if err != nil {
+			return fmt.Errorf("TWAP requires a valid --duration, e.g. 2h\nGiven: %q\n", request.TWAP.Duration)
+		}
 	}
 
-	if strings.ToUpper(currency) != string(types.ETH) {
-		return fmt.Errorf("Invalid currency\nGiven: %q Expected: %q\n", currency, string(types.ETH))
+	switch strategyName {
+	case types.HODL:
+	case types.DCA:
+		err := types.ValidateDCAPlan(request.DCA)
+
+		if err != nil {
+			return err
+		}
+	case types.GRID:
+		err := types.ValidateGridPlan(request.Grid)
+
+		if err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Invalid strategy\nGiven: %q Expected one of: %s, %s, %s, %s\n", strategy, types.HODL, types.DCA, types.REBALANCE, types.GRID)
 	}
 
-	request := &types.ExecuteStrategyRequest{
-		Portfolio: portfolio,
-		Strategy:  types.HODL,
-		Currency:  types.ETH,
+	// The server checks the currency against the exchange's products
+	if strings.TrimSpace(currency) == "" {
+		return fmt.Errorf("Invalid currency\nGiven: %q Expected a currency such as BTC or ETH\n", currency)
 	}
+
+	request.Strategy = strategyName
+	request.Currency = types.SupportedCurrency(strings.ToUpper(strings.TrimSpace(currency)))
+
 	serializedRequest, err := json.Marshal(request)
 
 	if err != nil {
@@ -56,3 +124,107 @@ func executeStrategy(portfolio, strategy, currency string, client *infrastructur
 
 	return nil
 }
+
+// getProtection returns the stop-loss and take-profit from the flags, or nil if none are set.
+func getProtection(cmd *cobra.Command) *types.Protection {
+	protection := &types.Protection{
+		StopLossPrice:     getDecimalFlag(cmd, "stop-loss"),
+		StopLossPercent:   getFloatFlag(cmd, "stop-loss-percent"),
+		TakeProfitPrice:   getDecimalFlag(cmd, "take-profit"),
+		TakeProfitPercent: getFloatFlag(cmd, "take-profit-percent"),
+	}
+
+	if protection.IsEmpty() {
+		return nil
+	}
+
+	return protection
+}
+
+func rebalance(cmd *cobra.Command, portfolio string, client *infrastructure.InvestmentManagerInternalHttpClient) error {
+	targets, err := parseTargets(getStringSliceFlag(cmd, "target"))
+
+	if err != nil {
+		return err
+	}
+
+	request := &types.ExecuteStrategyRequest{
+		Portfolio:     portfolio,
+		Strategy:      types.REBALANCE,
+		QuoteCurrency: strings.ToUpper(getStringFlag(cmd, "quote-currency")),
+		Rebalance: &types.RebalancePlan{
+			Targets:   targets,
+			Tolerance: getFloatFlag(cmd, "tolerance"),
+			Preview:   getBoolFlag(cmd, "preview"),
+		},
+	}
+
+	serializedRequest, err := json.Marshal(request)
+
+	if err != nil {
+		fmt.Printf("Failed to serialize request: %v", err)
+		return err
+	}
+
+	response, err := client.Post("/execute-strategy", serializedRequest)
+
+	if err != nil {
+		fmt.Printf("Request failed: %v\n", err)
+		return err
+	}
+
+	var plan types.RebalancePlan
+	err = json.Unmarshal(response, &plan)
+
+	if err != nil {
+		return fmt.Errorf("Failed to deserialize rebalance plan\n%v\n", err)
+	}
+
+	showRebalancePlan(&plan)
+
+	return nil
+}
+
+// parseTargets turns "ASSET=WEIGHT" pairs into target weights.
+func parseTargets(pairs []string) (map[string]float64, error) {
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("Rebalance requires at least one --target, e.g. --target ETH=30\n")
+	}
+
+	targets := map[string]float64{}
+
+	for _, pair := range pairs {
+		asset, weight, found := strings.Cut(pair, "=")
+
+		if !found {
+			return nil, fmt.Errorf("Invalid target, expected ASSET=WEIGHT\nGiven: %q\n", pair)
+		}
+
+		weightFloat, err := strconv.ParseFloat(weight, 64)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid target weight\nGiven: %q\n", pair)
+		}
+
+		targets[strings.ToUpper(asset)] = weightFloat
+	}
+
+	return targets, nil
+}
+
+func showRebalancePlan(plan *types.RebalancePlan) {
+	if plan.Preview {
+		fmt.Println("Rebalance preview, no orders placed")
+	} else {
+		fmt.Println("Rebalancing")
+	}
+
+	if len(plan.Trades) == 0 {
+		fmt.Printf("All assets are within %.2f%% of their targets\n", plan.Tolerance)
+		return
+	}
+
+	for _, trade := range plan.Trades {
+		fmt.Printf("%s %s %s at %s (%.2f) - %.2f%% -> %.2f%%\n", trade.Side, trade.BaseSize, trade.ProductID, trade.LimitPrice, trade.Amount.Float64(), trade.CurrentWeight, trade.TargetWeight)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"testing"
 
 	"github.com/iPopcorn/investment-manager/handlers"
@@ -33,6 +34,16 @@ func (testClient *TestExecuteStrategyHttpClient) Do(req *http.Request) (*http.Re
 	return &resp, nil
 }
 
+// TestRejectingHttpClient responds to every request with the given status and body.
+type TestRejectingHttpClient struct {
+	status int
+	body   string
+}
+
+func (testClient *TestRejectingHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: testClient.status, Body: io.NopCloser(bytes.NewBufferString(testClient.body))}, nil
+}
+
 func TestExecuteStrategy(t *testing.T) {
 	t.Run("Fails to execute a strategy that is already running", func(t *testing.T) {
 
@@ -53,4 +64,48 @@ func TestExecuteStrategy(t *testing.T) {
 			t.Fatalf("Expected error but did not receive one")
 		}
 	})
+
+	t.Run("Fails to execute DCA without an amount and schedule", func(t *testing.T) {
+		testHttpClient := &TestExecuteStrategyHttpClient{counter: 0}
+		testInternalClient := infrastructure.InvestmentManagerInternalHttpClientFactory(testHttpClient, "")
+		testHandler := handlers.ExecuteStrategyHandlerFactory(testInternalClient)
+
+		err := testHandler(testutils.TestCmd, []string{"test", "dca", "eth"})
+
+		if err == nil {
+			t.Fatalf("Expected error but did not receive one")
+		}
+
+		if testHttpClient.counter != 0 {
+			t.Errorf("Expected no request to be sent, sent %d", testHttpClient.counter)
+		}
+	})
+
+	t.Run("Fails to rebalance without target weights", func(t *testing.T) {
+		testHttpClient := &TestExecuteStrategyHttpClient{counter: 0}
+		testInternalClient := infrastructure.InvestmentManagerInternalHttpClientFactory(testHttpClient, "")
+		testHandler := handlers.ExecuteStrategyHandlerFactory(testInternalClient)
+
+		err := testHandler(testutils.TestCmd, []string{"test", "rebalance"})
+
+		if err == nil {
+			t.Fatalf("Expected error but did not receive one")
+		}
+
+		if testHttpClient.counter != 0 {
+			t.Errorf("Expected no request to be sent, sent %d", testHttpClient.counter)
+		}
+	})
+
+	t.Run("Shows why the server rejected the strategy", func(t *testing.T) {
+		testHttpClient := &TestRejectingHttpClient{status: http.StatusBadRequest, body: `{"error": "Invalid currency\nGiven: \"XYZ\""}`}
+		testInternalClient := infrastructure.InvestmentManagerInternalHttpClientFactory(testHttpClient, "")
+		testHandler := handlers.ExecuteStrategyHandlerFactory(testInternalClient)
+
+		err := testHandler(testutils.TestCmd, []string{"test", "hodl", "xyz"})
+
+		if err == nil || !strings.Contains(err.Error(), "Invalid currency") {
+			t.Errorf("Expected the server's reason in the error\nActual: %v", err)
+		}
+	})
 }
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/spf13/cobra"
+)
+
+// The flag helpers return the zero value when a command does not define the flag,
+// so handlers can be called with commands that only take positional args.
+
+func getIntFlag(cmd *cobra.Command, name string) int {
+	value, err := cmd.Flags().GetInt(name)
+
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
+
+func getFloatFlag(cmd *cobra.Command, name string) float64 {
+	value, err := cmd.Flags().GetFloat64(name)
+
+	if err != nil {
+		return 0
+	}
+
+	return value
+}
+
+// getDecimalFlag reads a float flag as a Decimal, keeping the digits the user typed.
+func getDecimalFlag(cmd *cobra.Command, name string) types.Decimal {
+	return types.NewDecimalFromFloat(getFloatFlag(cmd, name))
+}
+
+func getStringFlag(cmd *cobra.Command, name string) string {
+	value, err := cmd.Flags().GetString(name)
+
+	if err != nil {
+		return ""
+	}
+
+	return value
+}
+
+func getBoolFlag(cmd *cobra.Command, name string) bool {
+	value, err := cmd.Flags().GetBool(name)
+
+	if err != nil {
+		return false
+	}
+
+	return value
+}
+
+func getStringSliceFlag(cmd *cobra.Command, name string) []string {
+	value, err := cmd.Flags().GetStringSlice(name)
+
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
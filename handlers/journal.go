@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/spf13/cobra"
+)
+
+func JournalHandlerFactory(client *infrastructure.InvestmentManagerInternalHttpClient) CobraCommandHandler {
+	return func(cmd *cobra.Command, args []string) error {
+		query, err := getJournalQuery(cmd, client)
+
+		if err != nil {
+			return err
+		}
+
+		response, err := client.Get("/" + string(types.Journal) + "?" + query.Encode())
+
+		if err != nil {
+			return fmt.Errorf("Request failed: %v\n", err)
+		}
+
+		var journalResp types.JournalResponse
+		err = json.Unmarshal(response, &journalResp)
+
+		if err != nil {
+			return fmt.Errorf("Journal request was rejected, check the server logs for details\n")
+		}
+
+		if len(journalResp.Entries) == 0 {
+			fmt.Println("No journal entries found")
+			return nil
+		}
+
+		replay := getBoolFlag(cmd, "replay")
+
+		for _, entry := range journalResp.Entries {
+			if replay {
+				replayJournalEntry(entry)
+			} else {
+				showJournalEntry(entry)
+			}
+		}
+
+		return nil
+	}
+}
+
+func getJournalQuery(cmd *cobra.Command, client *infrastructure.InvestmentManagerInternalHttpClient) (url.Values, error) {
+	query := url.Values{}
+
+	if portfolio := getStringFlag(cmd, "portfolio"); portfolio != "" {
+		portfolioID, err := getPortfolioIdFromName(client, portfolio)
+
+		if err != nil {
+			return nil, err
+		}
+
+		query.Set("portfolio_id", portfolioID)
+	}
+
+	if event := getStringFlag(cmd, "event"); event != "" {
+		query.Set("event", strings.ToLower(event))
+	}
+
+	if product := getStringFlag(cmd, "product"); product != "" {
+		query.Set("product_id", strings.ToUpper(product))
+	}
+
+	// --since takes a duration back from now, or a timestamp
+	if since := getStringFlag(cmd, "since"); since != "" {
+		if duration, err := time.ParseDuration(since); err == nil {
+			since = time.Now().Add(-duration).Format(time.RFC3339)
+		} else if _, err := time.Parse(time.RFC3339, since); err != nil {
+			return nil, fmt.Errorf("Invalid --since, expected a duration e.g. 24h or an RFC3339 timestamp\nGiven: %q\n", since)
+		}
+
+		query.Set("since", since)
+	}
+
+	return query, nil
+}
+
+func showJournalEntry(entry types.JournalEntry) {
+	fmt.Printf("%s %s", entry.Time, entry.Event)
+
+	if entry.Strategy != "" {
+		fmt.Printf(" %s", entry.Strategy)
+	}
+
+	if entry.ProductID != "" {
+		fmt.Printf(" %s %s", entry.Side, entry.ProductID)
+	}
+
+	if entry.OrderID != "" {
+		fmt.Printf(" order: %s", entry.OrderID)
+	}
+
+	if entry.Transfer != nil {
+		fmt.Printf(" %s %s to %s", entry.Transfer.Amount, entry.Transfer.Currency, entry.Transfer.ReceiverID)
+	}
+
+	if entry.Error != "" {
+		fmt.Printf(" error: %s", entry.Error)
+	}
+
+	fmt.Println()
+}
+
+// replayJournalEntry shows everything recorded for the entry, in the order it happened.
+func replayJournalEntry(entry types.JournalEntry) {
+	fmt.Println()
+	showJournalEntry(entry)
+
+	if entry.PortfolioID != "" {
+		fmt.Printf(" Portfolio: %s\n", entry.PortfolioID)
+	}
+
+	if entry.StrategyID != "" {
+		fmt.Printf(" Strategy ID: %s\n", entry.StrategyID)
+	}
+
+	if entry.ClientOrderID != "" {
+		fmt.Printf(" Client order ID: %s\n", entry.ClientOrderID)
+	}
+
+	if inputs := entry.Inputs; inputs != nil {
+		if inputs.Reason != "" {
+			fmt.Printf(" Reason: %s\n", inputs.Reason)
+		}
+
+		if inputs.BestBid != "" || inputs.BestAsk != "" {
+			fmt.Printf(" Best bid: %s Best ask: %s\n", inputs.BestBid, inputs.BestAsk)
+		}
+
+		if inputs.AvailableFunds != "" {
+			fmt.Printf(" Available funds: %s Commission estimate: %s\n", inputs.AvailableFunds, inputs.CommissionEstimate)
+		}
+	}
+
+	if entry.Order != nil {
+		orderType, _ := entry.Order.OrderType()
+		serializedOrder, _ := json.Marshal(entry.Order)
+		fmt.Printf(" Order: %s %s\n", orderType, serializedOrder)
+	}
+
+	if len(entry.Response) > 0 {
+		var indented bytes.Buffer
+
+		if json.Indent(&indented, entry.Response, " ", "  ") == nil {
+			fmt.Printf(" Response: %s\n", indented.String())
+		} else {
+			fmt.Printf(" Response: %s\n", entry.Response)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/spf13/cobra"
+)
+
+func ListOrdersHandlerFactory(client *infrastructure.InvestmentManagerInternalHttpClient) CobraCommandHandler {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != 1 {
+			return fmt.Errorf("Unexpected number of args.\nExpected 1, Received %d", len(args))
+		}
+
+		portfolioID, err := getPortfolioIdFromName(client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		response, err := client.Get("/" + string(types.Orders) + "/" + portfolioID)
+
+		if err != nil {
+			return fmt.Errorf("Request failed: %v\n", err)
+		}
+
+		var openOrders types.OpenOrdersResponse
+		err = json.Unmarshal(response, &openOrders)
+
+		if err != nil {
+			return fmt.Errorf("Failed to deserialize open orders\n%v\n", err)
+		}
+
+		showOpenOrders(&openOrders)
+
+		return nil
+	}
+}
+
+func CancelOrdersHandlerFactory(client *infrastructure.InvestmentManagerInternalHttpClient) CobraCommandHandler {
+	return func(cmd *cobra.Command, args []string) error {
+		all := getBoolFlag(cmd, "all")
+
+		if len(args) < 1 {
+			return fmt.Errorf("Expected a portfolio name\n")
+		}
+
+		if all == (len(args) > 1) {
+			return fmt.Errorf("Give either order ids or --all\n")
+		}
+
+		portfolioID, err := getPortfolioIdFromName(client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		request := types.CancelOrdersRequest{
+			PortfolioID: portfolioID,
+			OrderIDs:    args[1:],
+			All:         all,
+		}
+
+		serializedRequest, err := json.Marshal(request)
+
+		if err != nil {
+			return fmt.Errorf("Failed to serialize request\n%v\n", err)
+		}
+
+		response, err := client.Post("/"+string(types.Orders)+"/cancel", serializedRequest)
+
+		if err != nil {
+			return fmt.Errorf("Request failed: %v\n", err)
+		}
+
+		var cancelResp types.CancelOrdersResponse
+		err = json.Unmarshal(response, &cancelResp)
+
+		if err != nil {
+			return fmt.Errorf("Cancel was rejected, check the server logs for details\n")
+		}
+
+		if len(cancelResp.Results) == 0 {
+			fmt.Println("No open orders to cancel")
+		}
+
+		for _, result := range cancelResp.Results {
+			if result.Success {
+				fmt.Printf("Cancelled %s\n", result.OrderID)
+			} else {
+				fmt.Printf("Failed to cancel %s: %s\n", result.OrderID, result.FailureReason)
+			}
+		}
+
+		return nil
+	}
+}
+
+func getPortfolioIdFromName(client *infrastructure.InvestmentManagerInternalHttpClient, name string) (string, error) {
+	portfolios, err := listPortfolios(client)
+
+	if err != nil {
+		return "", fmt.Errorf("could not get portfolios\n%v\n", err)
+	}
+
+	return getPortfolioIdByName(name, portfolios)
+}
+
+func showOpenOrders(openOrders *types.OpenOrdersResponse) {
+	tracked := map[string]bool{}
+
+	for _, offer := range openOrders.StrategyOffers {
+		tracked[offer.OrderId] = true
+	}
+
+	fmt.Printf("\nOpen orders on the exchange: %d\n", len(openOrders.Orders))
+
+	for _, order := range openOrders.Orders {
+		orderType, _ := order.OrderConfiguration.OrderType()
+		source := "manual"
+
+		if tracked[order.OrderID] {
+			source = string(openOrders.StrategyName)
+		}
+
+		fmt.Printf("%s %s %s %s filled: %s (%s)\n", order.OrderID, order.Side, order.ProductID, orderType, order.FilledSize, source)
+	}
+
+	onExchange := map[string]bool{}
+
+	for _, order := range openOrders.Orders {
+		onExchange[order.OrderID] = true
+	}
+
+	// Offers the strategy still thinks are open but the exchange has closed, the reconciler will catch these up
+	for _, offer := range openOrders.StrategyOffers {
+		if !onExchange[offer.OrderId] {
+			fmt.Printf("%s %s %s open in state only (%s)\n", offer.OrderId, offer.Side, offer.ProductId, openOrders.StrategyName)
+		}
+	}
+}
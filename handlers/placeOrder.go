@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/spf13/cobra"
+)
+
+func PlaceOrderHandlerFactory(client *infrastructure.InvestmentManagerInternalHttpClient) CobraCommandHandler {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != 4 {
+			return fmt.Errorf("Unexpected number of args.\nExpected 4, Received %d", len(args))
+		}
+
+		request, err := getPlaceOrderRequest(cmd, args)
+
+		if err != nil {
+			return err
+		}
+
+		request.PortfolioID, err = getPortfolioIdFromName(client, args[0])
+
+		if err != nil {
+			return err
+		}
+
+		return placeOrder(cmd, request, client)
+	}
+}
+
+func getPlaceOrderRequest(cmd *cobra.Command, args []string) (*types.PlaceOrderRequest, error) {
+	side := types.Side(strings.ToUpper(args[2]))
+
+	if side != types.BUY && side != types.SELL {
+		return nil, fmt.Errorf("Invalid side\nGiven: %q Expected: buy or sell\n", args[2])
+	}
+
+	size, err := types.ParseDecimal(args[3])
+
+	if err != nil || size.Sign() <= 0 {
+		return nil, fmt.Errorf("Invalid size, expected a positive number\nGiven: %q\n", args[3])
+	}
+
+	request := &types.PlaceOrderRequest{
+		ProductID: strings.ToUpper(args[1]),
+		Side:      side,
+		OrderType: types.OrderType(strings.ToLower(getStringFlag(cmd, "order-type"))),
+		PostOnly:  getBoolFlag(cmd, "post-only"),
+	}
+
+	if request.OrderType == "" {
+		request.OrderType = types.MarketOrder
+	}
+
+	if getBoolFlag(cmd, "quote") {
+		request.QuoteSize = size
+	} else {
+		request.BaseSize = size
+	}
+
+	request.LimitPrice, err = getPriceFlag(cmd, "limit-price")
+
+	if err != nil {
+		return nil, err
+	}
+
+	request.StopPrice, err = getPriceFlag(cmd, "stop-price")
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expires := getStringFlag(cmd, "expires"); expires != "" {
+		duration, err := time.ParseDuration(expires)
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid --expires, expected a duration e.g. 24h\nGiven: %q\n", expires)
+		}
+
+		request.EndTime = time.Now().Add(duration).Format(time.RFC3339)
+	}
+
+	return request, nil
+}
+
+// getPriceFlag returns zero when the flag is not given.
+func getPriceFlag(cmd *cobra.Command, name string) (types.Decimal, error) {
+	value := getStringFlag(cmd, name)
+
+	if value == "" {
+		return types.Decimal{}, nil
+	}
+
+	price, err := types.ParseDecimal(value)
+
+	if err != nil || price.Sign() <= 0 {
+		return types.Decimal{}, fmt.Errorf("Invalid --%s, expected a positive number\nGiven: %q\n", name, value)
+	}
+
+	return price, nil
+}
+
+// placeOrder previews the order, asks for confirmation unless --yes is set, then places it.
+func placeOrder(cmd *cobra.Command, request *types.PlaceOrderRequest, client *infrastructure.InvestmentManagerInternalHttpClient) error {
+	request.Preview = true
+
+	resp, err := postOrder(request, client)
+
+	if err != nil {
+		return err
+	}
+
+	showOrderPreview(request, resp.Preview)
+
+	if !getBoolFlag(cmd, "yes") && !confirm(cmd.InOrStdin(), "Place this order? [y/N] ") {
+		fmt.Println("Order not placed")
+		return nil
+	}
+
+	request.Preview = false
+
+	resp, err = postOrder(request, client)
+
+	if err != nil {
+		return err
+	}
+
+	if resp.Order == nil {
+		return fmt.Errorf("Server did not return the placed order\n")
+	}
+
+	fmt.Printf("Order placed!\nOrder ID: %s\n", resp.Order.GetOrderID())
+
+	return nil
+}
+
+func postOrder(request *types.PlaceOrderRequest, client *infrastructure.InvestmentManagerInternalHttpClient) (*types.PlaceOrderResponse, error) {
+	serializedRequest, err := json.Marshal(request)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to serialize request\n%v\n", err)
+	}
+
+	response, err := client.Post("/"+string(types.Orders), serializedRequest)
+
+	// Orders the server rejects itself come back as an error saying why
+	if err != nil {
+		return nil, fmt.Errorf("Request failed: %v\n", err)
+	}
+
+	var resp types.PlaceOrderResponse
+	err = json.Unmarshal(response, &resp)
+
+	if err != nil || resp.Preview == nil {
+		return nil, fmt.Errorf("Unexpected response from the server\n%s\n", response)
+	}
+
+	if len(resp.Preview.Errors) > 0 {
+		return nil, fmt.Errorf("Order was rejected by the exchange\n%s\n", strings.Join(resp.Preview.Errors, "\n"))
+	}
+
+	return &resp, nil
+}
+
+func showOrderPreview(request *types.PlaceOrderRequest, preview *types.CoinbaseOrderPreviewResponse) {
+	fmt.Printf("\n%s %s %s order\n", request.OrderType, request.Side, request.ProductID)
+	fmt.Printf("Best bid: %s Best ask: %s\n", preview.BestBid, preview.BestAsk)
+	fmt.Printf("Base size: %s\n", preview.BaseSize)
+	fmt.Printf("Quote size: %s\n", preview.QuoteSize)
+	fmt.Printf("Commission: %s\n", preview.CommissionTotal)
+	fmt.Printf("Order total: %s\n", preview.OrderTotal)
+
+	if preview.Slippage != "" {
+		fmt.Printf("Slippage: %s\n", preview.Slippage)
+	}
+
+	for _, warning := range preview.Warnings {
+		fmt.Printf("Warning: %s\n", warning)
+	}
+}
+
+// confirm reports whether the user answered yes to the prompt.
+func confirm(in io.Reader, prompt string) bool {
+	fmt.Print(prompt)
+
+	answer, err := bufio.NewReader(in).ReadString('\n')
+
+	if err != nil && answer == "" {
+		return false
+	}
+
+	answer = strings.ToLower(strings.TrimSpace(answer))
+
+	return answer == "y" || answer == "yes"
+}
@@ -0,0 +1,129 @@
+package handlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/iPopcorn/investment-manager/handlers"
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/spf13/cobra"
+)
+
+type testPlaceOrderHttpClient struct {
+	requests      []types.PlaceOrderRequest
+	previewErrors []string // Returned by the preview with a 422 when set
+}
+
+func (testClient *testPlaceOrderHttpClient) Do(req *http.Request) (*http.Response, error) {
+	var response any = types.PortfolioResponse{
+		Portfolios: []types.Portfolio{{Name: "test", Uuid: "test-portfolio-id"}},
+	}
+
+	if req.Method == http.MethodPost {
+		var request types.PlaceOrderRequest
+		body, _ := io.ReadAll(req.Body)
+		json.Unmarshal(body, &request)
+		testClient.requests = append(testClient.requests, request)
+
+		placeOrderResponse := types.PlaceOrderResponse{
+			Preview: &types.CoinbaseOrderPreviewResponse{OrderTotal: "25", Errors: testClient.previewErrors},
+		}
+
+		if len(testClient.previewErrors) > 0 {
+			serializedResponse, _ := json.Marshal(placeOrderResponse)
+
+			return &http.Response{StatusCode: http.StatusUnprocessableEntity, Body: io.NopCloser(bytes.NewReader(serializedResponse))}, nil
+		}
+
+		if !request.Preview {
+			placeOrderResponse.Order = &types.CoinbaseOrderPlacedResponse{Success: true, OrderID: "test-order-id"}
+		}
+
+		response = placeOrderResponse
+	}
+
+	serializedResponse, _ := json.Marshal(response)
+
+	return &http.Response{Body: io.NopCloser(bytes.NewReader(serializedResponse))}, nil
+}
+
+func TestPlaceOrder(t *testing.T) {
+	setup := func(answer string) (*testPlaceOrderHttpClient, handlers.CobraCommandHandler, *cobra.Command) {
+		testHttpClient := &testPlaceOrderHttpClient{}
+		testInternalClient := infrastructure.InvestmentManagerInternalHttpClientFactory(testHttpClient, "")
+		testCmd := &cobra.Command{Use: "test"}
+		testCmd.SetIn(strings.NewReader(answer))
+
+		return testHttpClient, handlers.PlaceOrderHandlerFactory(testInternalClient), testCmd
+	}
+
+	args := []string{"test", "eth-gbp", "buy", "0.01"}
+
+	t.Run("Does not place the order if the preview is not confirmed", func(t *testing.T) {
+		testHttpClient, testHandler, testCmd := setup("n\n")
+
+		err := testHandler(testCmd, args)
+
+		if err != nil {
+			t.Fatalf("Received error but did not expect one\n%v", err)
+		}
+
+		if len(testHttpClient.requests) != 1 || !testHttpClient.requests[0].Preview {
+			t.Errorf("Expected only a preview request, got %+v", testHttpClient.requests)
+		}
+	})
+
+	t.Run("Places the order once the preview is confirmed", func(t *testing.T) {
+		testHttpClient, testHandler, testCmd := setup("y\n")
+
+		err := testHandler(testCmd, args)
+
+		if err != nil {
+			t.Fatalf("Received error but did not expect one\n%v", err)
+		}
+
+		if len(testHttpClient.requests) != 2 || testHttpClient.requests[1].Preview {
+			t.Fatalf("Expected a preview then an order, got %+v", testHttpClient.requests)
+		}
+
+		placed := testHttpClient.requests[1]
+
+		if placed.PortfolioID != "test-portfolio-id" || placed.ProductID != "ETH-GBP" || placed.BaseSize.String() != "0.01" {
+			t.Errorf("Unexpected order request: %+v", placed)
+		}
+	})
+
+	t.Run("Shows the errors when the exchange rejects the preview", func(t *testing.T) {
+		testHttpClient, testHandler, testCmd := setup("y\n")
+		testHttpClient.previewErrors = []string{"PREVIEW_INSUFFICIENT_FUND"}
+
+		err := testHandler(testCmd, args)
+
+		if err == nil || !strings.Contains(err.Error(), "PREVIEW_INSUFFICIENT_FUND") {
+			t.Fatalf("Expected an error with the exchange's reason\nActual: %v", err)
+		}
+
+		if len(testHttpClient.requests) != 1 {
+			t.Errorf("Expected only the preview request, sent %d", len(testHttpClient.requests))
+		}
+	})
+
+	t.Run("Fails given an invalid side", func(t *testing.T) {
+		testHttpClient, testHandler, testCmd := setup("y\n")
+
+		err := testHandler(testCmd, []string{"test", "eth-gbp", "hold", "0.01"})
+
+		if err == nil {
+			t.Fatalf("Expected error but did not receive one")
+		}
+
+		if len(testHttpClient.requests) != 0 {
+			t.Errorf("Expected no request to be sent, sent %d", len(testHttpClient.requests))
+		}
+	})
+}
@@ -42,6 +42,97 @@ func showPortfolioDetails(details *types.PortfolioDetailsResponse) {
 	fmt.Printf("Name: %s\n", name)
 	fmt.Printf("Total Value: %s %s\n", totalBalance.Value, totalBalance.Currency)
 	fmt.Printf("Amount available for trade: %s %s\n", cashBalance.Value, cashBalance.Currency)
+
+	showPerpPositions(details.Breakdown.PerpPositions, details.Breakdown.PortfolioBalances.PerpUnrealizedPnl)
+	showFuturesPositions(details.Breakdown.FuturesPositions, details.Breakdown.PortfolioBalances.FuturesUnrealizedPnl)
+
+	if details.Strategy != nil {
+		showStrategy(details.Strategy)
+	}
+}
+
+func showPerpPositions(positions []types.PerpPositions, unrealizedPnl types.Balance) {
+	if len(positions) == 0 {
+		return
+	}
+
+	fmt.Printf("Perpetual positions, unrealized PnL: %s %s\n", unrealizedPnl.Value, unrealizedPnl.Currency)
+
+	for _, position := range positions {
+		pnl := position.UnrealizedPnl.UserNativeCurrency
+		fmt.Printf("%s %s size: %s entry: %s mark: %s unrealized PnL: %s %s liquidation: %s\n",
+			position.ProductID,
+			getPositionSideName(position.PositionSide),
+			position.NetSize,
+			position.Vwap.UserNativeCurrency.Value,
+			position.MarkPrice.UserNativeCurrency.Value,
+			pnl.Value,
+			pnl.Currency,
+			position.LiquidationPrice.UserNativeCurrency.Value,
+		)
+	}
+}
+
+func showFuturesPositions(positions []types.FuturesPositions, unrealizedPnl types.Balance) {
+	if len(positions) == 0 {
+		return
+	}
+
+	fmt.Printf("Futures positions, unrealized PnL: %s %s\n", unrealizedPnl.Value, unrealizedPnl.Currency)
+
+	// Coinbase doesn't report a liquidation price for dated futures
+	for _, position := range positions {
+		fmt.Printf("%s %s size: %s entry: %s current: %s unrealized PnL: %s expires: %s\n",
+			position.ProductID,
+			getPositionSideName(position.Side),
+			position.Amount,
+			position.AvgEntryPrice,
+			position.CurrentPrice,
+			position.UnrealizedPnl,
+			position.Expiry,
+		)
+	}
+}
+
+// getPositionSideName turns FUTURES_POSITION_SIDE_LONG into LONG.
+func getPositionSideName(side types.PositionSide) string {
+	return strings.TrimPrefix(string(side), "FUTURES_POSITION_SIDE_")
+}
+
+func showStrategy(strategy *types.Strategy) {
+	fmt.Printf("Strategy: %s %s\n", strategy.Name, strategy.Currency)
+
+	if trailingStop := strategy.TrailingStop; trailingStop != nil {
+		fmt.Printf("Trailing stop: %.2f%% below peak of %s, stop at %s\n", trailingStop.Percent, trailingStop.PeakPrice.StringFixed(2), trailingStop.StopPrice.StringFixed(2))
+
+		if trailingStop.Triggered {
+			fmt.Println("Trailing stop triggered")
+		} else if trailingStop.Disarmed {
+			fmt.Println("Trailing stop disarmed, its order was cancelled")
+		}
+	}
+
+	protection := strategy.Protection
+
+	if protection == nil {
+		return
+	}
+
+	if protection.StopLossPrice.Sign() > 0 {
+		fmt.Printf("Stop-loss: %s\n", protection.StopLossPrice)
+	} else if protection.StopLossPercent > 0 {
+		fmt.Printf("Stop-loss: %.2f%% below average cost\n", protection.StopLossPercent)
+	}
+
+	if protection.TakeProfitPrice.Sign() > 0 {
+		fmt.Printf("Take-profit: %s\n", protection.TakeProfitPrice)
+	} else if protection.TakeProfitPercent > 0 {
+		fmt.Printf("Take-profit: %.2f%% above average cost\n", protection.TakeProfitPercent)
+	}
+
+	if protection.Triggered != "" {
+		fmt.Printf("%s triggered at %s on %s\n", protection.Triggered, protection.TriggerPrice, protection.TriggeredAt)
+	}
 }
 
 func getPortfolioDetails(portfolioName string, client *infrastructure.InvestmentManagerInternalHttpClient) (*types.PortfolioDetailsResponse, error) {
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/util"
+	"github.com/spf13/cobra"
+)
+
+// HandleMigrateState migrates the state file directly rather than through the server,
+// so it still works when the server can't read the file. A .db file is an SQLite state database.
+func HandleMigrateState(cmd *cobra.Command, args []string) error {
+	dryRun := getBoolFlag(cmd, "dry-run")
+	filename := getStringFlag(cmd, "file")
+	migrate := state.StateRepositoryFactory(filename).Migrate
+
+	if strings.HasSuffix(filename, ".db") {
+		// Opening the database creates it, so check there's one to migrate first
+		pathToFile, err := util.GetPathToDataFile(filename)
+
+		if err == nil {
+			_, err = os.Stat(pathToFile)
+		}
+
+		if err != nil {
+			return fmt.Errorf("Failed to migrate %s\n%v\n", filename, err)
+		}
+
+		sqliteRepository, err := state.SQLiteStateRepositoryFactory(filename)
+
+		if err != nil {
+			return fmt.Errorf("Failed to open %s\n%v\n", filename, err)
+		}
+
+		defer sqliteRepository.Close()
+		migrate = sqliteRepository.Migrate
+	}
+
+	report, err := migrate(dryRun)
+
+	if err != nil {
+		return fmt.Errorf("Failed to migrate %s\n%v\n", filename, err)
+	}
+
+	showMigrationReport(filename, report, dryRun)
+
+	return nil
+}
+
+func showMigrationReport(filename string, report *state.MigrationReport, dryRun bool) {
+	if len(report.Migrations) == 0 {
+		fmt.Printf("%s is up to date, schema version %d\n", filename, report.ToVersion)
+		return
+	}
+
+	if dryRun {
+		fmt.Printf("Would migrate %s from schema version %d to %d\n", filename, report.FromVersion, report.ToVersion)
+	} else {
+		fmt.Printf("Migrated %s from schema version %d to %d\n", filename, report.FromVersion, report.ToVersion)
+	}
+
+	for _, migration := range report.Migrations {
+		fmt.Printf("\nVersion %d: %s\n", migration.Version, migration.Description)
+
+		for _, change := range migration.Changes {
+			fmt.Printf(" %s\n", change)
+		}
+	}
+}
@@ -3,7 +3,7 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
-	"strconv"
+	"strings"
 
 	"github.com/iPopcorn/investment-manager/infrastructure"
 	"github.com/iPopcorn/investment-manager/types"
@@ -15,17 +15,17 @@ func TransferFundsHandlerFactory(internalClient *infrastructure.InvestmentManage
 		if len(args) != 3 {
 			return fmt.Errorf("Unexpected number of args.\nExpected 3, Received %d", len(args))
 		}
-		err := transferFundsHandler(internalClient, args)
+		err := transferFundsHandler(internalClient, args, getStringFlag(cmd, "currency"))
 
 		return err
 	}
 }
 
-func transferFundsHandler(internalClient *infrastructure.InvestmentManagerInternalHttpClient, args []string) error {
-	_, err := strconv.ParseFloat(args[2], 64)
+func transferFundsHandler(internalClient *infrastructure.InvestmentManagerInternalHttpClient, args []string, currency string) error {
+	amount, err := types.ParseDecimal(args[2])
 
-	if err != nil {
-		return fmt.Errorf("Could not convert arg to float.\nGiven: %s\n%v\n", args[2], err)
+	if err != nil || amount.Sign() <= 0 {
+		return fmt.Errorf("Invalid amount, expected a number greater than 0\nGiven: %q\n", args[2])
 	}
 
 	senderName := args[0]
@@ -51,7 +51,8 @@ func transferFundsHandler(internalClient *infrastructure.InvestmentManagerIntern
 	request := types.TransferRequest{
 		SenderID:   senderID,
 		ReceiverID: receiverID,
-		Amount:     args[2],
+		Amount:     amount,
+		Currency:   strings.ToUpper(currency),
 	}
 
 	serializedRequest, err := json.Marshal(request)
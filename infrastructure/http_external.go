@@ -15,8 +15,12 @@ type HttpClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
+// RequestSigner returns the token a request to the given url is authorized with.
+type RequestSigner func(url, httpMethod string) (string, error)
+
 type InvestmentManagerExternalHttpClient struct {
 	HttpClient HttpClient
+	Sign       RequestSigner // Defaults to a JWT signed with the API key
 }
 
 func GetInvestmentManagerExternalHttpClient() *InvestmentManagerExternalHttpClient {
@@ -36,7 +40,13 @@ func (client InvestmentManagerExternalHttpClient) Post(url string, request []byt
 func (client InvestmentManagerExternalHttpClient) sendAuthenticatedHttpRequest(url, method string, request []byte) ([]byte, error) {
 	emptyResponse := []byte{}
 
-	jwt, err := getJWT(url, method)
+	sign := client.Sign
+
+	if sign == nil {
+		sign = getJWT
+	}
+
+	jwt, err := sign(url, method)
 	if err != nil {
 		fmt.Println("Failed to get jwt")
 		return emptyResponse, err
@@ -80,12 +90,6 @@ func (client InvestmentManagerExternalHttpClient) sendAuthenticatedHttpRequest(u
 }
 
 func getJWT(url, httpMethod string) (string, error) {
-	apiKey, err := auth.GetApiKey()
-	if err != nil {
-		fmt.Printf("error getting API Key\n%v\n", err)
-		return "", err
-	}
-
 	// separate "https://" from the rest of the url
 	token1 := strings.Split(url, "//")
 
@@ -117,6 +121,12 @@ func getJWT(url, httpMethod string) (string, error) {
 	path = strings.Split(path, "?")[0]
 	uri := fmt.Sprintf("%s %s%s", httpMethod, host, path)
 
+	apiKey, err := auth.GetApiKey()
+	if err != nil {
+		fmt.Printf("error getting API Key\n%v\n", err)
+		return "", err
+	}
+
 	jwtOptions := auth.BuildJWTOptions{
 		Service:    "retail_rest_api_proxy",
 		Uri:        uri,
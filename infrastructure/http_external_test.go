@@ -14,6 +14,7 @@ func TestGet(t *testing.T) {
 		httpClient := testutils.TestHttpClient{GetResponse: expected}
 		testClient := infrastructure.InvestmentManagerExternalHttpClient{
 			HttpClient: httpClient,
+			Sign:       testutils.TestSign,
 		}
 		data, err := testClient.Get("https://api.coinbase.com/api/v3/brokerage/portfolios")
 
@@ -2,10 +2,14 @@ package infrastructure
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
+
+	"github.com/iPopcorn/investment-manager/config"
+	"github.com/iPopcorn/investment-manager/types"
 )
 
 type InvestmentManagerInternalHttpClient struct {
@@ -13,10 +17,11 @@ type InvestmentManagerInternalHttpClient struct {
 	baseURL string
 }
 
+// GetDefaultInvestmentManagerInternalHttpClient sends requests to the server address in the config.
+// Commands create their client before flags are parsed, so the address is only looked up when a request is sent.
 func GetDefaultInvestmentManagerInternalHttpClient() *InvestmentManagerInternalHttpClient {
 	return &InvestmentManagerInternalHttpClient{
-		client:  &http.Client{},
-		baseURL: "http://127.0.0.1:5000",
+		client: &http.Client{},
 	}
 }
 
@@ -28,15 +33,31 @@ func InvestmentManagerInternalHttpClientFactory(client HttpClient, baseURL strin
 }
 
 func (c *InvestmentManagerInternalHttpClient) Get(path string) ([]byte, error) {
-	url := c.baseURL + path
+	url := c.getBaseURL() + path
 	return c.sendInternalHttpRequest(url, "GET", nil)
 }
 
 func (c *InvestmentManagerInternalHttpClient) Post(path string, request []byte) ([]byte, error) {
-	url := c.baseURL + path
+	url := c.getBaseURL() + path
 	return c.sendInternalHttpRequest(url, "POST", request)
 }
 
+// getBaseURL returns the base URL the client was created with, or the server address in the config.
+// The default address is used when the config can't be loaded.
+func (c *InvestmentManagerInternalHttpClient) getBaseURL() string {
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+
+	address := config.DefaultServerAddress
+
+	if cfg, err := config.GetConfig(); err == nil {
+		address = cfg.ServerAddress
+	}
+
+	return "http://" + address
+}
+
 func (c *InvestmentManagerInternalHttpClient) sendInternalHttpRequest(url, method string, request []byte) ([]byte, error) {
 	emptyResponse := []byte{}
 
@@ -71,5 +92,14 @@ func (c *InvestmentManagerInternalHttpClient) sendInternalHttpRequest(url, metho
 		return emptyResponse, err
 	}
 
+	// Requests the server rejects come back with why in an ErrorResponse
+	if res.StatusCode >= http.StatusBadRequest {
+		var errorResponse types.ErrorResponse
+
+		if json.Unmarshal(body, &errorResponse) == nil && errorResponse.Error != "" {
+			return emptyResponse, errors.New(errorResponse.Error)
+		}
+	}
+
 	return body, nil
 }
@@ -0,0 +1,392 @@
+package exchange
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/types/mappers"
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+const coinbaseBaseURL = "https://api.coinbase.com/api/v3/brokerage"
+
+type CoinbaseExchange struct {
+	client *infrastructure.InvestmentManagerExternalHttpClient
+}
+
+type coinbaseCreatePortfolioRequest struct {
+	Name string `json:"name"`
+}
+
+type coinbaseCreateOrderRequest struct {
+	ClientOrderId         string                      `json:"client_order_id"`
+	ProductId             string                      `json:"product_id"`
+	Side                  types.Side                  `json:"side"`
+	Config                types.OrderConfiguration    `json:"order_configuration"`
+	SelfTradePreventionId types.SelfTradePreventionID `json:"self_trade_prevention_id"`
+	RetailPortfolioId     string                      `json:"retail_portfolio_id"`
+}
+
+type coinbasePreviewOrderRequest struct {
+	ProductId         string                   `json:"product_id"`
+	Side              types.Side               `json:"side"`
+	Config            types.OrderConfiguration `json:"order_configuration"`
+	RetailPortfolioId string                   `json:"retail_portfolio_id"`
+}
+
+type coinbaseCancelOrdersRequest struct {
+	OrderIDs []string `json:"order_ids"`
+}
+
+type coinbaseTransferFundsRequest struct {
+	Funds      Funds  `json:"funds"`
+	SenderID   string `json:"source_portfolio_uuid"`
+	ReceiverID string `json:"target_portfolio_uuid"`
+}
+
+type Funds struct {
+	Value    string `json:"value"`
+	Currency string `json:"currency"`
+}
+
+func GetDefaultCoinbaseExchange() *CoinbaseExchange {
+	return CoinbaseExchangeFactory(infrastructure.GetInvestmentManagerExternalHttpClient())
+}
+
+func CoinbaseExchangeFactory(client *infrastructure.InvestmentManagerExternalHttpClient) *CoinbaseExchange {
+	return &CoinbaseExchange{
+		client: client,
+	}
+}
+
+func (e *CoinbaseExchange) ListPortfolios() (*types.PortfolioResponse, error) {
+	url := coinbaseBaseURL + "/portfolios"
+
+	resp, err := e.client.Get(url)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving portfolios from URL: %q\nError: %v", url, err)
+	}
+
+	return mappers.MapPortfolioResponse(resp)
+}
+
+func (e *CoinbaseExchange) CreatePortfolio(name string) (*types.PortfolioCreatedResponse, error) {
+	url := coinbaseBaseURL + "/portfolios"
+
+	serializedReq, err := json.Marshal(coinbaseCreatePortfolioRequest{Name: name})
+
+	if err != nil {
+		log.Printf("Failed to serialize request\nGiven: %q\n", name)
+		return nil, err
+	}
+
+	resp, err := e.client.Post(url, serializedReq)
+
+	if err != nil {
+		return nil, fmt.Errorf("Error creating portfolio at URL: %q\nError: %v", url, err)
+	}
+
+	err = util.HandleErrorResponse(resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var createdResponse types.PortfolioCreatedResponse
+
+	err = json.Unmarshal(resp, &createdResponse)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to map http response to object\n%v", err)
+	}
+
+	return &createdResponse, nil
+}
+
+func (e *CoinbaseExchange) PortfolioDetails(portfolioID string) (*types.PortfolioDetailsResponse, error) {
+	url := coinbaseBaseURL + "/portfolios/" + portfolioID
+	resp, err := e.client.Get(url)
+
+	if err != nil {
+		log.Printf("Error retrieving portfolio details from URL: %q\nError: %v", url, err)
+		return nil, err
+	}
+
+	return mappers.MapPortfolioDetailsResponse(resp)
+}
+
+func (e *CoinbaseExchange) ListProducts(productIDs []string) (*types.ProductResponse, error) {
+	url := coinbaseBaseURL + "/products?product_type=SPOT"
+
+	for _, productID := range productIDs {
+		url += "&product_ids=" + productID
+	}
+
+	resp, err := e.client.Get(url)
+
+	if err != nil {
+		log.Printf("Error retrieving product list from URL: %q\nError: %v", url, err)
+		return nil, err
+	}
+
+	productResponse, err := mappers.MapProductResponse(resp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to map product response to object\n%v\n", err)
+	}
+
+	return productResponse, nil
+}
+
+func (e *CoinbaseExchange) GetBestBidAsk(productID string) (*types.BestBidAskResponse, error) {
+	url := fmt.Sprintf("%s/best_bid_ask?product_ids=%s", coinbaseBaseURL, productID)
+	resp, err := e.client.Get(url)
+
+	log.Printf("raw resp: \n%s\n", string(resp))
+
+	if err != nil {
+		log.Printf("Error retrieving best bid ask from URL: %q\nError: %v\n", url, err)
+		return nil, err
+	}
+
+	bestBidAskResponse, err := mappers.MapBestBidAskResponse(resp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to map product response to object\n%v\n", err)
+	}
+
+	return bestBidAskResponse, nil
+}
+
+func (e *CoinbaseExchange) PlaceOrder(offer *types.Offer) (*types.CoinbaseOrderPlacedResponse, error) {
+	url := coinbaseBaseURL + "/orders"
+
+	createReq := coinbaseCreateOrderRequest{
+		ClientOrderId:         offer.ClientOrderId,
+		ProductId:             offer.ProductId,
+		Side:                  offer.Side,
+		Config:                offer.Config,
+		SelfTradePreventionId: offer.SelfTradePreventionId,
+		RetailPortfolioId:     offer.RetailPortfolioId,
+	}
+
+	serializedRequest, err := json.Marshal(createReq)
+
+	if err != nil {
+		log.Printf("Failed to serialize coinbase request\nrequest: %+v\nerror: %v\n", createReq, err)
+		return nil, err
+	}
+
+	resp, err := e.sendOrderRequest(url, serializedRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var coinbaseResp types.CoinbaseOrderPlacedResponse
+
+	err = json.Unmarshal(resp, &coinbaseResp)
+
+	if err != nil {
+		fmt.Printf("Failed to parse coinbase response\n%v\n", err)
+
+		return nil, err
+	}
+
+	return &coinbaseResp, nil
+}
+
+func (e *CoinbaseExchange) PreviewOrder(offer *types.Offer) (*types.CoinbaseOrderPreviewResponse, error) {
+	url := coinbaseBaseURL + "/orders/preview"
+
+	previewReq := coinbasePreviewOrderRequest{
+		ProductId:         offer.ProductId,
+		Side:              offer.Side,
+		Config:            offer.Config,
+		RetailPortfolioId: offer.RetailPortfolioId,
+	}
+
+	serializedRequest, err := json.Marshal(previewReq)
+
+	if err != nil {
+		log.Printf("Failed to serialize coinbase request\nrequest: %+v\nerror: %v\n", previewReq, err)
+		return nil, err
+	}
+
+	resp, err := e.sendOrderRequest(url, serializedRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var coinbaseResp types.CoinbaseOrderPreviewResponse
+
+	err = json.Unmarshal(resp, &coinbaseResp)
+
+	if err != nil {
+		fmt.Printf("Failed to parse coinbase preview response\n%v\n", err)
+
+		return nil, err
+	}
+
+	return &coinbaseResp, nil
+}
+
+func (e *CoinbaseExchange) GetOrder(orderID string) (*types.Order, error) {
+	url := coinbaseBaseURL + "/orders/historical/" + orderID
+	resp, err := e.client.Get(url)
+
+	if err != nil {
+		log.Printf("Error retrieving order from URL: %q\nError: %v", url, err)
+		return nil, err
+	}
+
+	err = util.HandleErrorResponse(resp)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return mappers.MapOrderResponse(resp)
+}
+
+// ListOpenOrders follows the cursor until every open order in the portfolio is fetched.
+func (e *CoinbaseExchange) ListOpenOrders(portfolioID string) ([]types.Order, error) {
+	orders := []types.Order{}
+	cursor := ""
+
+	for {
+		url := fmt.Sprintf("%s/orders/historical/batch?order_status=OPEN&retail_portfolio_id=%s", coinbaseBaseURL, portfolioID)
+
+		if cursor != "" {
+			url += "&cursor=" + cursor
+		}
+
+		resp, err := e.client.Get(url)
+
+		if err != nil {
+			log.Printf("Error retrieving open orders from URL: %q\nError: %v", url, err)
+			return nil, err
+		}
+
+		err = util.HandleErrorResponse(resp)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var listOrdersResp types.ListOrdersResponse
+
+		err = json.Unmarshal(resp, &listOrdersResp)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to map http response to object\n%v", err)
+		}
+
+		orders = append(orders, listOrdersResp.Orders...)
+
+		if !listOrdersResp.HasNext || listOrdersResp.Cursor == "" {
+			return orders, nil
+		}
+
+		cursor = listOrdersResp.Cursor
+	}
+}
+
+func (e *CoinbaseExchange) CancelOrders(orderIDs []string) (*types.CancelOrdersResponse, error) {
+	url := coinbaseBaseURL + "/orders/batch_cancel"
+
+	serializedRequest, err := json.Marshal(coinbaseCancelOrdersRequest{OrderIDs: orderIDs})
+
+	if err != nil {
+		log.Printf("Failed to serialize coinbase request\nrequest: %v\nerror: %v\n", orderIDs, err)
+		return nil, err
+	}
+
+	resp, err := e.sendOrderRequest(url, serializedRequest)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var coinbaseResp types.CancelOrdersResponse
+
+	err = json.Unmarshal(resp, &coinbaseResp)
+
+	if err != nil {
+		fmt.Printf("Failed to parse coinbase cancel response\n%v\n", err)
+
+		return nil, err
+	}
+
+	return &coinbaseResp, nil
+}
+
+func (e *CoinbaseExchange) TransferFunds(req *types.TransferRequest) (*types.TransferFundsResponse, error) {
+	url := coinbaseBaseURL + "/portfolios/move_funds"
+
+	coinbaseReq := coinbaseTransferFundsRequest{
+		Funds: Funds{
+			Value:    req.Amount.String(),
+			Currency: req.Currency,
+		},
+		SenderID:   req.SenderID,
+		ReceiverID: req.ReceiverID,
+	}
+
+	serializedReq, err := json.Marshal(coinbaseReq)
+	if err != nil {
+		log.Printf("Failed to serialize request\nGiven: %+v\n", req)
+		return nil, err
+	}
+
+	log.Printf("Sending request to coinbase: %q\n", string(serializedReq))
+	resp, err := e.client.Post(url, serializedReq)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = util.HandleErrorResponse(resp)
+
+	if err != nil {
+		log.Printf("Received error from coinbase when transferring funds: %v\n", err)
+		return nil, err
+	}
+
+	var transferResp types.TransferFundsResponse
+
+	err = json.Unmarshal(resp, &transferResp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to map http response to object\n%v", err)
+	}
+
+	return &transferResp, nil
+}
+
+func (e *CoinbaseExchange) sendOrderRequest(url string, serializedRequest []byte) ([]byte, error) {
+	log.Printf("Sending order request to coinbase\nurl: %s\nreq: %s\n", url, string(serializedRequest))
+	resp, err := e.client.Post(url, serializedRequest)
+
+	if err != nil {
+		log.Printf("Error when sending req to coinbase\n%v\n", err)
+		return nil, err
+	}
+
+	err = util.HandleErrorResponse(resp)
+
+	if err != nil {
+		fmt.Printf("Received error from coinbase while sending order request\n%v\n", err)
+
+		return nil, err
+	}
+
+	log.Printf("Received resp from coinbase\nresp: %s\n", string(resp))
+
+	return resp, nil
+}
@@ -0,0 +1,252 @@
+package exchange
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+type testHttpClient struct {
+	getResponseMap map[string][]byte
+	requestedPaths *[]string
+	requestBodies  *[]string
+}
+
+func (testClient testHttpClient) Do(req *http.Request) (*http.Response, error) {
+	if testClient.requestedPaths != nil {
+		*testClient.requestedPaths = append(*testClient.requestedPaths, req.URL.Path)
+	}
+
+	if testClient.requestBodies != nil && req.Body != nil {
+		body, _ := io.ReadAll(req.Body)
+		*testClient.requestBodies = append(*testClient.requestBodies, string(body))
+	}
+
+	pathTokens := strings.Split(req.URL.Path, "/")
+	key := pathTokens[len(pathTokens)-1]
+	responseData := testClient.getResponseMap[key]
+
+	resp := http.Response{
+		Body: io.NopCloser(bytes.NewReader(responseData)),
+	}
+
+	return &resp, nil
+}
+
+// testSign stands in for signing requests with an API key, so the tests don't need credentials.
+func testSign(url, httpMethod string) (string, error) {
+	return "test-jwt", nil
+}
+
+func getTestCoinbaseExchange(responseMap map[string][]byte, requestedPaths *[]string) *CoinbaseExchange {
+	client := &infrastructure.InvestmentManagerExternalHttpClient{
+		HttpClient: testHttpClient{
+			getResponseMap: responseMap,
+			requestedPaths: requestedPaths,
+		},
+		Sign: testSign,
+	}
+
+	return CoinbaseExchangeFactory(client)
+}
+
+func TestCoinbaseListPortfolios(t *testing.T) {
+	t.Run("Maps portfolios from coinbase", func(t *testing.T) {
+		expected := &types.PortfolioResponse{
+			Portfolios: []types.Portfolio{
+				{Name: "Test One", Uuid: "test-portfolio-1", Type: "DEFAULT"},
+			},
+		}
+
+		serializedExpected, err := json.Marshal(expected)
+		if err != nil {
+			t.Fatalf("Failed to serialize expected response\n%v", err)
+		}
+
+		responseMap := map[string][]byte{"portfolios": serializedExpected}
+		testExchange := getTestCoinbaseExchange(responseMap, nil)
+
+		actual, err := testExchange.ListPortfolios()
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if len(actual.Portfolios) != 1 {
+			t.Fatalf("Expected 1 portfolio, got %d", len(actual.Portfolios))
+		}
+
+		if actual.Portfolios[0].Uuid != "test-portfolio-1" {
+			t.Errorf("Expected: %q Actual: %q", "test-portfolio-1", actual.Portfolios[0].Uuid)
+		}
+	})
+}
+
+func TestCoinbasePortfolioDetails(t *testing.T) {
+	t.Run("Maps perp and futures positions from coinbase", func(t *testing.T) {
+		responseMap := map[string][]byte{
+			"test-portfolio-1": []byte(`{"breakdown": {
+				"portfolio": {"name": "INTX", "uuid": "test-portfolio-1", "type": "INTX"},
+				"perp_positions": [{
+					"product_id": "BTC-PERP-INTX",
+					"position_side": "FUTURES_POSITION_SIDE_LONG",
+					"net_size": "0.5",
+					"vwap": {"userNativeCurrency": {"value": "60000", "currency": "USD"}, "rawCurrency": {"value": "60000", "currency": "USDC"}},
+					"unrealized_pnl": {"userNativeCurrency": {"value": "1250.5", "currency": "USD"}, "rawCurrency": {"value": "1250.5", "currency": "USDC"}},
+					"liquidation_price": {"userNativeCurrency": {"value": "41000", "currency": "USD"}, "rawCurrency": {"value": "41000", "currency": "USDC"}}
+				}],
+				"futures_positions": [{
+					"product_id": "BIT-28JUL23-CDE",
+					"side": "FUTURES_POSITION_SIDE_SHORT",
+					"amount": "2",
+					"avg_entry_price": "29000",
+					"unrealized_pnl": "-15.25",
+					"expiry": "2023-07-28T15:00:00Z"
+				}]
+			}}`),
+		}
+		testExchange := getTestCoinbaseExchange(responseMap, nil)
+
+		actual, err := testExchange.PortfolioDetails("test-portfolio-1")
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if len(actual.Breakdown.PerpPositions) != 1 || len(actual.Breakdown.FuturesPositions) != 1 {
+			t.Fatalf("Expected 1 perp and 1 futures position, got %+v", actual.Breakdown)
+		}
+
+		perp := actual.Breakdown.PerpPositions[0]
+
+		if perp.PositionSide != types.PositionSideLong || perp.NetSize.String() != "0.5" {
+			t.Errorf("Unexpected perp position: %+v", perp)
+		}
+
+		if perp.Vwap.UserNativeCurrency.Value.String() != "60000" || perp.LiquidationPrice.UserNativeCurrency.Value.String() != "41000" {
+			t.Errorf("Unexpected perp prices: %+v", perp)
+		}
+
+		if perp.UnrealizedPnl.UserNativeCurrency.Value.String() != "1250.5" || perp.UnrealizedPnl.UserNativeCurrency.Currency != "USD" {
+			t.Errorf("Unexpected perp PnL: %+v", perp.UnrealizedPnl)
+		}
+
+		futures := actual.Breakdown.FuturesPositions[0]
+
+		if futures.Side != types.PositionSideShort || futures.AvgEntryPrice.String() != "29000" || futures.UnrealizedPnl.String() != "-15.25" {
+			t.Errorf("Unexpected futures position: %+v", futures)
+		}
+	})
+}
+
+func TestCoinbaseOrders(t *testing.T) {
+	offer := &types.Offer{
+		ClientOrderId: "test-client-order-id",
+		ProductId:     "ETH-GBP",
+		Side:          types.BUY,
+	}
+
+	t.Run("Places orders at the orders endpoint", func(t *testing.T) {
+		requestedPaths := []string{}
+		responseMap := map[string][]byte{
+			"orders": []byte(`{"success": true, "order_id": "test-order-id"}`),
+		}
+		testExchange := getTestCoinbaseExchange(responseMap, &requestedPaths)
+
+		resp, err := testExchange.PlaceOrder(offer)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if !resp.Success || resp.OrderID != "test-order-id" {
+			t.Errorf("Unexpected response: %+v", resp)
+		}
+
+		if len(requestedPaths) != 1 || requestedPaths[0] != "/api/v3/brokerage/orders" {
+			t.Errorf("Unexpected requests: %v", requestedPaths)
+		}
+	})
+
+	t.Run("Previews orders at the preview endpoint", func(t *testing.T) {
+		requestedPaths := []string{}
+		responseMap := map[string][]byte{
+			"preview": []byte(`{"order_total": "100", "commission_total": "0.4"}`),
+		}
+		testExchange := getTestCoinbaseExchange(responseMap, &requestedPaths)
+
+		resp, err := testExchange.PreviewOrder(offer)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if resp.OrderTotal != "100" {
+			t.Errorf("Expected: %q Actual: %q", "100", resp.OrderTotal)
+		}
+
+		if len(requestedPaths) != 1 || requestedPaths[0] != "/api/v3/brokerage/orders/preview" {
+			t.Errorf("Unexpected requests: %v", requestedPaths)
+		}
+	})
+
+	t.Run("Sends only the configured order type", func(t *testing.T) {
+		requestBodies := []string{}
+		client := &infrastructure.InvestmentManagerExternalHttpClient{
+			HttpClient: testHttpClient{
+				getResponseMap: map[string][]byte{"orders": []byte(`{"success": true, "order_id": "test-order-id"}`)},
+				requestBodies:  &requestBodies,
+			},
+			Sign: testSign,
+		}
+
+		marketOffer := *offer
+		marketOffer.Config = types.OrderConfiguration{
+			MarketMarketIOC: &types.MarketMarketIOC{QuoteSize: types.NewDecimalFromInt(10)},
+		}
+
+		_, err := CoinbaseExchangeFactory(client).PlaceOrder(&marketOffer)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if len(requestBodies) != 1 {
+			t.Fatalf("Expected 1 request body, got %d", len(requestBodies))
+		}
+
+		var sent struct {
+			Config map[string]any `json:"order_configuration"`
+		}
+		err = json.Unmarshal([]byte(requestBodies[0]), &sent)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize request\n%v\n%s", err, requestBodies[0])
+		}
+
+		config := sent.Config
+
+		if len(config) != 1 || config["market_market_ioc"] == nil {
+			t.Errorf("Expected only market_market_ioc in order configuration, got %v", config)
+		}
+	})
+
+	t.Run("Returns coinbase errors", func(t *testing.T) {
+		responseMap := map[string][]byte{
+			"orders": []byte(`{"error": "INVALID_ARGUMENT", "message": "bad order"}`),
+		}
+		testExchange := getTestCoinbaseExchange(responseMap, nil)
+
+		_, err := testExchange.PlaceOrder(offer)
+
+		if err == nil {
+			t.Fatalf("Expected error but did not receive one")
+		}
+	})
+}
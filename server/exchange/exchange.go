@@ -0,0 +1,19 @@
+package exchange
+
+import "github.com/iPopcorn/investment-manager/types"
+
+// Exchange is the trading platform the server talks to.
+// Coinbase is currently the only live implementation.
+type Exchange interface {
+	ListPortfolios() (*types.PortfolioResponse, error)
+	CreatePortfolio(name string) (*types.PortfolioCreatedResponse, error)
+	PortfolioDetails(portfolioID string) (*types.PortfolioDetailsResponse, error)
+	ListProducts(productIDs []string) (*types.ProductResponse, error)
+	GetBestBidAsk(productID string) (*types.BestBidAskResponse, error)
+	PlaceOrder(offer *types.Offer) (*types.CoinbaseOrderPlacedResponse, error)
+	PreviewOrder(offer *types.Offer) (*types.CoinbaseOrderPreviewResponse, error)
+	GetOrder(orderID string) (*types.Order, error)
+	ListOpenOrders(portfolioID string) ([]types.Order, error)
+	CancelOrders(orderIDs []string) (*types.CancelOrdersResponse, error)
+	TransferFunds(req *types.TransferRequest) (*types.TransferFundsResponse, error)
+}
@@ -0,0 +1,87 @@
+package exchange
+
+import (
+	"encoding/json"
+
+	"github.com/iPopcorn/investment-manager/server/journal"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// JournaledExchange records every order and transfer in the journal along with the exchange's
+// response, whichever handler, strategy or monitor asked for it.
+// Every other Exchange method is passed straight through.
+type JournaledExchange struct {
+	Exchange
+	journal *journal.Journal
+}
+
+func JournaledExchangeFactory(ex Exchange, j *journal.Journal) *JournaledExchange {
+	return &JournaledExchange{
+		Exchange: ex,
+		journal:  j,
+	}
+}
+
+func (e *JournaledExchange) PlaceOrder(offer *types.Offer) (*types.CoinbaseOrderPlacedResponse, error) {
+	resp, err := e.Exchange.PlaceOrder(offer)
+
+	entry := types.JournalEntry{
+		Event:         types.OrderPlaced,
+		PortfolioID:   offer.RetailPortfolioId,
+		ProductID:     offer.ProductId,
+		Side:          offer.Side,
+		ClientOrderID: offer.ClientOrderId,
+		Order:         &offer.Config,
+	}
+
+	switch {
+	case err != nil:
+		entry.Event = types.OrderFailed
+		entry.Error = err.Error()
+	case !resp.Success:
+		entry.Event = types.OrderFailed
+		entry.Error = resp.FailureReason
+	}
+
+	if resp != nil {
+		entry.OrderID = resp.GetOrderID()
+		entry.Response = serializeResponse(resp)
+	}
+
+	e.journal.Record(entry)
+
+	return resp, err
+}
+
+func (e *JournaledExchange) TransferFunds(req *types.TransferRequest) (*types.TransferFundsResponse, error) {
+	resp, err := e.Exchange.TransferFunds(req)
+
+	entry := types.JournalEntry{
+		Event:       types.FundsTransferred,
+		PortfolioID: req.SenderID,
+		Transfer:    req,
+	}
+
+	if err != nil {
+		entry.Event = types.TransferFailed
+		entry.Error = err.Error()
+	}
+
+	if resp != nil {
+		entry.Response = serializeResponse(resp)
+	}
+
+	e.journal.Record(entry)
+
+	return resp, err
+}
+
+func serializeResponse(resp any) json.RawMessage {
+	data, err := json.Marshal(resp)
+
+	if err != nil {
+		return nil
+	}
+
+	return data
+}
@@ -0,0 +1,999 @@
+package exchange
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fossoreslp/go-uuid-v4"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+// PriceFeed supplies the market data the paper exchange fills orders against.
+type PriceFeed interface {
+	ListProducts(productIDs []string) (*types.ProductResponse, error)
+	GetBestBidAsk(productID string) (*types.BestBidAskResponse, error)
+}
+
+// PaperExchange simulates portfolios and order fills against a live price feed
+// so strategies can run without placing real orders.
+type PaperExchange struct {
+	mu            sync.Mutex
+	feed          PriceFeed
+	filename      string
+	orderSettings types.OrderSettings
+	now           func() time.Time
+	state         paperState
+}
+
+type PaperExchangeArgs struct {
+	Feed          PriceFeed
+	Filename      string // File in the data directory to persist balances in, no persistence if empty
+	PortfolioName string
+	QuoteCurrency string
+	StartingFunds float64
+	OrderSettings types.OrderSettings // Commission charged on fills
+}
+
+type paperState struct {
+	QuoteCurrency string           `json:"quote_currency"`
+	Portfolios    []paperPortfolio `json:"portfolios"`
+	Orders        []paperOrder     `json:"orders"`
+}
+
+type paperPortfolio struct {
+	Name      string             `json:"name"`
+	Uuid      string             `json:"uuid"`
+	Balances  map[string]float64 `json:"balances"`
+	Holds     map[string]float64 `json:"holds"`
+	CostBasis map[string]float64 `json:"cost_basis"`
+}
+
+type paperOrder struct {
+	OrderID            string            `json:"order_id"`
+	Offer              types.Offer       `json:"offer"`
+	Status             types.OrderStatus `json:"status"`
+	CreatedTime        string            `json:"created_time"`
+	HoldAsset          string            `json:"hold_asset"`
+	HoldAmount         float64           `json:"hold_amount"`
+	FilledSize         float64           `json:"filled_size"`
+	AverageFilledPrice float64           `json:"average_filled_price"`
+	TotalFees          float64           `json:"total_fees"`
+	StopTriggered      bool              `json:"stop_triggered,omitempty"` // Set once a stop-limit order has become a limit order
+}
+
+// paperOrderTerms are the parts of an order configuration the paper exchange fills against.
+type paperOrderTerms struct {
+	BaseSize          float64
+	QuoteSize         float64 // Only set for market buys sized in quote currency
+	LimitPrice        float64 // 0 for market orders
+	Market            bool
+	ImmediateOrCancel bool    // Fills straight away or is cancelled, charged taker commission
+	StopPrice         float64 // 0 unless the order is a stop-limit order
+	StopDirection     types.StopDirection
+	EndTime           time.Time // Zero if the order is good til cancelled
+	PostOnly          bool
+}
+
+func PaperExchangeFactory(args PaperExchangeArgs) (*PaperExchange, error) {
+	e := &PaperExchange{
+		feed:          args.Feed,
+		filename:      args.Filename,
+		orderSettings: args.OrderSettings,
+		now:           time.Now,
+	}
+
+	loaded, err := e.load()
+
+	if err != nil {
+		return nil, err
+	}
+
+	if loaded {
+		return e, nil
+	}
+
+	portfolioID, err := uuid.NewString()
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.state = paperState{
+		QuoteCurrency: args.QuoteCurrency,
+		Portfolios: []paperPortfolio{
+			newPaperPortfolio(args.PortfolioName, portfolioID),
+		},
+		Orders: []paperOrder{},
+	}
+
+	e.state.Portfolios[0].Balances[args.QuoteCurrency] = args.StartingFunds
+
+	return e, e.save()
+}
+
+func newPaperPortfolio(name, portfolioID string) paperPortfolio {
+	return paperPortfolio{
+		Name:      name,
+		Uuid:      portfolioID,
+		Balances:  map[string]float64{},
+		Holds:     map[string]float64{},
+		CostBasis: map[string]float64{},
+	}
+}
+
+// Watch settles open orders against the price feed every interval until stop receives.
+func (e *PaperExchange) Watch(interval time.Duration, stop <-chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			e.mu.Lock()
+			err := e.settleAll()
+			e.mu.Unlock()
+
+			if err != nil {
+				log.Printf("PaperExchange.Watch: failed to settle orders\n%v\n", err)
+			}
+		}
+	}
+}
+
+func (e *PaperExchange) ListPortfolios() (*types.PortfolioResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	portfolios := []types.Portfolio{}
+
+	for _, p := range e.state.Portfolios {
+		portfolios = append(portfolios, p.toPortfolio())
+	}
+
+	return &types.PortfolioResponse{Portfolios: portfolios}, nil
+}
+
+func (e *PaperExchange) CreatePortfolio(name string) (*types.PortfolioCreatedResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	portfolioID, err := uuid.NewString()
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := newPaperPortfolio(name, portfolioID)
+	e.state.Portfolios = append(e.state.Portfolios, p)
+
+	return &types.PortfolioCreatedResponse{Portfolio: p.toPortfolio()}, e.save()
+}
+
+func (e *PaperExchange) PortfolioDetails(portfolioID string) (*types.PortfolioDetailsResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	err := e.settleAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := e.findPortfolio(portfolioID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	quote := e.state.QuoteCurrency
+	positions := []types.SpotPositions{}
+	var totalFiat, cashFiat float64
+
+	assets := []string{}
+
+	for asset := range p.Balances {
+		assets = append(assets, asset)
+	}
+
+	sort.Strings(assets)
+
+	for _, asset := range assets {
+		balance := p.Balances[asset]
+
+		if balance <= 0 && asset != quote {
+			continue
+		}
+
+		position := types.SpotPositions{
+			Asset:              asset,
+			AccountUuid:        p.Uuid + "-" + asset,
+			TotalBalanceCrypto: types.NewDecimalFromFloat(balance),
+			CostBasis:          types.Balance{Value: paperDecimal(p.CostBasis[asset]), Currency: quote},
+			IsCash:             asset == quote,
+		}
+
+		var balanceFiat float64
+
+		if asset == quote {
+			balanceFiat = balance
+			position.AvailableToTradeFiat = paperDecimal(balance - p.Holds[asset])
+			cashFiat += balance
+		} else {
+			bid, _, err := e.getPrices(asset + "-" + quote)
+
+			if err != nil {
+				return nil, err
+			}
+
+			balanceFiat = balance * bid
+			position.AvailableToTradeFiat = paperDecimal((balance - p.Holds[asset]) * bid)
+		}
+
+		position.TotalBalanceFiat = paperDecimal(balanceFiat)
+		totalFiat += balanceFiat
+		positions = append(positions, position)
+	}
+
+	for i := range positions {
+		if totalFiat > 0 {
+			positions[i].Allocation = positions[i].TotalBalanceFiat.Float64() / totalFiat
+		}
+	}
+
+	zero := types.Balance{Currency: quote}
+
+	return &types.PortfolioDetailsResponse{
+		Breakdown: types.Breakdown{
+			Portfolio: p.toPortfolio(),
+			PortfolioBalances: types.PortfolioBalances{
+				TotalBalance:               types.Balance{Value: paperDecimal(totalFiat), Currency: quote},
+				TotalFuturesBalance:        zero,
+				TotalCashEquivalentBalance: types.Balance{Value: paperDecimal(cashFiat), Currency: quote},
+				TotalCryptoBalance:         types.Balance{Value: paperDecimal(totalFiat - cashFiat), Currency: quote},
+				FuturesUnrealizedPnl:       zero,
+				PerpUnrealizedPnl:          zero,
+			},
+			SpotPositions: positions,
+		},
+	}, nil
+}
+
+func (e *PaperExchange) ListProducts(productIDs []string) (*types.ProductResponse, error) {
+	return e.feed.ListProducts(productIDs)
+}
+
+func (e *PaperExchange) GetBestBidAsk(productID string) (*types.BestBidAskResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	bestBidAsk, err := e.feed.GetBestBidAsk(productID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bid, ask, err := parseBestBidAsk(bestBidAsk)
+
+	if err != nil {
+		return nil, err
+	}
+
+	e.settle(productID, bid, ask)
+
+	return bestBidAsk, e.save()
+}
+
+func (e *PaperExchange) PlaceOrder(offer *types.Offer) (*types.CoinbaseOrderPlacedResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order, failureReason, err := e.newOrder(offer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if failureReason != "" {
+		log.Printf("PaperExchange: rejected order %q: %s\n", offer.ClientOrderId, failureReason)
+
+		return &types.CoinbaseOrderPlacedResponse{
+			Success:       false,
+			FailureReason: failureReason,
+			ErrorResponse: types.CoinbaseOrderPlacedErrorResponse{
+				Error:   failureReason,
+				Message: failureReason,
+			},
+		}, nil
+	}
+
+	p, _ := e.findPortfolio(offer.RetailPortfolioId)
+	p.Holds[order.HoldAsset] += order.HoldAmount
+	e.state.Orders = append(e.state.Orders, *order)
+
+	// Immediate or cancel orders are done before the response goes back
+	if terms, _ := getPaperOrderTerms(offer.Config); terms.ImmediateOrCancel {
+		bid, ask, err := e.getPrices(offer.ProductId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e.settleImmediateOrCancel(&e.state.Orders[len(e.state.Orders)-1], terms, bid, ask)
+	}
+
+	log.Printf("PaperExchange: placed order %q\n%+v\n", order.OrderID, order.Offer)
+
+	return &types.CoinbaseOrderPlacedResponse{
+		Success: true,
+		OrderID: order.OrderID,
+		SuccessResponse: types.CoinbaseOrderPlacedSuccessResponse{
+			OrderID:       order.OrderID,
+			ProductID:     offer.ProductId,
+			Side:          string(offer.Side),
+			ClientOrderID: offer.ClientOrderId,
+		},
+	}, e.save()
+}
+
+func (e *PaperExchange) PreviewOrder(offer *types.Offer) (*types.CoinbaseOrderPreviewResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order, failureReason, err := e.newOrder(offer)
+
+	if err != nil {
+		return nil, err
+	}
+
+	bid, ask, err := e.getPrices(offer.ProductId)
+
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &types.CoinbaseOrderPreviewResponse{
+		Errors:   []string{},
+		Warnings: []string{},
+		BestBid:  formatPaperAmount(bid),
+		BestAsk:  formatPaperAmount(ask),
+	}
+
+	if failureReason != "" {
+		preview.Errors = append(preview.Errors, "PREVIEW_"+failureReason)
+
+		return preview, nil
+	}
+
+	terms, _ := getPaperOrderTerms(offer.Config)
+	commissionRate := e.orderSettings.CommissionRate(terms.ImmediateOrCancel)
+
+	// Market and immediate orders fill at the other side of the book, resting orders at their limit price
+	price := terms.LimitPrice
+	if terms.ImmediateOrCancel && order.Offer.Side == types.BUY {
+		price = ask
+	} else if terms.ImmediateOrCancel {
+		price = bid
+	}
+
+	baseSize := terms.BaseSize
+	quoteSize := baseSize * price
+
+	if terms.QuoteSize > 0 {
+		quoteSize = terms.QuoteSize / (1 + commissionRate)
+		baseSize = quoteSize / price
+	}
+
+	commission := quoteSize * commissionRate
+
+	preview.BaseSize = formatPaperAmount(baseSize)
+	preview.QuoteSize = formatPaperAmount(quoteSize)
+	preview.CommissionTotal = formatPaperAmount(commission)
+
+	if order.Offer.Side == types.BUY {
+		preview.OrderTotal = formatPaperAmount(quoteSize + commission)
+	} else {
+		preview.OrderTotal = formatPaperAmount(quoteSize - commission)
+	}
+
+	return preview, nil
+}
+
+func (e *PaperExchange) GetOrder(orderID string) (*types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	order := e.findOrder(orderID)
+
+	if order == nil {
+		return nil, fmt.Errorf("Could not find paper order\nGiven: %q", orderID)
+	}
+
+	if order.Status == types.OPEN {
+		bid, ask, err := e.getPrices(order.Offer.ProductId)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e.settle(order.Offer.ProductId, bid, ask)
+
+		err = e.save()
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return order.toOrder(), nil
+}
+
+func (e *PaperExchange) ListOpenOrders(portfolioID string) ([]types.Order, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	// Settle first so orders the current prices have filled aren't listed
+	err := e.settleAll()
+
+	if err != nil {
+		return nil, err
+	}
+
+	orders := []types.Order{}
+
+	for i := range e.state.Orders {
+		order := &e.state.Orders[i]
+
+		if order.Status == types.OPEN && order.Offer.RetailPortfolioId == portfolioID {
+			orders = append(orders, *order.toOrder())
+		}
+	}
+
+	return orders, nil
+}
+
+func (e *PaperExchange) CancelOrders(orderIDs []string) (*types.CancelOrdersResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	resp := &types.CancelOrdersResponse{Results: []types.CancelOrderResult{}}
+
+	for _, orderID := range orderIDs {
+		result := types.CancelOrderResult{OrderID: orderID}
+		order := e.findOrder(orderID)
+
+		switch {
+		case order == nil:
+			result.FailureReason = "UNKNOWN_CANCEL_ORDER"
+		case order.Status != types.OPEN:
+			result.FailureReason = "INVALID_CANCEL_REQUEST"
+		default:
+			e.closeOrder(order, types.CANCELLED)
+			result.Success = true
+		}
+
+		resp.Results = append(resp.Results, result)
+	}
+
+	return resp, e.save()
+}
+
+func (e *PaperExchange) TransferFunds(req *types.TransferRequest) (*types.TransferFundsResponse, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	amount := req.Amount.Float64()
+
+	if amount <= 0 {
+		return nil, fmt.Errorf("Invalid transfer amount\nGiven: %s", req.Amount)
+	}
+
+	sender, err := e.findPortfolio(req.SenderID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	receiver, err := e.findPortfolio(req.ReceiverID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	quote := e.state.QuoteCurrency
+
+	if req.Currency != "" && !strings.EqualFold(req.Currency, quote) {
+		return nil, fmt.Errorf("Paper portfolios only hold %s\nGiven: %q", quote, req.Currency)
+	}
+
+	if sender.Balances[quote]-sender.Holds[quote] < amount {
+		return nil, fmt.Errorf("Insufficient funds in paper portfolio %q to transfer %s %s", sender.Name, req.Amount, quote)
+	}
+
+	sender.Balances[quote] -= amount
+	receiver.Balances[quote] += amount
+
+	return &types.TransferFundsResponse{
+		SenderID:   sender.Uuid,
+		ReceiverID: receiver.Uuid,
+	}, e.save()
+}
+
+// newOrder validates the offer and works out the funds it needs to hold.
+// A non-empty failure reason means the exchange would reject the order.
+func (e *PaperExchange) newOrder(offer *types.Offer) (*paperOrder, string, error) {
+	p, err := e.findPortfolio(offer.RetailPortfolioId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	terms, failureReason := getPaperOrderTerms(offer.Config)
+
+	if failureReason != "" {
+		return nil, failureReason, nil
+	}
+
+	if !terms.EndTime.IsZero() && !terms.EndTime.After(e.now()) {
+		return nil, "INVALID_END_TIME", nil
+	}
+
+	bid, ask, err := e.getPrices(offer.ProductId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	if terms.PostOnly && ((offer.Side == types.BUY && terms.LimitPrice >= ask) || (offer.Side == types.SELL && terms.LimitPrice <= bid)) {
+		return nil, "INVALID_LIMIT_PRICE_POST_ONLY", nil
+	}
+
+	if terms.StopPrice > 0 && stopTriggered(terms, bid, ask) {
+		return nil, "INVALID_STOP_PRICE", nil
+	}
+
+	baseCurrency, quoteCurrency, err := splitProductID(offer.ProductId)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	order := &paperOrder{
+		Offer:       *offer,
+		Status:      types.OPEN,
+		CreatedTime: e.now().Format(time.RFC3339),
+	}
+
+	if offer.Side == types.BUY {
+		order.HoldAsset = quoteCurrency
+		order.HoldAmount = terms.holdAmount(ask, e.orderSettings.CommissionRate(terms.ImmediateOrCancel))
+	} else {
+		order.HoldAsset = baseCurrency
+		order.HoldAmount = terms.BaseSize
+	}
+
+	if p.Balances[order.HoldAsset]-p.Holds[order.HoldAsset] < order.HoldAmount {
+		return nil, "INSUFFICIENT_FUND", nil
+	}
+
+	order.OrderID, err = uuid.NewString()
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return order, "", nil
+}
+
+func (e *PaperExchange) settleAll() error {
+	products := map[string]bool{}
+
+	for _, order := range e.state.Orders {
+		if order.Status == types.OPEN {
+			products[order.Offer.ProductId] = true
+		}
+	}
+
+	for productID := range products {
+		bid, ask, err := e.getPrices(productID)
+
+		if err != nil {
+			return err
+		}
+
+		e.settle(productID, bid, ask)
+	}
+
+	return e.save()
+}
+
+// settle expires stale orders and fills any open order the current prices have crossed.
+func (e *PaperExchange) settle(productID string, bid, ask float64) {
+	now := e.now()
+
+	for i := range e.state.Orders {
+		order := &e.state.Orders[i]
+
+		if order.Status != types.OPEN || order.Offer.ProductId != productID {
+			continue
+		}
+
+		terms, _ := getPaperOrderTerms(order.Offer.Config)
+
+		if terms == nil {
+			continue
+		}
+
+		if !terms.EndTime.IsZero() && !now.Before(terms.EndTime) {
+			log.Printf("PaperExchange: order %q expired\n", order.OrderID)
+			e.closeOrder(order, types.EXPIRED)
+
+			continue
+		}
+
+		if terms.ImmediateOrCancel {
+			e.settleImmediateOrCancel(order, terms, bid, ask)
+
+			continue
+		}
+
+		if terms.StopPrice > 0 && !order.StopTriggered {
+			if !stopTriggered(terms, bid, ask) {
+				continue
+			}
+
+			log.Printf("PaperExchange: stop triggered for order %q\n", order.OrderID)
+			order.StopTriggered = true
+		}
+
+		if (order.Offer.Side == types.BUY && ask <= terms.LimitPrice) || (order.Offer.Side == types.SELL && bid >= terms.LimitPrice) {
+			e.fill(order, terms.LimitPrice)
+		}
+	}
+}
+
+func (e *PaperExchange) fill(order *paperOrder, price float64) {
+	p, err := e.findPortfolio(order.Offer.RetailPortfolioId)
+
+	if err != nil {
+		log.Printf("PaperExchange: cannot fill order %q\n%v\n", order.OrderID, err)
+		return
+	}
+
+	baseCurrency, quoteCurrency, _ := splitProductID(order.Offer.ProductId)
+	terms, _ := getPaperOrderTerms(order.Offer.Config)
+	commissionRate := e.orderSettings.CommissionRate(terms.ImmediateOrCancel)
+	baseSize := terms.BaseSize
+
+	if terms.QuoteSize > 0 {
+		baseSize = terms.QuoteSize / (1 + commissionRate) / price
+	}
+
+	value := baseSize * price
+	fee := value * commissionRate
+
+	if order.Offer.Side == types.BUY {
+		p.Balances[quoteCurrency] -= value + fee
+		p.Balances[baseCurrency] += baseSize
+		p.CostBasis[baseCurrency] += value + fee
+	} else {
+		heldBefore := p.Balances[baseCurrency]
+
+		if heldBefore > 0 {
+			p.CostBasis[baseCurrency] -= p.CostBasis[baseCurrency] * baseSize / heldBefore
+		}
+
+		p.Balances[baseCurrency] -= baseSize
+		p.Balances[quoteCurrency] += value - fee
+	}
+
+	order.FilledSize = baseSize
+	order.AverageFilledPrice = price
+	order.TotalFees = fee
+
+	log.Printf("PaperExchange: filled order %q, %s %f %s at %f\n", order.OrderID, order.Offer.Side, baseSize, baseCurrency, price)
+	e.closeOrder(order, types.FILLED)
+}
+
+// settleImmediateOrCancel fills the order at the best price on the other side of the book
+// if it can, and cancels it otherwise.
+func (e *PaperExchange) settleImmediateOrCancel(order *paperOrder, terms *paperOrderTerms, bid, ask float64) {
+	if order.Offer.Side == types.BUY && (terms.Market || ask <= terms.LimitPrice) {
+		e.fill(order, ask)
+	} else if order.Offer.Side == types.SELL && (terms.Market || bid >= terms.LimitPrice) {
+		e.fill(order, bid)
+	} else {
+		log.Printf("PaperExchange: order %q could not fill immediately, cancelling\n", order.OrderID)
+		e.closeOrder(order, types.CANCELLED)
+	}
+}
+
+func (e *PaperExchange) closeOrder(order *paperOrder, status types.OrderStatus) {
+	order.Status = status
+
+	p, err := e.findPortfolio(order.Offer.RetailPortfolioId)
+
+	if err != nil {
+		return
+	}
+
+	p.Holds[order.HoldAsset] -= order.HoldAmount
+
+	if p.Holds[order.HoldAsset] < 0 {
+		p.Holds[order.HoldAsset] = 0
+	}
+}
+
+func (e *PaperExchange) getPrices(productID string) (float64, float64, error) {
+	bestBidAsk, err := e.feed.GetBestBidAsk(productID)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return parseBestBidAsk(bestBidAsk)
+}
+
+func (e *PaperExchange) findPortfolio(portfolioID string) (*paperPortfolio, error) {
+	for i := range e.state.Portfolios {
+		if e.state.Portfolios[i].Uuid == portfolioID {
+			return &e.state.Portfolios[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("Could not find paper portfolio\nGiven: %q", portfolioID)
+}
+
+func (e *PaperExchange) findOrder(orderID string) *paperOrder {
+	for i := range e.state.Orders {
+		if e.state.Orders[i].OrderID == orderID {
+			return &e.state.Orders[i]
+		}
+	}
+
+	return nil
+}
+
+func (e *PaperExchange) load() (bool, error) {
+	if e.filename == "" {
+		return false, nil
+	}
+
+	filepath, err := util.GetPathToDataFile(e.filename)
+
+	if err != nil {
+		return false, err
+	}
+
+	data, err := os.ReadFile(filepath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	err = json.Unmarshal(data, &e.state)
+
+	if err != nil {
+		return false, fmt.Errorf("Failed to de-serialize paper exchange state\n%v", err)
+	}
+
+	return true, nil
+}
+
+func (e *PaperExchange) save() error {
+	if e.filename == "" {
+		return nil
+	}
+
+	filepath, err := util.GetPathToDataFile(e.filename)
+
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(e.state)
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath, data, 0666)
+}
+
+func (p paperPortfolio) toPortfolio() types.Portfolio {
+	return types.Portfolio{
+		Name: p.Name,
+		Uuid: p.Uuid,
+		Type: "PAPER",
+	}
+}
+
+func (o paperOrder) toOrder() *types.Order {
+	completion := "0"
+
+	if o.Status == types.FILLED {
+		completion = "100"
+	}
+
+	return &types.Order{
+		OrderID:              o.OrderID,
+		ProductID:            o.Offer.ProductId,
+		Side:                 o.Offer.Side,
+		ClientOrderID:        o.Offer.ClientOrderId,
+		Status:               o.Status,
+		OrderConfiguration:   o.Offer.Config,
+		CreatedTime:          o.CreatedTime,
+		CompletionPercentage: completion,
+		FilledSize:           paperDecimal(o.FilledSize),
+		AverageFilledPrice:   paperDecimal(o.AverageFilledPrice),
+		FilledValue:          paperDecimal(o.FilledSize * o.AverageFilledPrice),
+		TotalFees:            paperDecimal(o.TotalFees),
+		RetailPortfolioID:    o.Offer.RetailPortfolioId,
+	}
+}
+
+// getPaperOrderTerms reads the order configuration. A non-empty failure reason means the
+// exchange would reject the order.
+func getPaperOrderTerms(config types.OrderConfiguration) (*paperOrderTerms, string) {
+	var baseSize, limitPrice, stopPrice types.Decimal
+	var endTime string
+	terms := &paperOrderTerms{}
+
+	switch {
+	case config.MarketMarketIOC != nil:
+		terms.Market = true
+		terms.ImmediateOrCancel = true
+
+		if quoteSize := config.MarketMarketIOC.QuoteSize; !quoteSize.IsZero() {
+			if quoteSize.Sign() < 0 {
+				return nil, "INVALID_SIZE_PRECISION"
+			}
+
+			terms.QuoteSize = quoteSize.Float64()
+		} else {
+			baseSize = config.MarketMarketIOC.BaseSize
+		}
+	case config.SorLimitIOC != nil:
+		baseSize = config.SorLimitIOC.BaseSize
+		limitPrice = config.SorLimitIOC.LimitPrice
+		terms.ImmediateOrCancel = true
+	case config.LimitLimitGTC != nil:
+		baseSize = config.LimitLimitGTC.BaseSize
+		limitPrice = config.LimitLimitGTC.LimitPrice
+		terms.PostOnly = config.LimitLimitGTC.PostOnly
+	case config.LimitLimitGTD != nil:
+		baseSize = config.LimitLimitGTD.BaseSize
+		limitPrice = config.LimitLimitGTD.LimitPrice
+		endTime = config.LimitLimitGTD.EndTime
+		terms.PostOnly = config.LimitLimitGTD.PostOnly
+	case config.StopLimitStopLimitGTC != nil:
+		baseSize = config.StopLimitStopLimitGTC.BaseSize
+		limitPrice = config.StopLimitStopLimitGTC.LimitPrice
+		stopPrice = config.StopLimitStopLimitGTC.StopPrice
+		terms.StopDirection = config.StopLimitStopLimitGTC.StopDirection
+	case config.StopLimitStopLimitGTD != nil:
+		baseSize = config.StopLimitStopLimitGTD.BaseSize
+		limitPrice = config.StopLimitStopLimitGTD.LimitPrice
+		stopPrice = config.StopLimitStopLimitGTD.StopPrice
+		endTime = config.StopLimitStopLimitGTD.EndTime
+		terms.StopDirection = config.StopLimitStopLimitGTD.StopDirection
+	default:
+		return nil, "UNSUPPORTED_ORDER_CONFIGURATION"
+	}
+
+	if terms.QuoteSize == 0 {
+		if baseSize.Sign() <= 0 {
+			return nil, "INVALID_SIZE_PRECISION"
+		}
+
+		terms.BaseSize = baseSize.Float64()
+	}
+
+	if !terms.Market {
+		if limitPrice.Sign() <= 0 {
+			return nil, "INVALID_LIMIT_PRICE"
+		}
+
+		terms.LimitPrice = limitPrice.Float64()
+	}
+
+	isStopLimit := config.StopLimitStopLimitGTC != nil || config.StopLimitStopLimitGTD != nil
+
+	if isStopLimit {
+		if stopPrice.Sign() <= 0 {
+			return nil, "INVALID_STOP_PRICE"
+		}
+
+		terms.StopPrice = stopPrice.Float64()
+	}
+
+	var err error
+
+	// Only the GTD configurations have an end time
+	if config.LimitLimitGTD != nil || config.StopLimitStopLimitGTD != nil {
+		terms.EndTime, err = time.Parse(time.RFC3339, endTime)
+
+		if err != nil {
+			return nil, "INVALID_END_TIME"
+		}
+	}
+
+	return terms, ""
+}
+
+// holdAmount is the quote currency a buy order needs to hold.
+func (t *paperOrderTerms) holdAmount(ask float64, commissionRate float64) float64 {
+	if t.QuoteSize > 0 {
+		return t.QuoteSize
+	}
+
+	price := t.LimitPrice
+
+	if t.Market {
+		price = ask
+	}
+
+	return t.BaseSize * price * (1 + commissionRate)
+}
+
+// stopTriggered reports whether the price has reached the stop price of a stop-limit order.
+func stopTriggered(terms *paperOrderTerms, bid, ask float64) bool {
+	if terms.StopDirection == types.StopDirectionUp {
+		return ask >= terms.StopPrice
+	}
+
+	return bid <= terms.StopPrice
+}
+
+func parseBestBidAsk(bestBidAsk *types.BestBidAskResponse) (float64, float64, error) {
+	if len(bestBidAsk.PriceBooks) != 1 || len(bestBidAsk.PriceBooks[0].Bids) < 1 || len(bestBidAsk.PriceBooks[0].Asks) < 1 {
+		return 0, 0, fmt.Errorf("Invalid price book from price feed: %+v", bestBidAsk)
+	}
+
+	bid, err := strconv.ParseFloat(bestBidAsk.PriceBooks[0].Bids[0].Price, 64)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ask, err := strconv.ParseFloat(bestBidAsk.PriceBooks[0].Asks[0].Price, 64)
+
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return bid, ask, nil
+}
+
+func splitProductID(productID string) (string, string, error) {
+	tokens := strings.Split(productID, "-")
+
+	if len(tokens) != 2 {
+		return "", "", fmt.Errorf("Invalid product id\nGiven: %q", productID)
+	}
+
+	return tokens[0], tokens[1], nil
+}
+
+func formatPaperAmount(amount float64) string {
+	return strconv.FormatFloat(amount, 'f', 8, 64)
+}
+
+// paperDecimal converts the paper exchange's internal float balances at the same 8 decimal places Coinbase reports.
+func paperDecimal(amount float64) types.Decimal {
+	return types.MustParseDecimal(formatPaperAmount(amount))
+}
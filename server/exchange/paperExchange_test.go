@@ -0,0 +1,299 @@
+package exchange
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+type testPriceFeed struct {
+	bid string
+	ask string
+}
+
+func (f *testPriceFeed) ListProducts(productIDs []string) (*types.ProductResponse, error) {
+	return &types.ProductResponse{}, nil
+}
+
+func (f *testPriceFeed) GetBestBidAsk(productID string) (*types.BestBidAskResponse, error) {
+	return &types.BestBidAskResponse{
+		PriceBooks: []types.PriceBook{
+			{
+				ProductID: productID,
+				Bids:      []types.Bid{{Price: f.bid, Size: "1"}},
+				Asks:      []types.Bid{{Price: f.ask, Size: "1"}},
+			},
+		},
+	}, nil
+}
+
+func getTestPaperExchange(t *testing.T, feed *testPriceFeed, now time.Time) (*PaperExchange, string) {
+	t.Helper()
+
+	paperExchange, err := PaperExchangeFactory(PaperExchangeArgs{
+		Feed:          feed,
+		PortfolioName: "paper",
+		QuoteCurrency: "GBP",
+		StartingFunds: 1000,
+	})
+
+	if err != nil {
+		t.Fatalf("Failed to create paper exchange\n%v", err)
+	}
+
+	paperExchange.now = func() time.Time { return now }
+
+	portfolios, _ := paperExchange.ListPortfolios()
+
+	return paperExchange, portfolios.Portfolios[0].Uuid
+}
+
+func getTestOffer(portfolioID string, side types.Side, baseSize, limitPrice string, endTime time.Time) *types.Offer {
+	return &types.Offer{
+		ClientOrderId: "test-client-order-id",
+		ProductId:     "ETH-GBP",
+		Side:          side,
+		Config: types.OrderConfiguration{
+			LimitLimitGTD: &types.LimitLimitGTD{
+				BaseSize:   types.MustParseDecimal(baseSize),
+				LimitPrice: types.MustParseDecimal(limitPrice),
+				EndTime:    endTime.Format(time.RFC3339),
+				PostOnly:   true,
+			},
+		},
+		RetailPortfolioId: portfolioID,
+	}
+}
+
+func getPosition(t *testing.T, e *PaperExchange, portfolioID, asset string) types.SpotPositions {
+	t.Helper()
+
+	details, err := e.PortfolioDetails(portfolioID)
+
+	if err != nil {
+		t.Fatalf("Failed to get portfolio details\n%v", err)
+	}
+
+	for _, position := range details.Breakdown.SpotPositions {
+		if position.Asset == asset {
+			return position
+		}
+	}
+
+	t.Fatalf("No %s position found in %+v", asset, details.Breakdown.SpotPositions)
+	return types.SpotPositions{}
+}
+
+func assertFloatEquals(expected, actual float64, t *testing.T) {
+	t.Helper()
+
+	if math.Abs(expected-actual) > 0.000001 {
+		t.Errorf("Expected: %f Actual: %f", expected, actual)
+	}
+}
+
+func TestPaperExchange(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Fills a buy order when the price crosses the limit price", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(getTestOffer(portfolioID, types.BUY, "2", "100", now.Add(time.Minute*5)))
+
+		if err != nil || !resp.Success {
+			t.Fatalf("Failed to place order\nresp: %+v\nerr: %v", resp, err)
+		}
+
+		cash := getPosition(t, paperExchange, portfolioID, "GBP")
+		assertFloatEquals(1000, cash.TotalBalanceFiat.Float64(), t)
+		assertFloatEquals(1000-200*(1+types.MakerCommissionRate), cash.AvailableToTradeFiat.Float64(), t)
+
+		feed.ask = "100"
+		feed.bid = "99.5"
+
+		cash = getPosition(t, paperExchange, portfolioID, "GBP")
+		eth := getPosition(t, paperExchange, portfolioID, "ETH")
+
+		assertFloatEquals(1000-200*(1+types.MakerCommissionRate), cash.TotalBalanceFiat.Float64(), t)
+		assertFloatEquals(cash.TotalBalanceFiat.Float64(), cash.AvailableToTradeFiat.Float64(), t)
+		assertFloatEquals(2, eth.TotalBalanceCrypto.Float64(), t)
+	})
+
+	t.Run("Rejects post only orders that would take liquidity", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(getTestOffer(portfolioID, types.BUY, "1", "102", now.Add(time.Minute*5)))
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if resp.Success {
+			t.Fatalf("Expected post only order to be rejected")
+		}
+	})
+
+	t.Run("Rejects orders without enough funds", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(getTestOffer(portfolioID, types.BUY, "20", "100", now.Add(time.Minute*5)))
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if resp.Success {
+			t.Fatalf("Expected order to be rejected for insufficient funds")
+		}
+	})
+
+	t.Run("Expires orders after their end time", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(getTestOffer(portfolioID, types.BUY, "2", "100", now.Add(time.Minute*5)))
+
+		if err != nil || !resp.Success {
+			t.Fatalf("Failed to place order\nresp: %+v\nerr: %v", resp, err)
+		}
+
+		paperExchange.now = func() time.Time { return now.Add(time.Minute * 6) }
+		feed.ask = "100"
+
+		cash := getPosition(t, paperExchange, portfolioID, "GBP")
+		assertFloatEquals(1000, cash.TotalBalanceFiat.Float64(), t)
+		assertFloatEquals(1000, cash.AvailableToTradeFiat.Float64(), t)
+
+		if paperExchange.findOrder(resp.OrderID).Status != types.EXPIRED {
+			t.Errorf("Expected order to be expired")
+		}
+	})
+
+	t.Run("Sells with a stop-limit order once the price falls to the stop price", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+		paperExchange.state.Portfolios[0].Balances["ETH"] = 2
+
+		resp, err := paperExchange.PlaceOrder(&types.Offer{
+			ClientOrderId: "test-client-order-id",
+			ProductId:     "ETH-GBP",
+			Side:          types.SELL,
+			Config: types.OrderConfiguration{
+				StopLimitStopLimitGTC: &types.StopLimitStopLimitGTC{
+					BaseSize:      types.MustParseDecimal("2"),
+					LimitPrice:    types.MustParseDecimal("89"),
+					StopPrice:     types.MustParseDecimal("90"),
+					StopDirection: types.StopDirectionDown,
+				},
+			},
+			RetailPortfolioId: portfolioID,
+		})
+
+		if err != nil || !resp.Success {
+			t.Fatalf("Failed to place order\nresp: %+v\nerr: %v", resp, err)
+		}
+
+		feed.bid = "95"
+
+		if getPosition(t, paperExchange, portfolioID, "ETH").TotalBalanceCrypto.Float64() != 2 {
+			t.Fatalf("Expected stop-limit order not to fill above the stop price")
+		}
+
+		feed.bid = "89.5"
+
+		cash := getPosition(t, paperExchange, portfolioID, "GBP")
+		assertFloatEquals(1000+178*(1-types.MakerCommissionRate), cash.TotalBalanceFiat.Float64(), t)
+
+		if paperExchange.findOrder(resp.OrderID).Status != types.FILLED {
+			t.Errorf("Expected order to be filled")
+		}
+	})
+
+	t.Run("Fills market orders immediately at the best ask", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "100"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(&types.Offer{
+			ClientOrderId: "test-client-order-id",
+			ProductId:     "ETH-GBP",
+			Side:          types.BUY,
+			Config: types.OrderConfiguration{
+				MarketMarketIOC: &types.MarketMarketIOC{QuoteSize: types.MustParseDecimal("100.6")},
+			},
+			RetailPortfolioId: portfolioID,
+		})
+
+		if err != nil || !resp.Success {
+			t.Fatalf("Failed to place order\nresp: %+v\nerr: %v", resp, err)
+		}
+
+		if paperExchange.findOrder(resp.OrderID).Status != types.FILLED {
+			t.Fatalf("Expected market order to fill immediately")
+		}
+
+		assertFloatEquals(1000-100.6, getPosition(t, paperExchange, portfolioID, "GBP").TotalBalanceFiat.Float64(), t)
+		assertFloatEquals(1, getPosition(t, paperExchange, portfolioID, "ETH").TotalBalanceCrypto.Float64(), t)
+	})
+
+	t.Run("Cancels immediate or cancel orders that can not fill", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(&types.Offer{
+			ClientOrderId: "test-client-order-id",
+			ProductId:     "ETH-GBP",
+			Side:          types.BUY,
+			Config: types.OrderConfiguration{
+				SorLimitIOC: &types.SorLimitIOC{BaseSize: types.MustParseDecimal("1"), LimitPrice: types.MustParseDecimal("100")},
+			},
+			RetailPortfolioId: portfolioID,
+		})
+
+		if err != nil || !resp.Success {
+			t.Fatalf("Failed to place order\nresp: %+v\nerr: %v", resp, err)
+		}
+
+		if paperExchange.findOrder(resp.OrderID).Status != types.CANCELLED {
+			t.Errorf("Expected order to be cancelled")
+		}
+
+		assertFloatEquals(1000, getPosition(t, paperExchange, portfolioID, "GBP").AvailableToTradeFiat.Float64(), t)
+	})
+	t.Run("Lists only the portfolio's open orders", func(t *testing.T) {
+		feed := &testPriceFeed{bid: "99", ask: "101"}
+		paperExchange, portfolioID := getTestPaperExchange(t, feed, now)
+
+		resp, err := paperExchange.PlaceOrder(getTestOffer(portfolioID, types.BUY, "1", "98", now.Add(time.Minute*5)))
+
+		if err != nil || !resp.Success {
+			t.Fatalf("Failed to place order\nresp: %+v\nerr: %v", resp, err)
+		}
+
+		orders, err := paperExchange.ListOpenOrders(portfolioID)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if len(orders) != 1 || orders[0].OrderID != resp.OrderID {
+			t.Fatalf("Expected order %q to be listed, got %+v", resp.OrderID, orders)
+		}
+
+		_, err = paperExchange.CancelOrders([]string{resp.OrderID})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		orders, _ = paperExchange.ListOpenOrders(portfolioID)
+
+		if len(orders) != 0 {
+			t.Errorf("Expected no open orders after cancelling, got %+v", orders)
+		}
+	})
+}
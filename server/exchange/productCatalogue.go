@@ -0,0 +1,75 @@
+package exchange
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// ProductCatalogue caches product metadata in front of an exchange.
+// Increments, size limits and trading status rarely change, but are needed every time an
+// order is sized or validated, so they're only fetched again once they're older than the TTL.
+// Every other Exchange method is passed straight through.
+type ProductCatalogue struct {
+	Exchange
+	mu       sync.Mutex
+	ttl      time.Duration
+	now      func() time.Time
+	products map[string]cachedProduct
+}
+
+type cachedProduct struct {
+	product   types.Product
+	fetchedAt time.Time
+}
+
+func ProductCatalogueFactory(ex Exchange, ttl time.Duration) *ProductCatalogue {
+	return &ProductCatalogue{
+		Exchange: ex,
+		ttl:      ttl,
+		now:      time.Now,
+		products: map[string]cachedProduct{},
+	}
+}
+
+// ListProducts returns cached products and only asks the exchange for the ones that are missing or stale.
+// Listing every product always goes to the exchange, the results are cached.
+func (c *ProductCatalogue) ListProducts(productIDs []string) (*types.ProductResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	response := &types.ProductResponse{Products: []types.Product{}}
+	missing := []string{}
+
+	for _, productID := range productIDs {
+		cached, ok := c.products[productID]
+
+		if ok && c.now().Sub(cached.fetchedAt) < c.ttl {
+			response.Products = append(response.Products, cached.product)
+		} else {
+			missing = append(missing, productID)
+		}
+	}
+
+	if len(productIDs) > 0 && len(missing) == 0 {
+		return response, nil
+	}
+
+	fetched, err := c.Exchange.ListProducts(missing)
+
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("ProductCatalogue: fetched %d products\n", len(fetched.Products))
+
+	for _, product := range fetched.Products {
+		c.products[product.ProductID] = cachedProduct{product: product, fetchedAt: c.now()}
+	}
+
+	response.Products = append(response.Products, fetched.Products...)
+
+	return response, nil
+}
@@ -0,0 +1,73 @@
+package exchange
+
+import (
+	"testing"
+	"time"
+)
+
+func TestProductCatalogue(t *testing.T) {
+	responseMap := map[string][]byte{
+		"products": []byte(`{"products": [{
+			"product_id": "ETH-GBP",
+			"price": "2349.55",
+			"base_increment": "0.00000001",
+			"quote_increment": "0.01",
+			"base_min_size": "0.00001",
+			"quote_min_size": "1",
+			"status": "online",
+			"trading_disabled": false,
+			"limit_only": true
+		}]}`),
+	}
+
+	setup := func() (*ProductCatalogue, *[]string) {
+		requestedPaths := []string{}
+		catalogue := ProductCatalogueFactory(getTestCoinbaseExchange(responseMap, &requestedPaths), time.Hour)
+
+		return catalogue, &requestedPaths
+	}
+
+	t.Run("Maps product limits from coinbase", func(t *testing.T) {
+		catalogue, _ := setup()
+
+		resp, err := catalogue.ListProducts([]string{"ETH-GBP"})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if len(resp.Products) != 1 {
+			t.Fatalf("Expected 1 product, found %d", len(resp.Products))
+		}
+
+		product := resp.Products[0]
+
+		if product.BaseMinSize.String() != "0.00001" || product.QuoteIncrement.String() != "0.01" || !product.LimitOnly {
+			t.Errorf("Unexpected product: %+v", product)
+		}
+
+		if err = product.CheckTradable(); err != nil {
+			t.Errorf("Expected product to be tradable\n%v", err)
+		}
+	})
+
+	t.Run("Only asks the exchange again once the cache is stale", func(t *testing.T) {
+		catalogue, requestedPaths := setup()
+		now := time.Now()
+		catalogue.now = func() time.Time { return now }
+
+		catalogue.ListProducts([]string{"ETH-GBP"})
+		catalogue.ListProducts([]string{"ETH-GBP"})
+
+		if len(*requestedPaths) != 1 {
+			t.Fatalf("Expected 1 request to the exchange, sent %d", len(*requestedPaths))
+		}
+
+		now = now.Add(time.Hour)
+		catalogue.ListProducts([]string{"ETH-GBP"})
+
+		if len(*requestedPaths) != 2 {
+			t.Errorf("Expected a stale product to be fetched again, sent %d requests", len(*requestedPaths))
+		}
+	})
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// executeDCA buys the plan's amount per buy every interval until the plan is complete.
+// Progress is saved after every buy so a restarted server resumes from the next buy. The plan
+// is stopped once MaxSkippedBuys buys in a row spend nothing, e.g. because the portfolio has no fiat.
+func executeDCA(args executeStrategyArgs, strategy *types.Strategy) error {
+	plan := strategy.DCA
+
+	if plan == nil {
+		return fmt.Errorf("DCA strategy has no plan")
+	}
+
+	interval, err := time.ParseDuration(plan.Interval)
+
+	if err != nil {
+		return fmt.Errorf("Invalid interval\nGiven: %q\n%v\n", plan.Interval, err)
+	}
+
+	for !plan.IsComplete() && !plan.IsStopped() {
+		nextBuyAt, err := time.Parse(time.RFC3339, plan.NextBuyAt)
+
+		if err != nil {
+			return fmt.Errorf("Invalid next buy time\nGiven: %q\n%v\n", plan.NextBuyAt, err)
+		}
+
+		if wait := time.Until(nextBuyAt); wait > 0 {
+			fmt.Printf("Next DCA buy for %s at %s\n", plan.ProductID, plan.NextBuyAt)
+			time.Sleep(wait)
+		}
+
+		amount := plan.NextAmount()
+		fmt.Printf("DCA buy %d, spending %s\n", plan.BuysCompleted+1, amount)
+
+		spent, err := buyWithRepricing(args, strategy, amount)
+
+		if errors.Is(err, errStrategyReplaced) {
+			return err
+		}
+
+		if err != nil {
+			// A failed buy is skipped rather than stopping the plan, the next one is tried on schedule
+			fmt.Printf("DCA buy failed\n%v\n", err)
+		}
+
+		// Don't try to catch up on buys missed while the server was down
+		next := nextBuyAt.Add(interval)
+		if now := time.Now(); next.Before(now) {
+			next = now
+		}
+
+		err = updateStrategy(args, strategy, func(s *types.Strategy) {
+			if spent.Sign() > 0 {
+				s.DCA.BuysCompleted++
+				s.DCA.AmountSpent = s.DCA.AmountSpent.Add(spent)
+				s.DCA.SkippedBuys = 0
+			} else {
+				s.DCA.SkippedBuys++
+			}
+
+			s.DCA.NextBuyAt = next.Format(time.RFC3339)
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if plan.IsStopped() {
+		return fmt.Errorf("DCA plan stopped after %d buys in a row spent nothing, %d buys spent %s", plan.SkippedBuys, plan.BuysCompleted, plan.AmountSpent)
+	}
+
+	fmt.Printf("DCA plan complete, %d buys spent %s\n", plan.BuysCompleted, plan.AmountSpent)
+
+	return nil
+}
@@ -2,28 +2,40 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/fossoreslp/go-uuid-v4"
-	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/journal"
 	"github.com/iPopcorn/investment-manager/server/server_utils"
 	"github.com/iPopcorn/investment-manager/server/state"
 	"github.com/iPopcorn/investment-manager/types"
 )
 
+const (
+	defaultMaxAttempts   = 5
+	defaultMaxPriceDrift = 1.0 // percent
+	orderPollInterval    = time.Second * 15
+)
+
+var errNothingToSpend = errors.New("No funds available to spend")
+
 type HandleExecuteStrategyArgs struct {
-	Client          *infrastructure.InvestmentManagerExternalHttpClient
-	Writer          http.ResponseWriter
-	Req             *http.Request
-	Args            []string
-	Channels        []chan bool
-	StateRepository *state.StateRepository
+	Exchange          exchange.Exchange
+	Writer            http.ResponseWriter
+	Req               *http.Request
+	Args              []string
+	Channels          []chan bool
+	StateRepository   state.Repository
+	Journal           *journal.Journal
+	AllowedCurrencies []string            // Any currency with a product can be traded when empty
+	OrderSettings     types.OrderSettings // Commission rates and expiry orders are placed with
 }
 
 func HandleExecuteStrategy(args HandleExecuteStrategyArgs) {
@@ -59,10 +71,63 @@ func HandleExecuteStrategy(args HandleExecuteStrategyArgs) {
 		return
 	}
 
-	userPortfolios, err := server_utils.ListPortfolios(args.Client)
+	// Positions and products use upper case currencies
+	requestBody.Currency = types.SupportedCurrency(strings.ToUpper(string(requestBody.Currency)))
+
+	if requestBody.Strategy == types.DCA {
+		err = types.ValidateDCAPlan(requestBody.DCA)
+
+		if err != nil {
+			server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid DCA plan\n%v", err))
+
+			return
+		}
+	}
+
+	err = validateProtection(requestBody.Protection)
+
+	if err == nil {
+		err = validateTrailingStop(requestBody.TrailingStop, requestBody.Protection)
+	}
+
+	if err == nil {
+		err = validateTWAPPlan(requestBody.TWAP, requestBody.Strategy, requestBody.OrderType)
+	}
+
+	if err == nil {
+		err = validateOrderType(requestBody.OrderType, requestBody.Strategy)
+	}
+
+	if err != nil {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid strategy options\n%v", err))
+
+		return
+	}
+
+	if requestBody.Strategy == types.GRID {
+		err = types.ValidateGridPlan(requestBody.Grid)
+
+		if err != nil {
+			server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid grid plan\n%v", err))
+
+			return
+		}
+	}
+
+	if requestBody.Strategy == types.REBALANCE {
+		err = validateRebalancePlan(requestBody.Rebalance)
+
+		if err != nil {
+			server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid rebalance plan\n%v", err))
+
+			return
+		}
+	}
+
+	userPortfolios, err := args.Exchange.ListPortfolios()
 
 	if err != nil || len(userPortfolios.Portfolios) == 0 {
-		log.Printf(handlerName + "Failed to get portfolios from coinbase")
+		log.Printf(handlerName + "Failed to get portfolios from exchange")
 		server_utils.WriteResponse(args.Writer, nil, err)
 
 		return
@@ -82,7 +147,22 @@ func HandleExecuteStrategy(args HandleExecuteStrategyArgs) {
 		return
 	}
 
-	selectedPortfolioDetails, err := server_utils.PortfolioDetails(args.Client, selectedPortfolio.Uuid)
+	// A replaced strategy is archived and stops trading, so its orders are cancelled before it's replaced
+	currentStrategy, err := args.StateRepository.GetStrategy(selectedPortfolio.Uuid)
+
+	if err != nil {
+		server_utils.WriteResponse(args.Writer, nil, fmt.Errorf(handlerName+"Failed to get the current strategy\n%v", err))
+
+		return
+	}
+
+	if currentStrategy != nil && len(currentStrategy.OpenOffers) > 0 {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusConflict, fmt.Errorf("Portfolio %q has a %s strategy with %d open offers, cancel them with 'orders cancel' before starting a new strategy", selectedPortfolio.Name, currentStrategy.Name, len(currentStrategy.OpenOffers)))
+
+		return
+	}
+
+	selectedPortfolioDetails, err := args.Exchange.PortfolioDetails(selectedPortfolio.Uuid)
 
 	if err != nil {
 		fmt.Printf("err: %+v\n", err)
@@ -99,210 +179,564 @@ func HandleExecuteStrategy(args HandleExecuteStrategyArgs) {
 		finished = make(chan bool)
 	}
 
-	productID, err := server_utils.GetProductID(args.Client, selectedPortfolioDetails, string(requestBody.Currency))
+	quoteCurrency := strings.ToUpper(requestBody.QuoteCurrency)
+
+	if quoteCurrency == "" {
+		quoteCurrency, err = server_utils.GetQuoteCurrency(selectedPortfolioDetails)
+
+		if err != nil {
+			fmt.Printf("Error: %+v", err)
+			server_utils.WriteResponse(args.Writer, nil, fmt.Errorf(handlerName+"Failed to detect quote currency\n"))
+
+			return
+		}
+	}
+
+	err = validateCurrencies(&requestBody, quoteCurrency, args.AllowedCurrencies)
 
 	if err != nil {
-		fmt.Printf("Error: %+v", err)
-		server_utils.WriteResponse(args.Writer, nil, fmt.Errorf(handlerName+"Failed to get ProductID\n"))
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid currency\n%v", err))
+
+		return
+	}
+
+	if requestBody.Strategy == types.REBALANCE {
+		handleRebalance(args, selectedPortfolioDetails, requestBody.Rebalance, quoteCurrency, finished)
+
+		return
+	}
+
+	productID, err := server_utils.GetProductID(args.Exchange, selectedPortfolioDetails, string(requestBody.Currency), quoteCurrency)
+
+	if err != nil {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Currency %q is not supported, no tradable product for %s\n%v", requestBody.Currency, quoteCurrency, err))
 
 		return
 	}
 
 	executeStrategyArgs := executeStrategyArgs{
-		Client:           args.Client,
-		PortfolioDetails: selectedPortfolioDetails,
+		Exchange:         args.Exchange,
+		Portfolio:        selectedPortfolioDetails.Breakdown.Portfolio,
 		StateRepository:  args.StateRepository,
+		Journal:          args.Journal,
 		ProductID:        productID,
+		QuoteCurrency:    quoteCurrency,
 		StrategyName:     requestBody.Strategy,
 		StrategyCurrency: requestBody.Currency,
+		MaxAttempts:      requestBody.MaxAttempts,
+		MaxPriceDrift:    requestBody.MaxPriceDrift,
+		Protection:       requestBody.Protection,
+		TrailingStop:     requestBody.TrailingStop,
+		OrderType:        requestBody.OrderType,
+		OrderSettings:    args.OrderSettings,
 		Finished:         finished,
 	}
 
+	if requestBody.Strategy == types.DCA {
+		executeStrategyArgs.DCA = &types.DCAPlan{
+			AmountPerBuy: requestBody.DCA.AmountPerBuy,
+			Interval:     requestBody.DCA.Interval,
+			TotalBudget:  requestBody.DCA.TotalBudget,
+			NumberOfBuys: requestBody.DCA.NumberOfBuys,
+			NextBuyAt:    time.Now().Format(time.RFC3339),
+			ProductID:    productID,
+		}
+	}
+
+	if requestBody.TWAP != nil {
+		executeStrategyArgs.TWAP = &types.TWAPPlan{
+			Slices:    requestBody.TWAP.Slices,
+			Duration:  requestBody.TWAP.Duration,
+			ProductID: productID,
+		}
+	}
+
+	if requestBody.Strategy == types.GRID {
+		executeStrategyArgs.Grid = &types.GridPlan{
+			LowerPrice: requestBody.Grid.LowerPrice,
+			UpperPrice: requestBody.Grid.UpperPrice,
+			Levels:     requestBody.Grid.Levels,
+			Capital:    requestBody.Grid.Capital,
+			ProductID:  productID,
+		}
+	}
+
+	if executeStrategyArgs.MaxAttempts <= 0 {
+		executeStrategyArgs.MaxAttempts = defaultMaxAttempts
+	}
+
+	if executeStrategyArgs.MaxPriceDrift <= 0 {
+		executeStrategyArgs.MaxPriceDrift = defaultMaxPriceDrift
+	}
+
 	go executeStrategy(executeStrategyArgs)
 
 	server_utils.WriteResponse(args.Writer, []byte("OK"), nil)
 }
 
+// handleRebalance plans the trades for a REBALANCE request and responds with the plan.
+// The trades are only placed when the request isn't a preview.
+func handleRebalance(args HandleExecuteStrategyArgs, portfolioDetails *types.PortfolioDetailsResponse, plan *types.RebalancePlan, quoteCurrency string, finished chan bool) {
+	trades, err := planRebalance(args.Exchange, portfolioDetails, plan, quoteCurrency, args.OrderSettings)
+
+	if err != nil {
+		server_utils.WriteResponse(args.Writer, nil, fmt.Errorf("handleExecuteStrategy: Failed to plan rebalance\n%v\n", err))
+
+		return
+	}
+
+	plan.Trades = trades
+
+	if !plan.Preview && len(trades) > 0 {
+		go executeStrategy(executeStrategyArgs{
+			Exchange:        args.Exchange,
+			Portfolio:       portfolioDetails.Breakdown.Portfolio,
+			StateRepository: args.StateRepository,
+			Journal:         args.Journal,
+			QuoteCurrency:   quoteCurrency,
+			StrategyName:    types.REBALANCE,
+			MaxAttempts:     defaultMaxAttempts,
+			MaxPriceDrift:   defaultMaxPriceDrift,
+			Rebalance:       plan,
+			OrderSettings:   args.OrderSettings,
+			Finished:        finished,
+		})
+	}
+
+	server_utils.WriteJSONResponse(args.Writer, plan, nil)
+}
+
 type executeStrategyArgs struct {
-	Client           *infrastructure.InvestmentManagerExternalHttpClient
-	PortfolioDetails *types.PortfolioDetailsResponse
-	StateRepository  *state.StateRepository
+	Exchange         exchange.Exchange
+	Portfolio        types.Portfolio
+	StateRepository  state.Repository
+	Journal          *journal.Journal
 	ProductID        string
+	Product          *types.Product // Looked up from ProductID when the strategy starts
+	QuoteCurrency    string
 	StrategyName     types.StrategyName
 	StrategyCurrency types.SupportedCurrency
+	MaxAttempts      int
+	MaxPriceDrift    float64
+	DCA              *types.DCAPlan
+	Rebalance        *types.RebalancePlan
+	Grid             *types.GridPlan
+	Protection       *types.Protection
+	TrailingStop     *types.TrailingStop
+	TWAP             *types.TWAPPlan
+	OrderType        types.OrderType
+	OrderSettings    types.OrderSettings
+	Strategy         *types.Strategy // Set when resuming a strategy from state
 	Finished         chan bool
 }
 
 func executeStrategy(args executeStrategyArgs) {
 	fmt.Println("BEGIN executeStrategy()")
-	var newState *types.State
-	newState, err := args.StateRepository.GetState()
 
-	if err != nil {
-		if strings.Contains(fmt.Sprintf("%s", err), "no such file or directory") {
-			fmt.Printf("No state found, initializing\n")
+	defer func() {
+		fmt.Printf("END executeStrategy()\n")
 
-			newState = args.StateRepository.InitState()
-			err = args.StateRepository.Save(*newState)
-
-			if err != nil {
-				fmt.Printf("failed to init state, returning\n%v\n", err)
-				args.Finished <- true
-				return
-			}
-		} else {
-			fmt.Printf("Failed to get state from repository\n%v\nReturning\n", err)
+		if args.Finished != nil {
 			args.Finished <- true
-			return
 		}
-	}
+	}()
 
-	clientOrderID, err := uuid.NewString()
-	if err != nil {
-		fmt.Printf("Failed to generate uuid for clientOrderId\n%v\nReturning\n", err)
+	strategy := args.Strategy
 
-		args.Finished <- true
-		return
+	if strategy == nil {
+		strategyID, err := uuid.NewString()
+
+		if err != nil {
+			fmt.Printf("Failed to generate strategy id\n%v\n", err)
+
+			return
+		}
+
+		strategy = &types.Strategy{
+			Id:            strategyID,
+			Name:          args.StrategyName,
+			Currency:      args.StrategyCurrency,
+			OpenOffers:    []types.Offer{},
+			ClosedOffers:  nil,
+			DCA:           args.DCA,
+			Rebalance:     args.Rebalance,
+			Grid:          args.Grid,
+			Protection:    args.Protection,
+			TrailingStop:  args.TrailingStop,
+			TWAP:          args.TWAP,
+			OrderType:     args.OrderType,
+			QuoteCurrency: args.QuoteCurrency,
+		}
 	}
 
-	breakdown := args.PortfolioDetails.Breakdown
-	portfolio := breakdown.Portfolio
+	var err error
 
-	bestBidAsk, err := server_utils.GetBestBidAsk(args.Client, args.ProductID)
+	// Rebalance trades several products and looks each one up itself
+	if args.ProductID != "" {
+		args.Product, err = server_utils.GetProduct(args.Exchange, args.ProductID)
 
-	if err != nil {
-		fmt.Printf("Failed to get best bid/ask \n%v\n", err)
+		if err != nil {
+			fmt.Printf("Failed to get product %q\n%v\n", args.ProductID, err)
 
-		args.Finished <- true
-		return
+			return
+		}
 	}
 
-	fmt.Printf("Best bid/ask for %s\n%+v\n", args.ProductID, bestBidAsk)
+	// Later changes only update the fields they touch, so a new strategy is saved whole first
+	if args.Strategy == nil {
+		err = args.StateRepository.UpsertStrategy(args.Portfolio, strategy)
+
+		if err != nil {
+			fmt.Printf("Failed to save state\n%v\n", err)
 
-	orderConfigArgs := &createOrderConfigArgs{
-		Breakdown:    &breakdown,
-		StrategyName: args.StrategyName,
-		BestBidAsk:   bestBidAsk,
+			return
+		}
 	}
 
-	orderConfig, err := createOrderConfig(orderConfigArgs)
+	switch args.StrategyName {
+	case types.HODL:
+		err = executeHODL(args, strategy)
+	case types.DCA:
+		err = executeDCA(args, strategy)
+	case types.REBALANCE:
+		err = executeRebalance(args, strategy)
+	case types.GRID:
+		err = executeGrid(args, strategy)
+	default:
+		err = fmt.Errorf("Unsupported strategy name: %q\n", string(args.StrategyName))
+	}
 
 	if err != nil {
-		fmt.Printf("Failed to get order config\n%v\n", err)
-
-		args.Finished <- true
-		return
+		fmt.Printf("Failed to execute strategy\n%v\n", err)
 	}
+}
 
-	newOffer := &types.Offer{
-		ClientOrderId:         clientOrderID,
-		ProductId:             args.ProductID,
-		Side:                  types.BUY,
-		Config:                *orderConfig,
-		SelfTradePreventionId: types.Default,
-		RetailPortfolioId:     portfolio.Uuid,
+// executeHODL spends all available fiat on the strategy currency, in one order or in TWAP slices.
+func executeHODL(args executeStrategyArgs, strategy *types.Strategy) error {
+	if strategy.TWAP != nil {
+		return executeTWAP(args, strategy)
 	}
 
-	previewMode := false
+	_, err := buyWithRepricing(args, strategy, types.Decimal{})
 
-	_, err = server_utils.PlaceOrder(&server_utils.PlaceOrderArgs{
-		Client:  args.Client,
-		Offer:   newOffer,
-		Preview: previewMode,
-	})
+	return err
+}
 
-	if err != nil {
-		fmt.Printf("Failed to place order\n%v\n", err)
+// buyWithRepricing spends budget (or all available fiat when budget is 0) on the strategy
+// currency and returns the fiat spent, including fees. Orders that expire before they are
+// filled are re-priced at the current best bid until the fiat is spent, the attempts run
+// out or the price drifts too far from the first quote.
+func buyWithRepricing(args executeStrategyArgs, strategy *types.Strategy, budget types.Decimal) (types.Decimal, error) {
+	portfolio := args.Portfolio
+	var firstBid types.Decimal
+	var spent types.Decimal
 
-		args.Finished <- true
-		return
+	for attempt := 1; attempt <= args.MaxAttempts; attempt++ {
+		portfolioDetails, err := args.Exchange.PortfolioDetails(portfolio.Uuid)
+
+		if err != nil {
+			return spent, fmt.Errorf("Failed to get portfolio details\n%v\n", err)
+		}
+
+		bestBidAsk, err := server_utils.GetBestBidAsk(args.Exchange, args.ProductID)
+
+		if err != nil {
+			return spent, fmt.Errorf("Failed to get best bid/ask \n%v\n", err)
+		}
+
+		fmt.Printf("Best bid/ask for %s\n%+v\n", args.ProductID, bestBidAsk)
+
+		bid, err := types.ParseDecimal(bestBidAsk.PriceBooks[0].Bids[0].Price)
+
+		if err != nil {
+			return spent, fmt.Errorf("Failed to convert best bid to decimal\n%v\n", err)
+		}
+
+		if attempt == 1 {
+			firstBid = bid
+		} else if drift := bid.Sub(firstBid).Div(firstBid).Mul(types.NewDecimalFromInt(100)).Float64(); drift > args.MaxPriceDrift {
+			fmt.Printf("Price drifted %.2f%% from first quote, max drift is %.2f%%, stopping\n", drift, args.MaxPriceDrift)
+
+			return spent, nil
+		}
+
+		var fiatToSpend types.Decimal
+
+		if budget.Sign() > 0 {
+			fiatToSpend = budget.Sub(spent)
+
+			// An empty FiatToSpend spends all available fiat, so stop once the budget is used up
+			if fiatToSpend.Sign() <= 0 {
+				fmt.Printf("Budget of %s spent, stopping\n", budget)
+
+				return spent, nil
+			}
+		}
+
+		configArgs := &createOrderConfigArgs{
+			Breakdown:     &portfolioDetails.Breakdown,
+			StrategyName:  args.StrategyName,
+			BestBidAsk:    bestBidAsk,
+			Product:       args.Product,
+			FiatToSpend:   fiatToSpend,
+			OrderType:     strategy.OrderType,
+			OrderSettings: args.OrderSettings,
+		}
+
+		orderConfig, err := createOrderConfig(configArgs)
+
+		if errors.Is(err, errNothingToSpend) {
+			fmt.Printf("No fiat left to spend, stopping\n")
+
+			return spent, nil
+		}
+
+		if err != nil {
+			return spent, fmt.Errorf("Failed to get order config\n%v\n", err)
+		}
+
+		fmt.Printf("Placing order, attempt %d of %d\n", attempt, args.MaxAttempts)
+		recordDecision(args, strategy, args.ProductID, types.BUY, orderConfig, getDecisionInputs(configArgs, fmt.Sprintf("Buy attempt %d of %d", attempt, args.MaxAttempts)))
+
+		offer, err := placeStrategyOffer(args, strategy, args.ProductID, types.BUY, orderConfig)
+
+		if err != nil {
+			return spent, err
+		}
+
+		err = addOpenOffer(args, strategy, *offer)
+
+		if err != nil {
+			return spent, err
+		}
+
+		order, err := server_utils.WaitForOrder(args.Exchange, offer.OrderId, orderPollInterval)
+
+		if err != nil {
+			return spent, fmt.Errorf("Failed to get status of order %q\n%v\n", offer.OrderId, err)
+		}
+
+		spent = spent.Add(getAmountSpent(order))
+		err = closeStrategyOffer(args, strategy, offer.ClientOrderId, order)
+
+		if err != nil {
+			return spent, err
+		}
+
+		switch order.Status {
+		case types.FILLED:
+			fmt.Printf("Order %q filled\n", order.OrderID)
+
+			return spent, nil
+		case types.FAILED:
+			return spent, fmt.Errorf("Order %q failed", order.OrderID)
+		case types.CANCELLED:
+			// Only a user cancels orders, e.g. with 'orders cancel', so don't place another one
+			fmt.Printf("Order %q was cancelled with %s filled, stopping\n", order.OrderID, order.FilledSize)
+
+			return spent, nil
+		default:
+			fmt.Printf("Order %q is %s with %s filled, re-pricing\n", order.OrderID, order.Status, order.FilledSize)
+		}
 	}
 
-	// TODO: Amend state rather than overwrite it
-	newState.Portfolios = []types.Portfolio{
-		{
-			Name:    portfolio.Name,
-			Uuid:    portfolio.Uuid,
-			Type:    portfolio.Type,
-			Deleted: portfolio.Deleted,
-			CurrentStrategy: &types.Strategy{
-				Name:     args.StrategyName,
-				Currency: args.StrategyCurrency,
-				OpenOffers: []types.Offer{
-					*newOffer,
-				},
-				ClosedOffers: nil,
-			},
-			PreviousStrategies: nil,
-		},
+	fmt.Printf("Reached max attempts (%d), stopping\n", args.MaxAttempts)
+
+	return spent, nil
+}
+
+// getAmountSpent returns the fiat spent on an order's fills, including fees.
+func getAmountSpent(order *types.Order) types.Decimal {
+	return order.FilledSize.Mul(order.AverageFilledPrice).Add(order.TotalFees)
+}
+
+// getSpendableFunds returns the fiat an order can spend and the commission to leave room for out of it.
+func getSpendableFunds(args *createOrderConfigArgs) (types.Decimal, types.Decimal) {
+	availableToTrade := server_utils.GetAvailableFunds(args.Breakdown.SpotPositions, args.Product.GetQuoteCurrency())
+
+	if args.FiatToSpend.Sign() > 0 && args.FiatToSpend.LessThan(availableToTrade) {
+		availableToTrade = args.FiatToSpend
 	}
 
-	newState.LastUpdated = time.Now().Add(time.Second).Format(time.RFC3339)
+	//subtract expected commission, orders that take liquidity pay the taker rate
+	takesLiquidity := args.OrderType == types.MarketOrder || args.OrderType == types.SorLimitOrder
+	commissionRate := types.NewDecimalFromFloat(args.OrderSettings.CommissionRate(takesLiquidity))
 
-	err = args.StateRepository.Save(*newState)
+	commissionRate = commissionRate.Add(types.MustParseDecimal("0.00000001")) // add 0.000001% padding
 
-	if err != nil {
-		fmt.Printf("Failed to save state\nstate: %+v\nerror: %v\n", newState, err)
+	return availableToTrade, availableToTrade.Mul(commissionRate)
+}
 
-		args.Finished <- true
-		return
+// getDecisionInputs returns what an order sized by createOrderConfig is based on, for the journal.
+func getDecisionInputs(args *createOrderConfigArgs, reason string) *types.JournalInputs {
+	availableToTrade, expectedCommission := getSpendableFunds(args)
+	inputs := &types.JournalInputs{
+		AvailableFunds:     availableToTrade.String(),
+		CommissionEstimate: expectedCommission.String(),
+		Reason:             reason,
+	}
+
+	if args.BestBidAsk != nil && len(args.BestBidAsk.PriceBooks) > 0 {
+		priceBook := args.BestBidAsk.PriceBooks[0]
+
+		if len(priceBook.Bids) > 0 {
+			inputs.BestBid = priceBook.Bids[0].Price
+		}
+
+		if len(priceBook.Asks) > 0 {
+			inputs.BestAsk = priceBook.Asks[0].Price
+		}
 	}
-	fmt.Printf("END executeStrategy()\n")
-	args.Finished <- true
+
+	return inputs
 }
 
 type createOrderConfigArgs struct {
-	Breakdown    *types.Breakdown
-	StrategyName types.StrategyName
-	BestBidAsk   *types.BestBidAskResponse
+	Breakdown     *types.Breakdown
+	StrategyName  types.StrategyName
+	BestBidAsk    *types.BestBidAskResponse
+	Product       *types.Product  // Sizes and prices are rounded to its increments
+	FiatToSpend   types.Decimal   // Spend all available fiat when 0
+	OrderType     types.OrderType // Defaults to a post-only limit GTD order
+	OrderSettings types.OrderSettings
 }
 
 func createOrderConfig(args *createOrderConfigArgs) (*types.OrderConfiguration, error) {
-	fiveMinutesFromNow := time.Now().Add(time.Minute * 5).Format(time.RFC3339)
-
 	fmt.Printf("%+v\n", args.BestBidAsk)
-	// get available funds, assume GBP for now.
-	var availableToTrade float64
-	for _, position := range args.Breakdown.SpotPositions {
-		if position.Asset == "GBP" {
-			availableToTrade = position.AvailableToTradeFiat
-			break
-		}
+
+	availableToTrade, expectedCommission := getSpendableFunds(args)
+
+	if availableToTrade.Sign() <= 0 {
+		return nil, errNothingToSpend
 	}
 
-	//subtract expected commission
-	commissionRate := 0.00400001 // maker commission is 0.40% for orders less than $10k add 0.000001% padding
-	expectedCommission := availableToTrade * commissionRate
-	availableToTrade -= expectedCommission
+	if args.StrategyName != types.HODL && args.StrategyName != types.DCA {
+		return nil, fmt.Errorf("Unsupported strategy name: %q\n", string(args.StrategyName))
+	}
 
-	// Match best bid price
-	limitPrice := args.BestBidAsk.PriceBooks[0].Bids[0].Price
-	limitPriceFloat, err := strconv.ParseFloat(limitPrice, 64)
-	if err != nil {
-		return nil, fmt.Errorf("Failed to convert limit price to float\nGiven: %q\n%v\n", limitPrice, err)
+	orderType := args.OrderType
+
+	if orderType == "" {
+		orderType = types.LimitGTDOrder
 	}
 
+	availableToTrade = availableToTrade.Sub(expectedCommission)
+
+	if orderType == types.MarketOrder {
+		// Market buys are sized in fiat
+		return &types.OrderConfiguration{
+			MarketMarketIOC: &types.MarketMarketIOC{
+				QuoteSize: args.Product.RoundQuoteSize(availableToTrade),
+			},
+		}, nil
+	}
+
+	// Match best bid price for orders that make liquidity, and the best ask for orders that take it
+	bestPrice := args.BestBidAsk.PriceBooks[0].Bids[0].Price
+	if orderType == types.SorLimitOrder {
+		bestPrice = args.BestBidAsk.PriceBooks[0].Asks[0].Price
+	}
+
+	limitPrice, err := types.ParseDecimal(bestPrice)
+
+	if err != nil || limitPrice.Sign() <= 0 {
+		return nil, fmt.Errorf("Invalid limit price\nGiven: %q\n%v\n", bestPrice, err)
+	}
+
+	limitPrice = args.Product.RoundPrice(limitPrice, types.BUY)
+
 	// Base size is the quantity of the base currency to buy.
 	// Base currency is on the left side of the product id.
 	// Example: "ETH-GBP" the base currency is "ETH"
-	baseSizeFloat := availableToTrade / limitPriceFloat
+	baseSize := args.Product.RoundBaseSize(availableToTrade.Div(limitPrice))
 
-	// Coinbase doesn't allow decimal precision > 8
-	decimalPrecision := 8
-	baseSize := strconv.FormatFloat(baseSizeFloat, 'f', decimalPrecision, 64)
+	if baseSize.Sign() <= 0 {
+		return nil, errNothingToSpend
+	}
 
-	switch args.StrategyName {
-	case types.HODL:
-		// For now, HODL strategy will spend all the fiat currency in 1 order
+	switch orderType {
+	case types.LimitGTDOrder:
+		return &types.OrderConfiguration{
+			LimitLimitGTD: &types.LimitLimitGTD{
+				BaseSize:   baseSize,
+				LimitPrice: limitPrice,
+				PostOnly:   true,
+				EndTime:    args.OrderSettings.GetEndTime(),
+			},
+		}, nil
+	case types.LimitGTCOrder:
+		return &types.OrderConfiguration{
+			LimitLimitGTC: &types.LimitLimitGTC{
+				BaseSize:   baseSize,
+				LimitPrice: limitPrice,
+				PostOnly:   true,
+			},
+		}, nil
+	case types.SorLimitOrder:
 		return &types.OrderConfiguration{
-			LimitLimitGTD: types.LimitLimitGTD{
+			SorLimitIOC: &types.SorLimitIOC{
 				BaseSize:   baseSize,
 				LimitPrice: limitPrice,
-				PostOnly:   true,               // TODO: set conditionally
-				EndTime:    fiveMinutesFromNow, // TODO: set conditionally
 			},
 		}, nil
 	default:
-		return nil, fmt.Errorf("Unsupported strategy name: %q\n", string(args.StrategyName))
+		return nil, fmt.Errorf("Unsupported order type for buying: %q\n", string(orderType))
+	}
+}
+
+// validateCurrencies checks the currencies the strategy buys are in the allow-list.
+// Whether the exchange has a product for them is checked when the product id is looked up.
+func validateCurrencies(request *types.ExecuteStrategyRequest, quoteCurrency string, allowedCurrencies []string) error {
+	currencies := []string{string(request.Currency)}
+
+	if request.Strategy == types.REBALANCE {
+		currencies = []string{}
+
+		// Assets with no target weight are only sold, and cash is never bought
+		for asset, weight := range request.Rebalance.Targets {
+			if weight > 0 && !strings.EqualFold(asset, quoteCurrency) {
+				currencies = append(currencies, asset)
+			}
+		}
+	} else if request.Currency == "" {
+		return fmt.Errorf("Currency is required by the %s strategy\n", request.Strategy)
+	}
+
+	if len(allowedCurrencies) == 0 {
+		return nil
+	}
+
+	for _, currency := range currencies {
+		if !isAllowedCurrency(currency, allowedCurrencies) {
+			return fmt.Errorf("Currency is not in the allow-list\nGiven: %q Expected one of: %v\n", currency, allowedCurrencies)
+		}
+	}
+
+	return nil
+}
+
+func isAllowedCurrency(currency string, allowedCurrencies []string) bool {
+	for _, allowed := range allowedCurrencies {
+		if strings.EqualFold(currency, allowed) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateOrderType checks the strategy can buy with the requested order type.
+func validateOrderType(orderType types.OrderType, strategyName types.StrategyName) error {
+	switch orderType {
+	case "":
+		return nil
+	case types.MarketOrder, types.SorLimitOrder, types.LimitGTCOrder, types.LimitGTDOrder:
+		if strategyName != types.HODL && strategyName != types.DCA {
+			return fmt.Errorf("Order type can only be chosen for HODL and DCA strategies\nGiven: %q", strategyName)
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("Unsupported order type for buying: %q", orderType)
 	}
 }
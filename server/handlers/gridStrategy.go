@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// Grid orders rest for a week, an order that expires is placed again at the same level.
+const gridOrderLifetime = time.Hour * 24 * 7
+
+// executeGrid places the ladder of grid orders and then watches them. When an order fills
+// the opposite order is placed one level away, so a filled buy is sold one level higher and
+// a filled sell is bought back one level lower. The grid runs until it has no open grid orders,
+// the sells protection and the trailing stop add are left to the reconciler.
+func executeGrid(args executeStrategyArgs, strategy *types.Strategy) error {
+	plan := strategy.Grid
+
+	if plan == nil {
+		return fmt.Errorf("GRID strategy has no plan")
+	}
+
+	// A resumed grid already has its orders
+	if len(strategy.OpenOffers) == 0 && len(strategy.ClosedOffers) == 0 {
+		err := placeGridLadder(args, strategy)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for hasGridOffers(strategy) {
+		err := checkGridOffers(args, strategy)
+
+		if err != nil {
+			return err
+		}
+
+		if hasGridOffers(strategy) {
+			time.Sleep(orderPollInterval)
+		}
+	}
+
+	fmt.Printf("Grid for %s has no open orders, stopping\n", plan.ProductID)
+
+	return nil
+}
+
+// hasGridOffers reports whether the strategy has open orders on a grid level.
+func hasGridOffers(strategy *types.Strategy) bool {
+	for _, offer := range strategy.OpenOffers {
+		if offer.GridLevel > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+func placeGridLadder(args executeStrategyArgs, strategy *types.Strategy) error {
+	plan := strategy.Grid
+
+	bestBidAsk, err := server_utils.GetBestBidAsk(args.Exchange, plan.ProductID)
+
+	if err != nil {
+		return fmt.Errorf("Failed to get best bid/ask \n%v\n", err)
+	}
+
+	bid, err := types.ParseDecimal(bestBidAsk.PriceBooks[0].Bids[0].Price)
+
+	if err != nil {
+		return fmt.Errorf("Failed to convert best bid to decimal\n%v\n", err)
+	}
+
+	ask, err := types.ParseDecimal(bestBidAsk.PriceBooks[0].Asks[0].Price)
+
+	if err != nil {
+		return fmt.Errorf("Failed to convert best ask to decimal\n%v\n", err)
+	}
+
+	mid := bid.Add(ask).Div(types.NewDecimalFromInt(2))
+
+	for level := 1; level <= plan.Levels; level++ {
+		price := plan.LevelPrice(level)
+		baseSize := plan.CapitalPerLevel().Div(price)
+
+		var side types.Side
+		if price.LessThan(mid) {
+			side = types.BUY
+		} else if price.GreaterThan(mid) {
+			side = types.SELL
+		} else {
+			continue
+		}
+
+		err = placeGridOffer(args, strategy, side, level, baseSize)
+
+		if err != nil {
+			// The rest of the ladder is still useful, e.g. sells fail when there is nothing to sell yet
+			fmt.Printf("Failed to place grid %s at level %d\n%v\n", side, level, err)
+		}
+	}
+
+	return nil
+}
+
+// checkGridOffers polls every open grid order once and replaces the ones that closed.
+// Replacements are checked in the same pass.
+func checkGridOffers(args executeStrategyArgs, strategy *types.Strategy) error {
+	plan := strategy.Grid
+	pending := append([]types.Offer{}, strategy.OpenOffers...)
+
+	for len(pending) > 0 {
+		offer := pending[0]
+		pending = pending[1:]
+
+		if offer.GridLevel <= 0 {
+			continue
+		}
+
+		order, err := args.Exchange.GetOrder(offer.OrderId)
+
+		if err != nil {
+			fmt.Printf("Failed to get status of grid order %q\n%v\n", offer.OrderId, err)
+			continue
+		}
+
+		if !order.Status.IsTerminal() {
+			continue
+		}
+
+		err = closeStrategyOffer(args, strategy, offer.ClientOrderId, order)
+
+		if err != nil {
+			return err
+		}
+
+		openOffers := len(strategy.OpenOffers)
+
+		switch order.Status {
+		case types.FILLED:
+			if offer.Side == types.BUY && offer.GridLevel < plan.Levels {
+				err = placeGridOffer(args, strategy, types.SELL, offer.GridLevel+1, order.FilledSize)
+			} else if offer.Side == types.SELL && offer.GridLevel > 1 {
+				level := offer.GridLevel - 1
+				err = placeGridOffer(args, strategy, types.BUY, level, plan.CapitalPerLevel().Div(plan.LevelPrice(level)))
+			}
+		case types.EXPIRED:
+			err = placeGridOffer(args, strategy, offer.Side, offer.GridLevel, offer.Config.LimitLimitGTD.BaseSize)
+		default:
+			fmt.Printf("Grid order %q at level %d is %s, not replacing it\n", order.OrderID, offer.GridLevel, order.Status)
+		}
+
+		if err != nil {
+			fmt.Printf("Failed to replace grid order %q\n%v\n", order.OrderID, err)
+		}
+
+		if len(strategy.OpenOffers) > openOffers {
+			pending = append(pending, strategy.OpenOffers[len(strategy.OpenOffers)-1])
+		}
+	}
+
+	return nil
+}
+
+func placeGridOffer(args executeStrategyArgs, strategy *types.Strategy, side types.Side, level int, baseSize types.Decimal) error {
+	plan := strategy.Grid
+
+	orderConfig := &types.OrderConfiguration{
+		LimitLimitGTD: &types.LimitLimitGTD{
+			BaseSize:   args.Product.RoundBaseSize(baseSize),
+			LimitPrice: args.Product.RoundPrice(plan.LevelPrice(level), side),
+			PostOnly:   true,
+			EndTime:    time.Now().Add(gridOrderLifetime).Format(time.RFC3339),
+		},
+	}
+
+	recordDecision(args, strategy, plan.ProductID, side, orderConfig, &types.JournalInputs{
+		Reason: fmt.Sprintf("Grid level %d", level),
+	})
+
+	offer, err := placeStrategyOffer(args, strategy, plan.ProductID, side, orderConfig)
+
+	if err != nil {
+		return err
+	}
+
+	offer.GridLevel = level
+
+	return addOpenOffer(args, strategy, *offer)
+}
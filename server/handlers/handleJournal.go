@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/server/journal"
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+type HandleJournalArgs struct {
+	Writer  http.ResponseWriter
+	Req     *http.Request
+	Journal *journal.Journal
+}
+
+// HandleJournal routes:
+// GET /journal?portfolio_id=&event=&product_id=&since= lists journal entries, oldest first
+func HandleJournal(args HandleJournalArgs) {
+	handlerName := "HandleJournal: "
+
+	args.Writer.Header().Set("Content-Type", "application/json")
+
+	if args.Req.Method != http.MethodGet {
+		server_utils.WriteResponse(args.Writer, nil, fmt.Errorf(handlerName+"Invalid http method, wanted %s got %s", http.MethodGet, args.Req.Method))
+
+		return
+	}
+
+	query := args.Req.URL.Query()
+	filter := types.JournalFilter{
+		PortfolioID: query.Get("portfolio_id"),
+		Event:       types.JournalEvent(query.Get("event")),
+		ProductID:   strings.ToUpper(query.Get("product_id")),
+		Since:       query.Get("since"),
+	}
+
+	if filter.Since != "" {
+		if _, err := time.Parse(time.RFC3339, filter.Since); err != nil {
+			server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid since, expected an RFC3339 timestamp\nGiven: %q", filter.Since))
+
+			return
+		}
+	}
+
+	entries, err := args.Journal.Read(filter)
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to read journal\n%v\n", err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	server_utils.WriteJSONResponse(args.Writer, types.JournalResponse{Entries: entries}, nil)
+}
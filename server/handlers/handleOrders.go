@@ -0,0 +1,454 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/fossoreslp/go-uuid-v4"
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+type HandleOrdersArgs struct {
+	Exchange        exchange.Exchange
+	Writer          http.ResponseWriter
+	Req             *http.Request
+	Args            []string
+	StateRepository state.Repository
+}
+
+// HandleOrders routes:
+// GET /orders/{portfolioUUID} lists open orders
+// POST /orders places an order
+// POST /orders/cancel cancels orders
+func HandleOrders(args HandleOrdersArgs) {
+	handlerName := "HandleOrders: "
+
+	args.Writer.Header().Set("Content-Type", "application/json")
+
+	if args.Req.Method == http.MethodGet && len(args.Args) == 1 {
+		handleListOpenOrders(args, args.Args[0])
+
+		return
+	}
+
+	if args.Req.Method != http.MethodPost {
+		server_utils.WriteResponse(args.Writer, nil, fmt.Errorf(handlerName+"Invalid http method, wanted %s got %s", http.MethodPost, args.Req.Method))
+
+		return
+	}
+
+	body := args.Req.Body
+
+	defer body.Close()
+
+	bodyData, err := ioutil.ReadAll(body)
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to read body from request: %v\n", err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	if len(args.Args) == 1 && args.Args[0] == "cancel" {
+		handleCancelOrders(args, bodyData)
+
+		return
+	}
+
+	handlePlaceOrder(args, bodyData)
+}
+
+func handlePlaceOrder(args HandleOrdersArgs, bodyData []byte) {
+	handlerName := "HandleOrders: "
+
+	var reqBody types.PlaceOrderRequest
+
+	err := json.Unmarshal(bodyData, &reqBody)
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to deserialize request: %v\n", err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	product, err := server_utils.GetProduct(args.Exchange, strings.ToUpper(reqBody.ProductID))
+
+	if err != nil {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Failed to get product %q\n%v", reqBody.ProductID, err))
+
+		return
+	}
+
+	config, err := createManualOrderConfig(&reqBody, product)
+
+	if err == nil {
+		err = product.ValidateOrder(*config)
+	}
+
+	if err != nil {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid order\n%v", err))
+
+		return
+	}
+
+	clientOrderID, err := uuid.NewString()
+
+	if err != nil {
+		server_utils.WriteResponse(args.Writer, nil, fmt.Errorf(handlerName+"Failed to generate uuid for clientOrderId\n%v\n", err))
+
+		return
+	}
+
+	offer := &types.Offer{
+		ClientOrderId:         clientOrderID,
+		ProductId:             strings.ToUpper(reqBody.ProductID),
+		Side:                  reqBody.Side,
+		Config:                *config,
+		SelfTradePreventionId: types.Default,
+		RetailPortfolioId:     reqBody.PortfolioID,
+	}
+
+	// Always preview first so the order is never placed if the exchange would reject it
+	previewResult, err := server_utils.PlaceOrder(&server_utils.PlaceOrderArgs{
+		Exchange: args.Exchange,
+		Offer:    offer,
+		Preview:  true,
+	})
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to preview order\n%v\n", err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	resp := &types.PlaceOrderResponse{Preview: previewResult.Preview}
+
+	if len(resp.Preview.Errors) > 0 {
+		args.Writer.WriteHeader(http.StatusUnprocessableEntity)
+		server_utils.WriteJSONResponse(args.Writer, resp, nil)
+
+		return
+	}
+
+	if reqBody.Preview {
+		server_utils.WriteJSONResponse(args.Writer, resp, nil)
+
+		return
+	}
+
+	placeResult, err := server_utils.PlaceOrder(&server_utils.PlaceOrderArgs{
+		Exchange: args.Exchange,
+		Offer:    offer,
+		Preview:  false,
+	})
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to place order\n%v\n", err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	log.Printf(handlerName+"Placed order %q\n", placeResult.Order.GetOrderID())
+
+	resp.Order = placeResult.Order
+	server_utils.WriteJSONResponse(args.Writer, resp, nil)
+}
+
+// createManualOrderConfig checks the request has the fields its order type needs and builds
+// the order configuration, rounding sizes and prices to the product's increments.
+func createManualOrderConfig(req *types.PlaceOrderRequest, product *types.Product) (*types.OrderConfiguration, error) {
+	if req.PortfolioID == "" || req.ProductID == "" {
+		return nil, fmt.Errorf("Portfolio and product are required\n")
+	}
+
+	if req.Side != types.BUY && req.Side != types.SELL {
+		return nil, fmt.Errorf("Invalid side\nGiven: %q Expected: %q or %q\n", req.Side, types.BUY, types.SELL)
+	}
+
+	if req.BaseSize.IsZero() == req.QuoteSize.IsZero() {
+		return nil, fmt.Errorf("Exactly one of base size or quote size is required\n")
+	}
+
+	if !req.QuoteSize.IsZero() && (req.OrderType != types.MarketOrder || req.Side != types.BUY) {
+		return nil, fmt.Errorf("Quote size is only supported by market buy orders, use base size\n")
+	}
+
+	for _, amount := range []types.Decimal{req.BaseSize, req.QuoteSize, req.LimitPrice, req.StopPrice} {
+		if amount.Sign() < 0 {
+			return nil, fmt.Errorf("Sizes and prices must be positive numbers\nGiven: %s\n", amount)
+		}
+	}
+
+	if req.OrderType != types.MarketOrder && req.LimitPrice.IsZero() {
+		return nil, fmt.Errorf("Order type %q requires a limit price\n", req.OrderType)
+	}
+
+	isStopLimit := req.OrderType == types.StopLimitGTCOrder || req.OrderType == types.StopLimitGTDOrder
+
+	if isStopLimit && req.StopPrice.IsZero() {
+		return nil, fmt.Errorf("Order type %q requires a stop price\n", req.OrderType)
+	}
+
+	if req.OrderType == types.LimitGTDOrder || req.OrderType == types.StopLimitGTDOrder {
+		_, err := time.Parse(time.RFC3339, req.EndTime)
+
+		if err != nil {
+			return nil, fmt.Errorf("Order type %q requires an RFC3339 end time\nGiven: %q\n", req.OrderType, req.EndTime)
+		}
+	}
+
+	baseSize := product.RoundBaseSize(req.BaseSize)
+	limitPrice := product.RoundPrice(req.LimitPrice, req.Side)
+	stopPrice := product.RoundPrice(req.StopPrice, req.Side)
+
+	if baseSize.IsZero() && req.QuoteSize.IsZero() {
+		return nil, fmt.Errorf("Base size %s is smaller than the product's base increment %s\n", req.BaseSize, product.BaseIncrement)
+	}
+
+	// A stop sell protects against the price falling, a stop buy triggers when the price rises
+	stopDirection := types.StopDirectionDown
+	if req.Side == types.BUY {
+		stopDirection = types.StopDirectionUp
+	}
+
+	switch req.OrderType {
+	case types.MarketOrder:
+		return &types.OrderConfiguration{
+			MarketMarketIOC: getMarketOrderSize(req, product),
+		}, nil
+	case types.SorLimitOrder:
+		return &types.OrderConfiguration{
+			SorLimitIOC: &types.SorLimitIOC{
+				BaseSize:   baseSize,
+				LimitPrice: limitPrice,
+			},
+		}, nil
+	case types.LimitGTCOrder:
+		return &types.OrderConfiguration{
+			LimitLimitGTC: &types.LimitLimitGTC{
+				BaseSize:   baseSize,
+				LimitPrice: limitPrice,
+				PostOnly:   req.PostOnly,
+			},
+		}, nil
+	case types.LimitGTDOrder:
+		return &types.OrderConfiguration{
+			LimitLimitGTD: &types.LimitLimitGTD{
+				BaseSize:   baseSize,
+				LimitPrice: limitPrice,
+				EndTime:    req.EndTime,
+				PostOnly:   req.PostOnly,
+			},
+		}, nil
+	case types.StopLimitGTCOrder:
+		return &types.OrderConfiguration{
+			StopLimitStopLimitGTC: &types.StopLimitStopLimitGTC{
+				BaseSize:      baseSize,
+				LimitPrice:    limitPrice,
+				StopPrice:     stopPrice,
+				StopDirection: stopDirection,
+			},
+		}, nil
+	case types.StopLimitGTDOrder:
+		return &types.OrderConfiguration{
+			StopLimitStopLimitGTD: &types.StopLimitStopLimitGTD{
+				BaseSize:      baseSize,
+				LimitPrice:    limitPrice,
+				StopPrice:     stopPrice,
+				EndTime:       req.EndTime,
+				StopDirection: stopDirection,
+			},
+		}, nil
+	default:
+		return nil, fmt.Errorf("Unsupported order type: %q\n", req.OrderType)
+	}
+}
+
+// getMarketOrderSize sets only the size the request gave, Coinbase rejects market orders with both.
+func getMarketOrderSize(req *types.PlaceOrderRequest, product *types.Product) *types.MarketMarketIOC {
+	if !req.QuoteSize.IsZero() {
+		return &types.MarketMarketIOC{QuoteSize: product.RoundQuoteSize(req.QuoteSize)}
+	}
+
+	return &types.MarketMarketIOC{BaseSize: product.RoundBaseSize(req.BaseSize)}
+}
+
+// handleListOpenOrders lists the portfolio's open orders from the exchange alongside the
+// open offers of its current strategy, so orders missing from either side stand out.
+func handleListOpenOrders(args HandleOrdersArgs, portfolioUUID string) {
+	orders, err := args.Exchange.ListOpenOrders(portfolioUUID)
+
+	if err != nil {
+		log.Printf("HandleOrders: Failed to list open orders for: %q\n%v\n", portfolioUUID, err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	resp := &types.OpenOrdersResponse{
+		Orders:         orders,
+		StrategyOffers: []types.Offer{},
+	}
+
+	strategy := getCurrentStrategy(args.StateRepository, portfolioUUID)
+
+	if strategy != nil {
+		resp.StrategyName = strategy.Name
+		resp.StrategyOffers = strategy.OpenOffers
+	}
+
+	server_utils.WriteJSONResponse(args.Writer, resp, nil)
+}
+
+func handleCancelOrders(args HandleOrdersArgs, bodyData []byte) {
+	handlerName := "HandleOrders: "
+
+	var reqBody types.CancelOrdersRequest
+
+	err := json.Unmarshal(bodyData, &reqBody)
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to deserialize request: %v\n", err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	if reqBody.PortfolioID == "" || (!reqBody.All && len(reqBody.OrderIDs) == 0) {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Cancel requires a portfolio and either order ids or all"))
+
+		return
+	}
+
+	orderIDs := reqBody.OrderIDs
+
+	if reqBody.All {
+		orderIDs, err = getOpenOrderIDs(args, reqBody.PortfolioID)
+
+		if err != nil {
+			log.Printf(handlerName+"Failed to list open orders for: %q\n%v\n", reqBody.PortfolioID, err)
+			server_utils.WriteResponse(args.Writer, nil, err)
+
+			return
+		}
+	}
+
+	if len(orderIDs) == 0 {
+		server_utils.WriteJSONResponse(args.Writer, &types.CancelOrdersResponse{Results: []types.CancelOrderResult{}}, nil)
+
+		return
+	}
+
+	resp, err := args.Exchange.CancelOrders(orderIDs)
+
+	if err != nil {
+		log.Printf(handlerName+"Failed to cancel orders: %v\n%v\n", orderIDs, err)
+		server_utils.WriteResponse(args.Writer, nil, err)
+
+		return
+	}
+
+	err = closeCancelledOffers(args, reqBody.PortfolioID, resp)
+
+	if err != nil {
+		// The orders are cancelled either way, the reconciler will catch the state up
+		log.Printf(handlerName+"Failed to update state with cancelled orders\n%v\n", err)
+	}
+
+	server_utils.WriteJSONResponse(args.Writer, resp, nil)
+}
+
+// getOpenOrderIDs returns the ids of every open order the exchange or the current strategy knows about.
+func getOpenOrderIDs(args HandleOrdersArgs, portfolioUUID string) ([]string, error) {
+	orders, err := args.Exchange.ListOpenOrders(portfolioUUID)
+
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	orderIDs := []string{}
+
+	for _, order := range orders {
+		seen[order.OrderID] = true
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+
+	strategy := getCurrentStrategy(args.StateRepository, portfolioUUID)
+
+	if strategy == nil {
+		return orderIDs, nil
+	}
+
+	for _, offer := range strategy.OpenOffers {
+		if offer.OrderId != "" && !seen[offer.OrderId] {
+			seen[offer.OrderId] = true
+			orderIDs = append(orderIDs, offer.OrderId)
+		}
+	}
+
+	return orderIDs, nil
+}
+
+// closeCancelledOffers moves the strategy offers of the cancelled orders into ClosedOffers.
+// The orders are fetched before state is locked, only the results are applied inside the Update.
+func closeCancelledOffers(args HandleOrdersArgs, portfolioUUID string, resp *types.CancelOrdersResponse) error {
+	if args.StateRepository == nil {
+		return nil
+	}
+
+	orders := []*types.Order{}
+
+	for _, result := range resp.Results {
+		if !result.Success {
+			continue
+		}
+
+		// Prefer the exchange's view so partial fills are kept
+		order, err := args.Exchange.GetOrder(result.OrderID)
+
+		if err != nil {
+			order = &types.Order{OrderID: result.OrderID, Status: types.CANCELLED}
+		}
+
+		orders = append(orders, order)
+	}
+
+	if len(orders) == 0 {
+		return nil
+	}
+
+	return args.StateRepository.Update(func(currentState *types.State) error {
+		var strategy *types.Strategy
+
+		for _, portfolio := range currentState.Portfolios {
+			if portfolio.Uuid == portfolioUUID {
+				strategy = portfolio.CurrentStrategy
+			}
+		}
+
+		if strategy == nil {
+			return state.ErrNoChanges
+		}
+
+		for _, order := range orders {
+			closeOfferByOrderID(strategy, order)
+		}
+
+		currentState.LastUpdated = time.Now().Format(time.RFC3339)
+
+		return nil
+	})
+}
@@ -1,28 +1,35 @@
 package handlers
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"log"
 	"net/http"
 
-	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/server/exchange"
 	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
 )
 
 type HandlePortfolioArgs struct {
-	Client *infrastructure.InvestmentManagerExternalHttpClient
-	Writer http.ResponseWriter
-	Req    *http.Request
-	Args   []string
+	Exchange        exchange.Exchange
+	Writer          http.ResponseWriter
+	Req             *http.Request
+	Args            []string
+	StateRepository state.Repository
+}
+
+type createPortfolioRequest struct {
+	Name string `json:"name"`
 }
 
 func HandlePortfolio(hpArgs HandlePortfolioArgs) {
 	w := hpArgs.Writer
-	client := hpArgs.Client
+	ex := hpArgs.Exchange
 	r := hpArgs.Req
 
 	w.Header().Set("Content-Type", "application/json")
-	url := "https://api.coinbase.com/api/v3/brokerage/portfolios"
 
 	if r.Method == http.MethodPost {
 		body := r.Body
@@ -34,32 +41,61 @@ func HandlePortfolio(hpArgs HandlePortfolioArgs) {
 		if err != nil {
 			log.Printf("Failed to read body from request: %v\n", err)
 			server_utils.WriteResponse(w, nil, err)
+
+			return
 		}
 
-		resp, err := client.Post(url, bodyData)
+		var req createPortfolioRequest
+
+		err = json.Unmarshal(bodyData, &req)
+
+		if err != nil {
+			log.Printf("Failed to deserialize request: %v\n", err)
+			server_utils.WriteResponse(w, nil, err)
+
+			return
+		}
 
-		server_utils.WriteResponse(w, resp, err)
+		resp, err := ex.CreatePortfolio(req.Name)
+
+		server_utils.WriteJSONResponse(w, resp, err)
 	} else {
 		if len(hpArgs.Args) == 1 {
 			portfolioUUID := hpArgs.Args[0]
-			url = url + "/" + portfolioUUID
-			resp, err := client.Get(url)
+			resp, err := ex.PortfolioDetails(portfolioUUID)
 
 			if err != nil {
-				log.Printf("Error retrieving portfolio details from URL: %q\nError: %v", url, err)
+				log.Printf("Error retrieving portfolio details for: %q\nError: %v", portfolioUUID, err)
+			} else {
+				resp.Strategy = getCurrentStrategy(hpArgs.StateRepository, portfolioUUID)
 			}
 
-			server_utils.WriteResponse(w, resp, err)
+			server_utils.WriteJSONResponse(w, resp, err)
 		} else {
-			resp, err := client.Get(url)
+			resp, err := ex.ListPortfolios()
 
 			if err != nil {
-				log.Printf("Error retrieving portfolios from URL: %q\nError: %v", url, err)
+				log.Printf("Error retrieving portfolios\nError: %v", err)
 			}
 
-			server_utils.WriteResponse(w, resp, err)
+			server_utils.WriteJSONResponse(w, resp, err)
 		}
 	}
 
 	log.Printf("Request handled successfully!")
 }
+
+// getCurrentStrategy returns the strategy running against the given portfolio, if there is one.
+func getCurrentStrategy(stateRepository state.Repository, portfolioUUID string) *types.Strategy {
+	if stateRepository == nil {
+		return nil
+	}
+
+	strategy, err := stateRepository.GetStrategy(portfolioUUID)
+
+	if err != nil {
+		return nil
+	}
+
+	return strategy
+}
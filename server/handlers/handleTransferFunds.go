@@ -6,18 +6,17 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"strconv"
+	"strings"
 
-	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/server/exchange"
 	"github.com/iPopcorn/investment-manager/server/server_utils"
 	"github.com/iPopcorn/investment-manager/types"
-	"github.com/iPopcorn/investment-manager/util"
 )
 
 type HandleTransferFundsArgs struct {
-	Client *infrastructure.InvestmentManagerExternalHttpClient
-	Writer http.ResponseWriter
-	Req    *http.Request
+	Exchange exchange.Exchange
+	Writer   http.ResponseWriter
+	Req      *http.Request
 }
 
 func HandleTransferFunds(args HandleTransferFundsArgs) {
@@ -53,16 +52,15 @@ func HandleTransferFunds(args HandleTransferFundsArgs) {
 		return
 	}
 
-	fundsToTransfer, err := strconv.ParseFloat(reqBody.Amount, 64)
+	fundsToTransfer := reqBody.Amount
 
-	if err != nil {
-		log.Printf(handlerName+"Invalid request\nCould not convert 'Amount' to float64\nGiven: %q\n%v", reqBody.Amount, err)
-		server_utils.WriteResponse(args.Writer, nil, err)
+	if fundsToTransfer.Sign() <= 0 {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Invalid request\nAmount must be greater than 0\nGiven: %s", fundsToTransfer))
 
 		return
 	}
 
-	senderPortfolioDetails, err := server_utils.PortfolioDetails(args.Client, reqBody.SenderID)
+	senderPortfolioDetails, err := args.Exchange.PortfolioDetails(reqBody.SenderID)
 
 	if err != nil {
 		log.Printf(handlerName+"Failed to get portfolio details for sender. Given: %q\n", reqBody.SenderID)
@@ -71,39 +69,35 @@ func HandleTransferFunds(args HandleTransferFundsArgs) {
 		return
 	}
 
-	// get available funds, assume GBP for now.
-	var senderAvailableFunds float64
-	for _, position := range senderPortfolioDetails.Breakdown.SpotPositions {
-		if position.Asset == "GBP" {
-			senderAvailableFunds = position.AvailableToTradeFiat
-			break
-		}
-	}
+	reqBody.Currency = strings.ToUpper(reqBody.Currency)
 
-	if senderAvailableFunds < fundsToTransfer {
-		log.Printf(handlerName+"Sender does not have enough funds to transfer\nAvailable funds: %f\nFunds to transfer %f\n", senderAvailableFunds, fundsToTransfer)
+	if reqBody.Currency == "" {
+		reqBody.Currency, err = server_utils.GetQuoteCurrency(senderPortfolioDetails)
 
-		args.Writer.WriteHeader(http.StatusBadRequest)
-		return
+		if err != nil {
+			server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Failed to detect the currency to transfer\n%v", err))
+
+			return
+		}
 	}
 
-	resp, err := server_utils.TransferFunds(args.Client, &reqBody)
+	senderAvailableFunds := server_utils.GetAvailableFunds(senderPortfolioDetails.Breakdown.SpotPositions, reqBody.Currency)
 
-	if err != nil {
-		log.Printf(handlerName+"Failed to transfer funds\nerror: %+v\nrequest: %+v", err, reqBody)
-		server_utils.WriteResponse(args.Writer, nil, err)
+	if senderAvailableFunds.LessThan(fundsToTransfer) {
+		server_utils.WriteErrorResponse(args.Writer, http.StatusBadRequest, fmt.Errorf("Sender does not have enough funds to transfer\nAvailable funds: %s %s\nFunds to transfer %s", senderAvailableFunds, reqBody.Currency, fundsToTransfer))
 
 		return
 	}
 
-	err = util.HandleErrorResponse(resp)
+	resp, err := args.Exchange.TransferFunds(&reqBody)
+
 	if err != nil {
-		log.Printf(handlerName+"Received error from coinbase when transferring funds: %v\n", err)
+		log.Printf(handlerName+"Failed to transfer funds\nerror: %+v\nrequest: %+v", err, reqBody)
 		server_utils.WriteResponse(args.Writer, nil, err)
 
 		return
 	}
 
-	log.Printf(handlerName+"Transfer funds success!\nresp: %s\n", string(resp))
-	server_utils.WriteResponse(args.Writer, resp, nil)
+	log.Printf(handlerName+"Transfer funds success!\nresp: %+v\n", resp)
+	server_utils.WriteJSONResponse(args.Writer, resp, nil)
 }
@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+var errAlreadyTriggered = errors.New("Protection already triggered")
+
+// ProtectionMonitor sells a strategy's position when the price crosses its stop-loss or take-profit,
+// and keeps the stop-limit order of a trailing stop below the highest price seen.
+type ProtectionMonitor struct {
+	exchange        exchange.Exchange
+	stateRepository state.Repository
+	orderSettings   types.OrderSettings
+}
+
+func ProtectionMonitorFactory(ex exchange.Exchange, stateRepository state.Repository, orderSettings types.OrderSettings) *ProtectionMonitor {
+	return &ProtectionMonitor{
+		exchange:        ex,
+		stateRepository: stateRepository,
+		orderSettings:   orderSettings,
+	}
+}
+
+// Run checks the protected positions every interval until stop receives a value.
+func (m *ProtectionMonitor) Run(interval time.Duration, stop <-chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := m.Check()
+
+			if err != nil {
+				log.Printf("ProtectionMonitor.Run: failed to check protected positions\n%v\n", err)
+			}
+		}
+	}
+}
+
+// Check looks at the protection and trailing stop of every current strategy, and cancels any trailing
+// stop order an archived strategy left resting. Prices and orders are read from a snapshot of state,
+// only the changes are written back, one strategy at a time.
+func (m *ProtectionMonitor) Check() error {
+	location := "ProtectionMonitor.Check()\n"
+	currentState, err := m.stateRepository.GetState()
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	for _, portfolio := range currentState.Portfolios {
+		if portfolio.PreviousStrategies != nil {
+			for _, previous := range *portfolio.PreviousStrategies {
+				if trailingStop := previous.TrailingStop; trailingStop != nil && trailingStop.OrderId != "" {
+					err := m.stopArchivedTrailingStop(portfolio, &previous)
+
+					if err != nil {
+						log.Printf(location+"Failed to stop the trailing stop of archived strategy %q\n%v\n", previous.Id, err)
+					}
+				}
+			}
+		}
+
+		strategy := portfolio.CurrentStrategy
+
+		if strategy == nil {
+			continue
+		}
+
+		if strategy.Protection != nil && strategy.Protection.Triggered == "" {
+			err := m.checkPosition(portfolio, strategy)
+
+			if err != nil {
+				log.Printf(location+"Failed to check protection for portfolio %q\n%v\n", portfolio.Name, err)
+			}
+		}
+
+		if trailingStop := strategy.TrailingStop; trailingStop != nil && !trailingStop.Triggered && !trailingStop.Disarmed {
+			err := m.checkTrailingStop(portfolio, strategy)
+
+			if err != nil {
+				log.Printf(location+"Failed to check trailing stop for portfolio %q\n%v\n", portfolio.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// checkPosition sells the strategy currency held by the portfolio if the best bid has crossed a threshold.
+// The trigger is saved before the order is placed, so a protection only ever places one sell.
+func (m *ProtectionMonitor) checkPosition(portfolio types.Portfolio, strategy *types.Strategy) error {
+	portfolioDetails, err := m.exchange.PortfolioDetails(portfolio.Uuid)
+
+	if err != nil {
+		return fmt.Errorf("Failed to get portfolio details\n%v\n", err)
+	}
+
+	var position *types.SpotPositions
+	for i := range portfolioDetails.Breakdown.SpotPositions {
+		if portfolioDetails.Breakdown.SpotPositions[i].Asset == string(strategy.Currency) {
+			position = &portfolioDetails.Breakdown.SpotPositions[i]
+			break
+		}
+	}
+
+	if position == nil || position.TotalBalanceCrypto.Sign() <= 0 {
+		return nil
+	}
+
+	averageCost := position.CostBasis.Value.Div(position.TotalBalanceCrypto)
+
+	productID, err := server_utils.GetProductID(m.exchange, portfolioDetails, string(strategy.Currency), strategy.QuoteCurrency)
+
+	if err != nil {
+		return err
+	}
+
+	bestBidAsk, err := server_utils.GetBestBidAsk(m.exchange, productID)
+
+	if err != nil {
+		return err
+	}
+
+	bidPrice := bestBidAsk.PriceBooks[0].Bids[0].Price
+	bid, err := types.ParseDecimal(bidPrice)
+
+	if err != nil {
+		return fmt.Errorf("Failed to convert best bid to decimal\n%v\n", err)
+	}
+
+	protection := strategy.Protection
+	var event types.ProtectionEvent
+
+	if stopLoss := protection.StopLoss(averageCost); stopLoss.Sign() > 0 && !bid.GreaterThan(stopLoss) {
+		event = types.StopLoss
+	} else if takeProfit := protection.TakeProfit(averageCost); takeProfit.Sign() > 0 && !bid.LessThan(takeProfit) {
+		event = types.TakeProfit
+	} else {
+		return nil
+	}
+
+	fmt.Printf("%s triggered for %s at %s, average cost %s\n", event, productID, bidPrice, averageCost.StringFixed(2))
+
+	product, err := server_utils.GetProduct(m.exchange, productID)
+
+	if err != nil {
+		return err
+	}
+
+	err = m.stateRepository.UpdateStrategy(portfolio.Uuid, strategy.Id, func(saved *types.Strategy) error {
+		if saved.Protection == nil || saved.Protection.Triggered != "" {
+			return errAlreadyTriggered
+		}
+
+		saved.Protection.Triggered = event
+		saved.Protection.TriggeredAt = time.Now().Format(time.RFC3339)
+		saved.Protection.TriggerPrice = bid
+
+		return nil
+	})
+
+	if errors.Is(err, errAlreadyTriggered) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed to save %s trigger, not selling\n%v\n", event, err)
+	}
+
+	// Sell at the best bid without post only so the order takes liquidity and fills straight away
+	orderConfig := &types.OrderConfiguration{
+		LimitLimitGTD: &types.LimitLimitGTD{
+			BaseSize:   product.RoundBaseSize(position.TotalBalanceCrypto),
+			LimitPrice: bid,
+			PostOnly:   false,
+			EndTime:    m.orderSettings.GetEndTime(),
+		},
+	}
+
+	offer, err := placeOffer(m.exchange, portfolio.Uuid, productID, types.SELL, orderConfig)
+
+	if err != nil {
+		// Nothing was sold, so the next check can try again
+		releaseErr := m.stateRepository.UpdateStrategy(portfolio.Uuid, strategy.Id, func(saved *types.Strategy) error {
+			saved.Protection.Triggered = ""
+			saved.Protection.TriggeredAt = ""
+			saved.Protection.TriggerPrice = types.Decimal{}
+
+			return nil
+		})
+
+		if releaseErr != nil {
+			log.Printf("ProtectionMonitor: failed to reset %s trigger after the sell failed\n%v\n", event, releaseErr)
+		}
+
+		return err
+	}
+
+	err = m.stateRepository.UpdateStrategy(portfolio.Uuid, strategy.Id, func(saved *types.Strategy) error {
+		saved.OpenOffers = append(saved.OpenOffers, *offer)
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("%s order %q was placed but not saved\n%v\n", event, offer.OrderId, err)
+	}
+
+	return nil
+}
+
+func validateProtection(protection *types.Protection) error {
+	if protection == nil {
+		return nil
+	}
+
+	if protection.StopLossPrice.Sign() < 0 || protection.TakeProfitPrice.Sign() < 0 || protection.TakeProfitPercent < 0 {
+		return fmt.Errorf("Protection thresholds can not be negative\nGiven: %+v", *protection)
+	}
+
+	if protection.StopLossPercent < 0 || protection.StopLossPercent >= 100 {
+		return fmt.Errorf("Stop-loss percent must be between 0 and 100\nGiven: %f", protection.StopLossPercent)
+	}
+
+	return nil
+}
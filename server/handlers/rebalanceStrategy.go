@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+func validateRebalancePlan(plan *types.RebalancePlan) error {
+	if plan == nil || len(plan.Targets) == 0 {
+		return fmt.Errorf("REBALANCE strategy requires target weights")
+	}
+
+	var total float64
+	for asset, weight := range plan.Targets {
+		if weight < 0 {
+			return fmt.Errorf("Target weight can not be negative\nGiven: %s=%f", asset, weight)
+		}
+
+		total += weight
+	}
+
+	if math.Abs(total-100) > 0.01 {
+		return fmt.Errorf("Target weights must add up to 100\nGiven: %f", total)
+	}
+
+	if plan.Tolerance < 0 {
+		return fmt.Errorf("Tolerance can not be negative\nGiven: %f", plan.Tolerance)
+	}
+
+	return nil
+}
+
+// planRebalance works out the trades needed to bring every asset within the plan's tolerance
+// of its target weight. Sells are listed first so their proceeds can fund the buys.
+func planRebalance(ex exchange.Exchange, portfolioDetails *types.PortfolioDetailsResponse, plan *types.RebalancePlan, quoteCurrency string, orderSettings types.OrderSettings) ([]types.RebalanceTrade, error) {
+	targets := map[string]float64{}
+	for asset, weight := range plan.Targets {
+		targets[strings.ToUpper(asset)] = weight
+	}
+
+	var totalFiat types.Decimal
+	positions := map[string]types.SpotPositions{}
+
+	for _, position := range portfolioDetails.Breakdown.SpotPositions {
+		totalFiat = totalFiat.Add(position.TotalBalanceFiat)
+		positions[strings.ToUpper(position.Asset)] = position
+
+		if _, ok := targets[strings.ToUpper(position.Asset)]; !ok && !position.IsCash {
+			targets[strings.ToUpper(position.Asset)] = 0
+		}
+	}
+
+	if totalFiat.Sign() <= 0 {
+		return nil, fmt.Errorf("Portfolio has no balance to rebalance")
+	}
+
+	trades := []types.RebalanceTrade{}
+
+	for asset, targetWeight := range targets {
+		position, held := positions[asset]
+
+		// Cash is what's left over once the other assets are traded
+		if asset == quoteCurrency || (held && position.IsCash) {
+			continue
+		}
+
+		currentWeight := position.Allocation * 100
+
+		if math.Abs(targetWeight-currentWeight) <= plan.Tolerance {
+			continue
+		}
+
+		productID, err := server_utils.GetProductID(ex, portfolioDetails, asset, quoteCurrency)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get product id for %s\n%v\n", asset, err)
+		}
+
+		bestBidAsk, err := server_utils.GetBestBidAsk(ex, productID)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get best bid/ask for %s\n%v\n", productID, err)
+		}
+
+		trade := types.RebalanceTrade{
+			Asset:         asset,
+			ProductID:     productID,
+			CurrentWeight: currentWeight,
+			TargetWeight:  targetWeight,
+			Amount:        totalFiat.Mul(types.NewDecimalFromFloat(math.Abs(targetWeight - currentWeight))).Div(types.NewDecimalFromInt(100)),
+		}
+
+		product, err := server_utils.GetProduct(ex, productID)
+
+		if err != nil {
+			return nil, fmt.Errorf("Failed to get product %s\n%v\n", productID, err)
+		}
+
+		// Match the best bid when buying and the best ask when selling so orders make liquidity
+		limitPrice := bestBidAsk.PriceBooks[0].Bids[0].Price
+		trade.Side = types.BUY
+
+		if targetWeight < currentWeight {
+			limitPrice = bestBidAsk.PriceBooks[0].Asks[0].Price
+			trade.Side = types.SELL
+		}
+
+		trade.LimitPrice, err = types.ParseDecimal(limitPrice)
+
+		if err != nil || trade.LimitPrice.Sign() <= 0 {
+			return nil, fmt.Errorf("Invalid limit price\nGiven: %q\n%v\n", limitPrice, err)
+		}
+
+		trade.LimitPrice = product.RoundPrice(trade.LimitPrice, trade.Side)
+		amount := trade.Amount
+
+		if trade.Side == types.BUY {
+			commissionRate := types.NewDecimalFromFloat(orderSettings.CommissionRate(false) + 0.00000001) // add 0.000001% padding
+			amount = amount.Sub(amount.Mul(commissionRate))
+		}
+
+		baseSize := amount.Div(trade.LimitPrice)
+
+		if trade.Side == types.SELL {
+			baseSize = types.MinDecimal(baseSize, position.TotalBalanceCrypto)
+		}
+
+		trade.BaseSize = product.RoundBaseSize(baseSize)
+		trades = append(trades, trade)
+	}
+
+	sort.Slice(trades, func(i, j int) bool {
+		if trades[i].Side != trades[j].Side {
+			return trades[i].Side == types.SELL
+		}
+
+		return trades[i].Asset < trades[j].Asset
+	})
+
+	return trades, nil
+}
+
+// executeRebalance places the planned trades one at a time, sells first. A trade that fails
+// or doesn't fill is logged and the rest are still attempted.
+func executeRebalance(args executeStrategyArgs, strategy *types.Strategy) error {
+	if strategy.Rebalance == nil {
+		return fmt.Errorf("REBALANCE strategy has no plan")
+	}
+
+	failedTrades := 0
+
+	for _, trade := range strategy.Rebalance.Trades {
+		fmt.Printf("Rebalancing %s: %s %s at %s\n", trade.Asset, trade.Side, trade.BaseSize, trade.LimitPrice)
+
+		orderConfig := &types.OrderConfiguration{
+			LimitLimitGTD: &types.LimitLimitGTD{
+				BaseSize:   trade.BaseSize,
+				LimitPrice: trade.LimitPrice,
+				PostOnly:   true,
+				EndTime:    args.OrderSettings.GetEndTime(),
+			},
+		}
+
+		recordDecision(args, strategy, trade.ProductID, trade.Side, orderConfig, &types.JournalInputs{
+			Reason: fmt.Sprintf("Rebalance %s towards its target weight", trade.Asset),
+		})
+
+		offer, err := placeStrategyOffer(args, strategy, trade.ProductID, trade.Side, orderConfig)
+
+		if errors.Is(err, errStrategyReplaced) {
+			return err
+		}
+
+		if err != nil {
+			fmt.Printf("Failed to place %s order for %s\n%v\n", trade.Side, trade.Asset, err)
+			failedTrades++
+
+			continue
+		}
+
+		err = addOpenOffer(args, strategy, *offer)
+
+		if err != nil {
+			return err
+		}
+
+		order, err := server_utils.WaitForOrder(args.Exchange, offer.OrderId, orderPollInterval)
+
+		if err != nil {
+			return fmt.Errorf("Failed to get status of order %q\n%v\n", offer.OrderId, err)
+		}
+
+		err = closeStrategyOffer(args, strategy, offer.ClientOrderId, order)
+
+		if err != nil {
+			return err
+		}
+
+		if order.Status != types.FILLED {
+			fmt.Printf("Order %q for %s is %s with %s filled\n", order.OrderID, trade.Asset, order.Status, order.FilledSize)
+			failedTrades++
+		}
+	}
+
+	if failedTrades > 0 {
+		return fmt.Errorf("%d of %d rebalance trades did not fill", failedTrades, len(strategy.Rebalance.Trades))
+	}
+
+	return nil
+}
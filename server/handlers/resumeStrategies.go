@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/journal"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// ResumeStrategies restarts the scheduled strategies found in state, so a server restart
+// carries on with a plan instead of starting over.
+func ResumeStrategies(ex exchange.Exchange, stateRepository state.Repository, j *journal.Journal, orderSettings types.OrderSettings) error {
+	currentState, err := stateRepository.GetState()
+
+	if err != nil {
+		return err
+	}
+
+	for _, portfolio := range currentState.Portfolios {
+		strategy := portfolio.CurrentStrategy
+
+		if !isResumable(strategy) {
+			continue
+		}
+
+		fmt.Printf("Resuming %s strategy for portfolio %q\n", strategy.Name, portfolio.Name)
+
+		go executeStrategy(executeStrategyArgs{
+			Exchange:         ex,
+			Portfolio:        portfolio,
+			StateRepository:  stateRepository,
+			Journal:          j,
+			ProductID:        getStrategyProductID(strategy),
+			StrategyName:     strategy.Name,
+			StrategyCurrency: strategy.Currency,
+			MaxAttempts:      defaultMaxAttempts,
+			MaxPriceDrift:    defaultMaxPriceDrift,
+			OrderSettings:    orderSettings,
+			Strategy:         strategy,
+		})
+	}
+
+	return nil
+}
+
+// isResumable reports whether a strategy saved in state still has work to do.
+func isResumable(strategy *types.Strategy) bool {
+	if strategy == nil {
+		return false
+	}
+
+	switch strategy.Name {
+	case types.HODL:
+		// Only a TWAP buy is spread over time, TWAP plans saved without a product can't be resumed
+		return strategy.TWAP != nil && strategy.TWAP.ProductID != "" && strategy.TWAP.SlicesCompleted < strategy.TWAP.Slices
+	case types.DCA:
+		return strategy.DCA != nil && !strategy.DCA.IsComplete() && !strategy.DCA.IsStopped()
+	case types.GRID:
+		return strategy.Grid != nil && hasGridOffers(strategy)
+	default:
+		return false
+	}
+}
+
+func getStrategyProductID(strategy *types.Strategy) string {
+	switch {
+	case strategy.DCA != nil:
+		return strategy.DCA.ProductID
+	case strategy.Grid != nil:
+		return strategy.Grid.ProductID
+	case strategy.TWAP != nil:
+		return strategy.TWAP.ProductID
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fossoreslp/go-uuid-v4"
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// errStrategyReplaced is returned instead of placing an order for a strategy that isn't current any more.
+var errStrategyReplaced = errors.New("Strategy has been replaced, not placing any more orders")
+
+// placeStrategyOffer places an order for a running strategy, as long as it's still the portfolio's
+// current strategy. A replaced strategy is archived, and archived strategies don't trade.
+func placeStrategyOffer(args executeStrategyArgs, strategy *types.Strategy, productID string, side types.Side, config *types.OrderConfiguration) (*types.Offer, error) {
+	current, err := args.StateRepository.GetStrategy(args.Portfolio.Uuid)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the current strategy\n%v\n", err)
+	}
+
+	if current == nil || current.Id != strategy.Id {
+		return nil, errStrategyReplaced
+	}
+
+	return placeOffer(args.Exchange, args.Portfolio.Uuid, productID, side, config)
+}
+
+// placeOffer places an order with the given configuration and returns the offer to track it by.
+func placeOffer(ex exchange.Exchange, portfolioID, productID string, side types.Side, config *types.OrderConfiguration) (*types.Offer, error) {
+	clientOrderID, err := uuid.NewString()
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to generate uuid for clientOrderId\n%v\n", err)
+	}
+
+	offer := &types.Offer{
+		ClientOrderId:         clientOrderID,
+		ProductId:             productID,
+		Side:                  side,
+		Config:                *config,
+		SelfTradePreventionId: types.Default,
+		RetailPortfolioId:     portfolioID,
+	}
+
+	placeOrderResult, err := server_utils.PlaceOrder(&server_utils.PlaceOrderArgs{
+		Exchange: ex,
+		Offer:    offer,
+		Preview:  false,
+	})
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to place order\n%v\n", err)
+	}
+
+	offer.OrderId = placeOrderResult.Order.GetOrderID()
+	offer.Status = types.OPEN
+
+	return offer, nil
+}
+
+// closeOffer moves the offer with the given client order id from OpenOffers to ClosedOffers.
+func closeOffer(strategy *types.Strategy, clientOrderID string, order *types.Order) {
+	openOffers := []types.Offer{}
+
+	for _, offer := range strategy.OpenOffers {
+		if offer.ClientOrderId != clientOrderID {
+			openOffers = append(openOffers, offer)
+			continue
+		}
+
+		offer.ApplyOrder(order)
+		strategy.ClosedOffers = append(strategy.ClosedOffers, offer)
+	}
+
+	strategy.OpenOffers = openOffers
+}
+
+// closeOfferByOrderID moves the open offer for the given exchange order to ClosedOffers.
+func closeOfferByOrderID(strategy *types.Strategy, order *types.Order) {
+	for _, offer := range strategy.OpenOffers {
+		if offer.OrderId == order.OrderID {
+			closeOffer(strategy, offer.ClientOrderId, order)
+			return
+		}
+	}
+}
+
+// recordDecision journals an order a strategy is about to place, and what it decided it on.
+func recordDecision(args executeStrategyArgs, strategy *types.Strategy, productID string, side types.Side, config *types.OrderConfiguration, inputs *types.JournalInputs) {
+	args.Journal.Record(types.JournalEntry{
+		Event:       types.StrategyDecision,
+		PortfolioID: args.Portfolio.Uuid,
+		Strategy:    strategy.Name,
+		StrategyID:  strategy.Id,
+		ProductID:   productID,
+		Side:        side,
+		Inputs:      inputs,
+		Order:       config,
+	})
+}
+
+// updateStrategy applies change to the running strategy and to its saved copy, in one state transaction.
+// Only what change touches is written, so a protection the monitor triggered or an offer the
+// reconciler closed isn't undone by the strategy's own copy, which doesn't have those changes.
+func updateStrategy(args executeStrategyArgs, strategy *types.Strategy, change func(*types.Strategy)) error {
+	change(strategy)
+
+	err := args.StateRepository.UpdateStrategy(args.Portfolio.Uuid, strategy.Id, func(saved *types.Strategy) error {
+		change(saved)
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("Failed to save state\n%v\n", err)
+	}
+
+	return nil
+}
+
+// addOpenOffer tracks a placed order against the strategy.
+func addOpenOffer(args executeStrategyArgs, strategy *types.Strategy, offer types.Offer) error {
+	return updateStrategy(args, strategy, func(s *types.Strategy) {
+		s.OpenOffers = append(s.OpenOffers, offer)
+	})
+}
+
+// closeStrategyOffer moves a finished order of the strategy into ClosedOffers.
+// It's left alone if the reconciler has already closed it.
+func closeStrategyOffer(args executeStrategyArgs, strategy *types.Strategy, clientOrderID string, order *types.Order) error {
+	return updateStrategy(args, strategy, func(s *types.Strategy) {
+		closeOffer(s, clientOrderID, order)
+	})
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// The limit price of a trailing stop sits below the stop price so the order still fills
+// when the price is falling quickly.
+const trailingStopLimitOffset = 0.5 // percent
+
+func validateTrailingStop(trailingStop *types.TrailingStop, protection *types.Protection) error {
+	if trailingStop == nil {
+		return nil
+	}
+
+	if trailingStop.Percent <= 0 || trailingStop.Percent >= 100 {
+		return fmt.Errorf("Trailing stop percent must be between 0 and 100\nGiven: %f", trailingStop.Percent)
+	}
+
+	// Both sell the whole position, and the stop-limit order holds it so the protection sell would fail
+	if protection != nil {
+		return fmt.Errorf("A trailing stop can't be combined with a stop-loss or take-profit")
+	}
+
+	return nil
+}
+
+// checkTrailingStop raises the trailing stop's peak to the best bid and moves the resting
+// stop-limit order up with it. The strategy is a snapshot, orders are placed and cancelled
+// outside of the state transaction and only the trailing stop's own fields are written back.
+func (m *ProtectionMonitor) checkTrailingStop(portfolio types.Portfolio, strategy *types.Strategy) error {
+	trailingStop := *strategy.TrailingStop
+
+	if trailingStop.OrderId != "" {
+		order, err := m.exchange.GetOrder(trailingStop.OrderId)
+
+		if err != nil {
+			return fmt.Errorf("Failed to get status of trailing stop order %q\n%v\n", trailingStop.OrderId, err)
+		}
+
+		if order.Status.IsTerminal() {
+			err = m.updateTrailingStop(portfolio, strategy, func(saved *types.Strategy) {
+				closeOfferByOrderID(saved, order)
+
+				if saved.TrailingStop.OrderId != order.OrderID {
+					return
+				}
+
+				switch order.Status {
+				case types.FILLED:
+					saved.TrailingStop.Triggered = true
+				case types.CANCELLED:
+					// The monitor clears the order id when it cancels its own order, so this
+					// was cancelled by the user and the stop stays off until it is set again
+					saved.TrailingStop.Disarmed = true
+				default:
+					saved.TrailingStop.OrderId = ""
+				}
+			})
+
+			if err != nil {
+				return err
+			}
+
+			switch order.Status {
+			case types.FILLED:
+				fmt.Printf("Trailing stop order %q filled at %s\n", order.OrderID, order.AverageFilledPrice)
+				return nil
+			case types.CANCELLED:
+				fmt.Printf("Trailing stop order %q was cancelled, the trailing stop is disarmed\n", order.OrderID)
+				return nil
+			}
+
+			// Expired or failed, place it again
+			trailingStop.OrderId = ""
+		}
+	}
+
+	portfolioDetails, err := m.exchange.PortfolioDetails(portfolio.Uuid)
+
+	if err != nil {
+		return fmt.Errorf("Failed to get portfolio details\n%v\n", err)
+	}
+
+	var size types.Decimal
+	for _, position := range portfolioDetails.Breakdown.SpotPositions {
+		if position.Asset == string(strategy.Currency) {
+			size = position.TotalBalanceCrypto
+			break
+		}
+	}
+
+	productID, err := server_utils.GetProductID(m.exchange, portfolioDetails, string(strategy.Currency), strategy.QuoteCurrency)
+
+	if err != nil {
+		return err
+	}
+
+	bestBidAsk, err := server_utils.GetBestBidAsk(m.exchange, productID)
+
+	if err != nil {
+		return err
+	}
+
+	bid, err := types.ParseDecimal(bestBidAsk.PriceBooks[0].Bids[0].Price)
+
+	if err != nil {
+		return fmt.Errorf("Failed to convert best bid to decimal\n%v\n", err)
+	}
+
+	newPeak := trailingStop.Observe(bid)
+	stopPrice := trailingStop.StopPriceForPeak()
+
+	if size.Sign() <= 0 || (trailingStop.OrderId != "" && !stopPrice.GreaterThan(trailingStop.StopPrice)) {
+		if !newPeak {
+			return nil
+		}
+
+		return m.updateTrailingStop(portfolio, strategy, func(saved *types.Strategy) {
+			saved.TrailingStop.Observe(bid)
+		})
+	}
+
+	if trailingStop.OrderId != "" {
+		err = m.cancelTrailingStopOrder(portfolio, strategy, trailingStop.OrderId)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	product, err := server_utils.GetProduct(m.exchange, productID)
+
+	if err != nil {
+		return err
+	}
+
+	baseSize := product.RoundBaseSize(size)
+	var orderConfig *types.OrderConfiguration
+
+	if !bid.GreaterThan(stopPrice) {
+		// Already below the stop, a stop order would be rejected so sell at the best bid instead
+		orderConfig = &types.OrderConfiguration{
+			LimitLimitGTD: &types.LimitLimitGTD{
+				BaseSize:   baseSize,
+				LimitPrice: bid,
+				PostOnly:   false,
+				EndTime:    m.orderSettings.GetEndTime(),
+			},
+		}
+	} else {
+		orderConfig = &types.OrderConfiguration{
+			StopLimitStopLimitGTC: &types.StopLimitStopLimitGTC{
+				BaseSize:      baseSize,
+				LimitPrice:    product.RoundPrice(stopPrice.Mul(types.NewDecimalFromFloat(1-trailingStopLimitOffset/100)), types.SELL),
+				StopPrice:     product.RoundPrice(stopPrice, types.SELL),
+				StopDirection: types.StopDirectionDown,
+			},
+		}
+	}
+
+	offer, err := placeOffer(m.exchange, portfolio.Uuid, productID, types.SELL, orderConfig)
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Trailing stop for %s at %s, peak %s\n", productID, stopPrice.StringFixed(2), trailingStop.PeakPrice)
+
+	return m.updateTrailingStop(portfolio, strategy, func(saved *types.Strategy) {
+		saved.OpenOffers = append(saved.OpenOffers, *offer)
+		saved.TrailingStop.Observe(bid)
+		saved.TrailingStop.OrderId = offer.OrderId
+		saved.TrailingStop.StopPrice = stopPrice
+	})
+}
+
+// stopArchivedTrailingStop cancels the resting order of an archived strategy's trailing stop.
+// Archived strategies don't trade, and the stop would sell the position of the current strategy.
+// An order that has already finished is recorded like checkTrailingStop would, and not replaced.
+func (m *ProtectionMonitor) stopArchivedTrailingStop(portfolio types.Portfolio, strategy *types.Strategy) error {
+	orderID := strategy.TrailingStop.OrderId
+	order, err := m.exchange.GetOrder(orderID)
+
+	if err != nil {
+		return fmt.Errorf("Failed to get status of trailing stop order %q\n%v\n", orderID, err)
+	}
+
+	if !order.Status.IsTerminal() {
+		fmt.Printf("Cancelling trailing stop order %q of archived strategy %q\n", orderID, strategy.Id)
+
+		return m.cancelTrailingStopOrder(portfolio, strategy, orderID)
+	}
+
+	return m.updateTrailingStop(portfolio, strategy, func(saved *types.Strategy) {
+		closeOfferByOrderID(saved, order)
+
+		if saved.TrailingStop.OrderId == orderID {
+			saved.TrailingStop.OrderId = ""
+			saved.TrailingStop.Triggered = order.Status == types.FILLED
+		}
+	})
+}
+
+// cancelTrailingStopOrder cancels the trailing stop's order and clears its order id in the same
+// update that closes the offer, so the cancel isn't mistaken for one made by the user.
+func (m *ProtectionMonitor) cancelTrailingStopOrder(portfolio types.Portfolio, strategy *types.Strategy, orderID string) error {
+	cancelResponse, err := m.exchange.CancelOrders([]string{orderID})
+
+	if err != nil {
+		return fmt.Errorf("Failed to cancel trailing stop order %q\n%v\n", orderID, err)
+	}
+
+	for _, result := range cancelResponse.Results {
+		if result.OrderID == orderID && !result.Success {
+			return fmt.Errorf("Failed to cancel trailing stop order %q: %s", orderID, result.FailureReason)
+		}
+	}
+
+	order, err := m.exchange.GetOrder(orderID)
+
+	if err != nil {
+		return fmt.Errorf("Failed to get status of trailing stop order %q\n%v\n", orderID, err)
+	}
+
+	return m.updateTrailingStop(portfolio, strategy, func(saved *types.Strategy) {
+		closeOfferByOrderID(saved, order)
+
+		if saved.TrailingStop.OrderId == orderID {
+			saved.TrailingStop.OrderId = ""
+		}
+	})
+}
+
+// updateTrailingStop writes a change to the saved strategy. The strategy's trailing stop is
+// left alone if it has been removed since the snapshot was taken.
+func (m *ProtectionMonitor) updateTrailingStop(portfolio types.Portfolio, strategy *types.Strategy, change func(*types.Strategy)) error {
+	err := m.stateRepository.UpdateStrategy(portfolio.Uuid, strategy.Id, func(saved *types.Strategy) error {
+		if saved.TrailingStop == nil {
+			return state.ErrNoChanges
+		}
+
+		change(saved)
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf("Failed to save trailing stop\n%v\n", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,240 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/fossoreslp/go-uuid-v4"
+	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+func validateTWAPPlan(plan *types.TWAPPlan, strategyName types.StrategyName, orderType types.OrderType) error {
+	if plan == nil {
+		return nil
+	}
+
+	if strategyName != types.HODL {
+		return fmt.Errorf("TWAP execution is only supported by the HODL strategy\nGiven: %q", strategyName)
+	}
+
+	// Each slice expires before the next one starts
+	if orderType != "" && orderType != types.LimitGTDOrder {
+		return fmt.Errorf("TWAP slices are always limit GTD orders\nGiven: %q", orderType)
+	}
+
+	if plan.Slices < 1 {
+		return fmt.Errorf("TWAP requires at least 1 slice\nGiven: %d", plan.Slices)
+	}
+
+	duration, err := time.ParseDuration(plan.Duration)
+
+	if err != nil || duration <= 0 {
+		return fmt.Errorf("Invalid TWAP duration\nGiven: %q", plan.Duration)
+	}
+
+	return nil
+}
+
+// executeTWAP works out the base size that spends all available fiat, then buys it in
+// slices spread evenly over the plan's duration. Each child order is recorded against the
+// strategy with the plan's parent id, so a resumed plan carries on from the next slice.
+func executeTWAP(args executeStrategyArgs, strategy *types.Strategy) error {
+	plan := strategy.TWAP
+	duration, err := time.ParseDuration(plan.Duration)
+
+	if err != nil {
+		return fmt.Errorf("Invalid TWAP duration\nGiven: %q\n%v\n", plan.Duration, err)
+	}
+
+	sliceInterval := duration / time.Duration(plan.Slices)
+
+	if plan.ParentId == "" {
+		err = startTWAP(args, strategy)
+	} else {
+		fmt.Printf("Resuming TWAP %q after %d of %d slices\n", plan.ParentId, plan.SlicesCompleted, plan.Slices)
+		err = resumeTWAP(args, strategy)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("TWAP buying %s %s in %d slices over %s\n", plan.TotalBaseSize, args.ProductID, plan.Slices, plan.Duration)
+
+	// Slices already bought keep their place in the schedule, so the next one starts now
+	start := time.Now().Add(-sliceInterval * time.Duration(plan.SlicesCompleted))
+
+	for slice := plan.SlicesCompleted; slice < plan.Slices; slice++ {
+		if wait := time.Until(start.Add(sliceInterval * time.Duration(slice))); wait > 0 {
+			time.Sleep(wait)
+		}
+
+		remaining := plan.TotalBaseSize.Sub(plan.FilledBaseSize)
+
+		if remaining.Sign() <= 0 {
+			break
+		}
+
+		// Re-quote for every slice, and never buy more than the fiat left can pay for
+		orderConfig, inputs, err := getAffordableOrderConfig(args)
+
+		if errors.Is(err, errNothingToSpend) {
+			fmt.Printf("No fiat left to spend, stopping\n")
+
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		sliceSize := remaining.Div(types.NewDecimalFromInt(int64(plan.Slices - slice)))
+		sliceSize = types.MinDecimal(args.Product.RoundBaseSize(sliceSize), orderConfig.LimitLimitGTD.BaseSize)
+
+		if sliceSize.Sign() <= 0 {
+			fmt.Printf("Remaining size is below the minimum increment, stopping\n")
+
+			return nil
+		}
+
+		orderConfig.LimitLimitGTD.BaseSize = sliceSize
+		orderConfig.LimitLimitGTD.EndTime = time.Now().Add(sliceInterval).Format(time.RFC3339)
+
+		fmt.Printf("Placing TWAP slice %d of %d\n", slice+1, plan.Slices)
+		inputs.Reason = fmt.Sprintf("TWAP slice %d of %d", slice+1, plan.Slices)
+		recordDecision(args, strategy, args.ProductID, types.BUY, orderConfig, inputs)
+
+		offer, err := placeStrategyOffer(args, strategy, args.ProductID, types.BUY, orderConfig)
+
+		if err != nil {
+			return err
+		}
+
+		offer.ParentId = plan.ParentId
+		err = addOpenOffer(args, strategy, *offer)
+
+		if err != nil {
+			return err
+		}
+
+		order, err := server_utils.WaitForOrder(args.Exchange, offer.OrderId, orderPollInterval)
+
+		if err != nil {
+			return fmt.Errorf("Failed to get status of order %q\n%v\n", offer.OrderId, err)
+		}
+
+		err = updateStrategy(args, strategy, func(s *types.Strategy) {
+			closeOffer(s, offer.ClientOrderId, order)
+			s.TWAP.FilledBaseSize = s.TWAP.FilledBaseSize.Add(order.FilledSize)
+			s.TWAP.SlicesCompleted++
+		})
+
+		if err != nil {
+			return err
+		}
+
+		if order.Status == types.FAILED {
+			return fmt.Errorf("Order %q failed", order.OrderID)
+		}
+	}
+
+	fmt.Printf("TWAP complete, filled %s of %s\n", plan.FilledBaseSize, plan.TotalBaseSize)
+
+	return nil
+}
+
+// startTWAP gives a new plan its parent id and the base size to buy.
+func startTWAP(args executeStrategyArgs, strategy *types.Strategy) error {
+	parentID, err := uuid.NewString()
+
+	if err != nil {
+		return fmt.Errorf("Failed to generate uuid for parent order\n%v\n", err)
+	}
+
+	parentConfig, _, err := getAffordableOrderConfig(args)
+
+	if err != nil {
+		return err
+	}
+
+	return updateStrategy(args, strategy, func(s *types.Strategy) {
+		s.TWAP.ParentId = parentID
+		s.TWAP.TotalBaseSize = parentConfig.LimitLimitGTD.BaseSize
+	})
+}
+
+// resumeTWAP waits for the slice that was open when the server stopped, then works out the
+// plan's progress from its child orders, as the reconciler may have closed some of them.
+func resumeTWAP(args executeStrategyArgs, strategy *types.Strategy) error {
+	plan := strategy.TWAP
+	openOffers := append([]types.Offer{}, strategy.OpenOffers...)
+
+	for _, offer := range openOffers {
+		if offer.ParentId != plan.ParentId {
+			continue
+		}
+
+		order, err := server_utils.WaitForOrder(args.Exchange, offer.OrderId, orderPollInterval)
+
+		if err != nil {
+			return fmt.Errorf("Failed to get status of order %q\n%v\n", offer.OrderId, err)
+		}
+
+		err = closeStrategyOffer(args, strategy, offer.ClientOrderId, order)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	slicesCompleted := 0
+	var filledBaseSize types.Decimal
+
+	for _, offer := range strategy.ClosedOffers {
+		if offer.ParentId != plan.ParentId {
+			continue
+		}
+
+		filledBaseSize = filledBaseSize.Add(offer.FilledSize)
+		slicesCompleted++
+	}
+
+	return updateStrategy(args, strategy, func(s *types.Strategy) {
+		s.TWAP.SlicesCompleted = slicesCompleted
+		s.TWAP.FilledBaseSize = filledBaseSize
+	})
+}
+
+// getAffordableOrderConfig quotes an order that spends all the available fiat at the best bid,
+// and returns what it was based on.
+func getAffordableOrderConfig(args executeStrategyArgs) (*types.OrderConfiguration, *types.JournalInputs, error) {
+	portfolioDetails, err := args.Exchange.PortfolioDetails(args.Portfolio.Uuid)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to get portfolio details\n%v\n", err)
+	}
+
+	bestBidAsk, err := server_utils.GetBestBidAsk(args.Exchange, args.ProductID)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("Failed to get best bid/ask \n%v\n", err)
+	}
+
+	configArgs := &createOrderConfigArgs{
+		Breakdown:     &portfolioDetails.Breakdown,
+		StrategyName:  args.StrategyName,
+		BestBidAsk:    bestBidAsk,
+		Product:       args.Product,
+		OrderSettings: args.OrderSettings,
+	}
+
+	orderConfig, err := createOrderConfig(configArgs)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return orderConfig, getDecisionInputs(configArgs, ""), nil
+}
@@ -0,0 +1,134 @@
+package journal
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+// Journal appends an entry per line to a JSON lines file and never changes what's already there.
+// A nil Journal records nothing, so code that writes to it doesn't need one to run.
+type Journal struct {
+	filename string
+	mu       sync.Mutex
+}
+
+func JournalFactory(filename string) *Journal {
+	if filename == "" {
+		filename = "journal.jsonl"
+	}
+
+	return &Journal{filename: filename}
+}
+
+// Record appends entry to the journal, stamping it with the current time if it has none.
+// Failing to write is logged rather than returned, an order shouldn't fail because it couldn't be journaled.
+func (j *Journal) Record(entry types.JournalEntry) {
+	if j == nil {
+		return
+	}
+
+	location := "Journal.Record()\n"
+
+	if entry.Time == "" {
+		entry.Time = time.Now().Format(time.RFC3339)
+	}
+
+	data, err := json.Marshal(entry)
+
+	if err != nil {
+		log.Printf(location+"Failed to serialize entry\n%v\n", err)
+		return
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	err = j.append(append(data, '\n'))
+
+	if err != nil {
+		log.Printf(location+"Failed to write entry\n%v\n", err)
+	}
+}
+
+func (j *Journal) append(line []byte) error {
+	filepath, err := util.GetPathToDataFile(j.filename)
+
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(filepath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0666)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(line)
+
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+// Read returns the entries that match filter, oldest first.
+func (j *Journal) Read(filter types.JournalFilter) ([]types.JournalEntry, error) {
+	entries := []types.JournalEntry{}
+
+	if j == nil {
+		return entries, nil
+	}
+
+	filepath, err := util.GetPathToDataFile(j.filename)
+
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(filepath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return entries, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		var entry types.JournalEntry
+
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+
+		// A line cut short by a crash shouldn't hide the rest of the journal
+		if err != nil {
+			log.Printf("Journal.Read(): skipping line %d\n%v\n", line, err)
+			continue
+		}
+
+		if filter.Matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, fmt.Errorf("Failed to read journal\n%v\n", err)
+	}
+
+	return entries, nil
+}
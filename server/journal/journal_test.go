@@ -0,0 +1,84 @@
+package journal
+
+import (
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+func TestMain(m *testing.M) {
+	dataDir, err := os.MkdirTemp("", "investment-manager-test-")
+
+	if err != nil {
+		log.Fatalf("Failed to create data directory\n%v\n", err)
+	}
+
+	util.SetDataDir(dataDir)
+	code := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(code)
+}
+
+func TestJournal(t *testing.T) {
+	const filename = "test-journal-package.jsonl"
+
+	pathToFile, _ := util.GetPathToDataFile(filename)
+	os.Remove(pathToFile)
+
+	t.Cleanup(func() {
+		os.Remove(pathToFile)
+	})
+
+	testJournal := JournalFactory(filename)
+
+	testJournal.Record(types.JournalEntry{Event: types.OrderPlaced, ProductID: "ETH-GBP", Time: "2024-05-01T20:00:00Z"})
+	testJournal.Record(types.JournalEntry{Event: types.OrderFailed, ProductID: "BTC-GBP"})
+
+	t.Run("Reads entries back oldest first", func(t *testing.T) {
+		entries, err := testJournal.Read(types.JournalFilter{})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if len(entries) != 2 || entries[0].ProductID != "ETH-GBP" || entries[1].Time == "" {
+			t.Errorf("Unexpected entries: %+v", entries)
+		}
+	})
+
+	t.Run("Filters entries", func(t *testing.T) {
+		since := time.Now().Add(-time.Hour).Format(time.RFC3339)
+		entries, _ := testJournal.Read(types.JournalFilter{Since: since})
+
+		if len(entries) != 1 || entries[0].Event != types.OrderFailed {
+			t.Errorf("Expected only the recent entry, found %+v", entries)
+		}
+	})
+
+	t.Run("Skips a line cut short by a crash", func(t *testing.T) {
+		file, _ := os.OpenFile(pathToFile, os.O_APPEND|os.O_WRONLY, 0666)
+		file.WriteString(`{"event": "order_pla`)
+		file.Close()
+
+		entries, err := testJournal.Read(types.JournalFilter{})
+
+		if err != nil || len(entries) != 2 {
+			t.Errorf("Expected the 2 complete entries, found %d\n%v", len(entries), err)
+		}
+	})
+
+	t.Run("Records nothing without a journal", func(t *testing.T) {
+		var noJournal *Journal
+		noJournal.Record(types.JournalEntry{Event: types.OrderPlaced})
+
+		entries, err := noJournal.Read(types.JournalFilter{})
+
+		if err != nil || len(entries) != 0 {
+			t.Errorf("Expected no entries, found %+v\n%v", entries, err)
+		}
+	})
+}
@@ -1,16 +1,180 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"log"
 	"net/http"
+	"os"
+	"time"
 
+	"github.com/iPopcorn/investment-manager/config"
 	"github.com/iPopcorn/investment-manager/server"
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/handlers"
+	"github.com/iPopcorn/investment-manager/server/journal"
+	"github.com/iPopcorn/investment-manager/server/reconciler"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/util"
 )
 
 func main() {
-	address := "127.0.0.1:5000"
-	server := server.GetDefaultInvestmentManagerHTTPServer()
+	// Flags are the last config layer, they only override config.yaml and the environment when given
+	defaults := config.GetDefaultConfig()
+	flags := defaults
+	flag.StringVar(&flags.Exchange, "exchange", defaults.Exchange, "Exchange to trade against: 'coinbase' or 'paper'")
+	flag.StringVar(&flags.ServerAddress, "address", defaults.ServerAddress, "Address to listen on")
+	flag.Float64Var(&flags.PaperFunds, "paper-funds", defaults.PaperFunds, "Starting fiat balance of the paper portfolio")
+	flag.StringVar(&flags.PaperCurrency, "paper-currency", defaults.PaperCurrency, "Fiat currency of the paper portfolio")
+	flag.StringVar(&flags.StateBackend, "state-backend", defaults.StateBackend, "Where state is kept: 'json' or 'sqlite'")
+	flag.DurationVar(&flags.OrderExpiry, "order-expiry", defaults.OrderExpiry, "How long limit orders rest before they expire")
+	flag.DurationVar(&flags.ReconcileInterval, "reconcile-interval", defaults.ReconcileInterval, "How often to poll the exchange for the status of open orders")
+	flag.DurationVar(&flags.ProtectionInterval, "protection-interval", defaults.ProtectionInterval, "How often to check prices against stop-loss and take-profit thresholds")
+	flag.DurationVar(&flags.ProductCacheTTL, "product-cache-ttl", defaults.ProductCacheTTL, "How long product increments, size limits and trading status are cached for")
+	importState := flag.Bool("import-state", false, "Import the JSON state file into the SQLite database, then exit")
+	profile := flag.String("profile", "", "Profile in config.yaml to use, e.g. 'live' or 'paper' (default $"+config.ProfileEnvVar+", then the profile named in config.yaml)")
+	dataDir := flag.String("data-dir", "", "Directory state, the journal and the paper exchange are kept in (default $"+util.DataDirEnvVar+", then $XDG_DATA_HOME/investment-manager)")
+	configDir := flag.String("config-dir", "", "Directory config.yaml and .env are read from (default $"+util.ConfigDirEnvVar+", then $XDG_CONFIG_HOME/investment-manager)")
+	flag.Parse()
 
-	log.Printf("Listening at %s\n", address)
-	log.Fatal(http.ListenAndServe(address, server))
+	util.SetDataDir(*dataDir)
+	util.SetConfigDir(*configDir)
+	config.SetProfile(*profile)
+
+	cfg, err := config.GetConfig()
+
+	if err != nil {
+		log.Fatalf("Failed to load config\n%v\n", err)
+	}
+
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "exchange":
+			cfg.Exchange = flags.Exchange
+		case "address":
+			cfg.ServerAddress = flags.ServerAddress
+		case "paper-funds":
+			cfg.PaperFunds = flags.PaperFunds
+		case "paper-currency":
+			cfg.PaperCurrency = flags.PaperCurrency
+		case "state-backend":
+			cfg.StateBackend = flags.StateBackend
+		case "order-expiry":
+			cfg.OrderExpiry = flags.OrderExpiry
+		case "reconcile-interval":
+			cfg.ReconcileInterval = flags.ReconcileInterval
+		case "protection-interval":
+			cfg.ProtectionInterval = flags.ProtectionInterval
+		case "product-cache-ttl":
+			cfg.ProductCacheTTL = flags.ProductCacheTTL
+		}
+	})
+
+	err = cfg.Validate()
+
+	if err != nil {
+		log.Fatalf("Invalid flags\n%v\n", err)
+	}
+
+	var ex exchange.Exchange
+	stateName := "state"
+	journalName := "journal.jsonl"
+
+	switch cfg.Exchange {
+	case "coinbase":
+		ex = exchange.GetDefaultCoinbaseExchange()
+	case "paper":
+		paperExchange, err := exchange.PaperExchangeFactory(exchange.PaperExchangeArgs{
+			Feed:          exchange.GetDefaultCoinbaseExchange(),
+			Filename:      "paper-exchange.json",
+			PortfolioName: "Default",
+			QuoteCurrency: cfg.PaperCurrency,
+			StartingFunds: cfg.PaperFunds,
+			OrderSettings: cfg.GetOrderSettings(),
+		})
+
+		if err != nil {
+			log.Fatalf("Failed to start paper exchange\n%v\n", err)
+		}
+
+		go paperExchange.Watch(time.Second*10, nil)
+
+		ex = paperExchange
+		stateName = "paper-state"
+		journalName = "paper-journal.jsonl"
+	default:
+		log.Fatalf("Unsupported exchange: %q\n", cfg.Exchange)
+	}
+
+	orderJournal := journal.JournalFactory(journalName)
+	ex = exchange.JournaledExchangeFactory(exchange.ProductCatalogueFactory(ex, cfg.ProductCacheTTL), orderJournal)
+
+	if *importState {
+		sqliteRepository, err := state.SQLiteStateRepositoryFactory(stateName + ".db")
+
+		if err != nil {
+			log.Fatalf("Failed to open state database\n%v\n", err)
+		}
+
+		err = state.ImportState(state.StateRepositoryFactory(stateName+".json"), sqliteRepository)
+
+		if err != nil {
+			log.Fatalf("Failed to import state\n%v\n", err)
+		}
+
+		log.Printf("Imported %s.json into %s.db, set STATE_BACKEND=sqlite to use it\n", stateName, stateName)
+
+		return
+	}
+
+	jsonRepository := state.StateRepositoryFactory(stateName + ".json")
+	var stateRepository state.Repository = jsonRepository
+
+	if cfg.StateBackend == "json" {
+		_, err = jsonRepository.Migrate(false)
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("Failed to migrate state\n%v\n", err)
+		}
+	}
+
+	if cfg.StateBackend == "sqlite" {
+		sqliteRepository, err := state.SQLiteStateRepositoryFactory(stateName + ".db")
+
+		if err != nil {
+			log.Fatalf("Failed to open state database\n%v\n", err)
+		}
+
+		_, err = sqliteRepository.Migrate(false)
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.Fatalf("Failed to migrate state\n%v\n", err)
+		}
+
+		stateRepository = sqliteRepository
+	}
+
+	investmentManagerServer := server.InvestmentManagerHttpServerFactory(server.InvestmentManagerHTTPServerArgs{
+		Exchange:          ex,
+		StateRepository:   stateRepository,
+		Journal:           orderJournal,
+		AllowedCurrencies: cfg.AllowedCurrencies,
+		OrderSettings:     cfg.GetOrderSettings(),
+	})
+
+	err = handlers.ResumeStrategies(ex, stateRepository, orderJournal, cfg.GetOrderSettings())
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		log.Printf("Failed to resume strategies\n%v\n", err)
+	}
+
+	go reconciler.ReconcilerFactory(ex, stateRepository).Run(cfg.ReconcileInterval, nil)
+	go handlers.ProtectionMonitorFactory(ex, stateRepository, cfg.GetOrderSettings()).Run(cfg.ProtectionInterval, nil)
+
+	if cfg.Profile != "" {
+		log.Printf("Using profile %q\n", cfg.Profile)
+	}
+
+	log.Printf("Listening at %s using %s exchange\n", cfg.ServerAddress, cfg.Exchange)
+	log.Fatal(http.ListenAndServe(cfg.ServerAddress, investmentManagerServer))
 }
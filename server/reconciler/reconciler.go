@@ -0,0 +1,180 @@
+package reconciler
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// Reconciler polls the exchange for the status of every open offer in state
+// and moves offers the exchange is done with into ClosedOffers.
+type Reconciler struct {
+	exchange        exchange.Exchange
+	stateRepository state.Repository
+}
+
+func ReconcilerFactory(ex exchange.Exchange, stateRepository state.Repository) *Reconciler {
+	return &Reconciler{
+		exchange:        ex,
+		stateRepository: stateRepository,
+	}
+}
+
+// Run reconciles every interval until stop receives.
+func (r *Reconciler) Run(interval time.Duration, stop <-chan bool) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			err := r.Reconcile()
+
+			if err != nil {
+				log.Printf("Reconciler.Run: failed to reconcile orders\n%v\n", err)
+			}
+		}
+	}
+}
+
+// Reconcile fetches the status of every open offer first, then applies the results in one Update,
+// so the exchange isn't called while other writers are waiting on state.
+func (r *Reconciler) Reconcile() error {
+	location := "Reconciler.Reconcile()\n"
+	currentState, err := r.stateRepository.GetState()
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to read state\n%v", err)
+	}
+
+	orders := r.getOrders(location, currentState)
+
+	if len(orders) == 0 {
+		return nil
+	}
+
+	err = r.stateRepository.Update(func(currentState *types.State) error {
+		if !reconcileState(location, currentState, orders) {
+			return state.ErrNoChanges
+		}
+
+		currentState.LastUpdated = time.Now().Format(time.RFC3339)
+
+		return nil
+	})
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to save state\n%v", err)
+	}
+
+	return nil
+}
+
+// getOrders returns the exchange's view of every open offer the reconciler looks after, by order id.
+func (r *Reconciler) getOrders(location string, currentState *types.State) map[string]*types.Order {
+	orders := map[string]*types.Order{}
+
+	for i := range currentState.Portfolios {
+		portfolio := &currentState.Portfolios[i]
+
+		for _, strategy := range getReconciledStrategies(portfolio) {
+			for _, offer := range strategy.OpenOffers {
+				if !isReconciled(portfolio, strategy, offer) {
+					continue
+				}
+
+				if offer.OrderId == "" {
+					log.Printf(location+"Open offer has no order id, skipping\nclient_order_id: %q\n", offer.ClientOrderId)
+
+					continue
+				}
+
+				order, err := r.exchange.GetOrder(offer.OrderId)
+
+				if err != nil {
+					log.Printf(location+"Failed to get order status\norder_id: %q\n%v\n", offer.OrderId, err)
+
+					continue
+				}
+
+				orders[offer.OrderId] = order
+			}
+		}
+	}
+
+	return orders
+}
+
+// reconcileState applies the fetched orders to the open offers, closes the ones that have finished
+// and reports whether anything changed. Offers closed since the orders were fetched are left alone.
+func reconcileState(location string, currentState *types.State, orders map[string]*types.Order) bool {
+	updated := false
+
+	for i := range currentState.Portfolios {
+		for _, strategy := range getReconciledStrategies(&currentState.Portfolios[i]) {
+			openOffers := []types.Offer{}
+
+			for _, offer := range strategy.OpenOffers {
+				order, ok := orders[offer.OrderId]
+
+				if !ok {
+					openOffers = append(openOffers, offer)
+
+					continue
+				}
+
+				if offer.ApplyOrder(order) {
+					updated = true
+				}
+
+				if offer.Status.IsTerminal() {
+					log.Printf(location+"Order %q is %s, closing offer\n", offer.OrderId, offer.Status)
+					strategy.ClosedOffers = append(strategy.ClosedOffers, offer)
+					updated = true
+				} else {
+					openOffers = append(openOffers, offer)
+				}
+			}
+
+			strategy.OpenOffers = openOffers
+		}
+	}
+
+	return updated
+}
+
+// getReconciledStrategies returns the strategies of the portfolio with open offers to reconcile.
+// Archived strategies no longer run, so nothing else closes their offers.
+func getReconciledStrategies(portfolio *types.Portfolio) []*types.Strategy {
+	strategies := []*types.Strategy{}
+
+	if portfolio.CurrentStrategy != nil {
+		strategies = append(strategies, portfolio.CurrentStrategy)
+	}
+
+	if portfolio.PreviousStrategies != nil {
+		for i := range *portfolio.PreviousStrategies {
+			strategies = append(strategies, &(*portfolio.PreviousStrategies)[i])
+		}
+	}
+
+	return strategies
+}
+
+// isReconciled reports whether the reconciler looks after the offer. A running grid watches its own
+// orders so it can replace the ones that fill, but not the sells protection and the trailing stop add.
+func isReconciled(portfolio *types.Portfolio, strategy *types.Strategy, offer types.Offer) bool {
+	return strategy != portfolio.CurrentStrategy || strategy.Name != types.GRID || offer.GridLevel <= 0
+}
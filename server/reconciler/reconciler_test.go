@@ -0,0 +1,226 @@
+package reconciler
+
+import (
+	"log"
+	"os"
+	"testing"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/state"
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+type testExchange struct {
+	exchange.Exchange
+	orders map[string]*types.Order
+}
+
+func (e *testExchange) GetOrder(orderID string) (*types.Order, error) {
+	return e.orders[orderID], nil
+}
+
+func TestMain(m *testing.M) {
+	dataDir, err := os.MkdirTemp("", "investment-manager-test-")
+
+	if err != nil {
+		log.Fatalf("Failed to create data directory\n%v\n", err)
+	}
+
+	util.SetDataDir(dataDir)
+	code := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(code)
+}
+
+func TestReconcile(t *testing.T) {
+	t.Run("Moves terminal orders into closed offers", func(t *testing.T) {
+		// Arrange
+		testFilename := "test-reconciler-state.json"
+		testRepo := state.StateRepositoryFactory(testFilename)
+
+		initialState := testRepo.InitState()
+		initialState.Portfolios = []types.Portfolio{
+			{
+				Name: "test",
+				Uuid: "test-portfolio-id",
+				CurrentStrategy: &types.Strategy{
+					Name:     types.HODL,
+					Currency: "ETH",
+					OpenOffers: []types.Offer{
+						{ClientOrderId: "filled-client-id", OrderId: "filled-order-id", Status: types.OPEN},
+						{ClientOrderId: "open-client-id", OrderId: "open-order-id", Status: types.OPEN},
+					},
+				},
+			},
+		}
+
+		err := testRepo.Save(*initialState)
+
+		if err != nil {
+			t.Fatalf("Failed to save initial state\n%v", err)
+		}
+
+		defer func() {
+			pathToCreatedFile, _ := util.GetPathToDataFile(testFilename)
+			os.Remove(pathToCreatedFile)
+		}()
+
+		testExchange := &testExchange{
+			orders: map[string]*types.Order{
+				"filled-order-id": {
+					OrderID:            "filled-order-id",
+					Status:             types.FILLED,
+					FilledSize:         types.MustParseDecimal("0.5"),
+					AverageFilledPrice: types.MustParseDecimal("2000"),
+					TotalFees:          types.MustParseDecimal("4"),
+				},
+				"open-order-id": {
+					OrderID:    "open-order-id",
+					Status:     types.OPEN,
+					FilledSize: types.MustParseDecimal("0.1"),
+				},
+			},
+		}
+
+		// Act
+		err = ReconcilerFactory(testExchange, testRepo).Reconcile()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Failed to reconcile\n%v", err)
+		}
+
+		actualState, err := testRepo.GetState()
+
+		if err != nil {
+			t.Fatalf("Failed to get state\n%v", err)
+		}
+
+		strategy := actualState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.OpenOffers) != 1 || strategy.OpenOffers[0].OrderId != "open-order-id" {
+			t.Fatalf("Unexpected open offers: %+v", strategy.OpenOffers)
+		}
+
+		if strategy.OpenOffers[0].FilledSize.String() != "0.1" {
+			t.Errorf("Expected partial fill to be recorded, got %q", strategy.OpenOffers[0].FilledSize.String())
+		}
+
+		if len(strategy.ClosedOffers) != 1 {
+			t.Fatalf("Expected 1 closed offer, got %d", len(strategy.ClosedOffers))
+		}
+
+		closedOffer := strategy.ClosedOffers[0]
+
+		if closedOffer.Status != types.FILLED || closedOffer.FilledSize.String() != "0.5" || closedOffer.AverageFilledPrice.String() != "2000" || closedOffer.TotalFees.String() != "4" {
+			t.Errorf("Closed offer does not match the filled order: %+v", closedOffer)
+		}
+	})
+
+	t.Run("Closes the open offers of archived strategies", func(t *testing.T) {
+		testFilename := "test-reconciler-state.json"
+		testRepo := state.StateRepositoryFactory(testFilename)
+
+		initialState := testRepo.InitState()
+		initialState.Portfolios = []types.Portfolio{
+			{
+				Name: "test",
+				Uuid: "test-portfolio-id",
+				PreviousStrategies: &[]types.Strategy{
+					{
+						Name:       types.HODL,
+						Currency:   "ETH",
+						OpenOffers: []types.Offer{{ClientOrderId: "filled-client-id", OrderId: "filled-order-id", Status: types.OPEN}},
+					},
+				},
+			},
+		}
+
+		err := testRepo.Save(*initialState)
+
+		if err != nil {
+			t.Fatalf("Failed to save initial state\n%v", err)
+		}
+
+		defer func() {
+			pathToCreatedFile, _ := util.GetPathToDataFile(testFilename)
+			os.Remove(pathToCreatedFile)
+		}()
+
+		testExchange := &testExchange{
+			orders: map[string]*types.Order{
+				"filled-order-id": {OrderID: "filled-order-id", Status: types.FILLED, FilledSize: types.MustParseDecimal("0.5")},
+			},
+		}
+
+		err = ReconcilerFactory(testExchange, testRepo).Reconcile()
+
+		if err != nil {
+			t.Fatalf("Failed to reconcile\n%v", err)
+		}
+
+		actualState, _ := testRepo.GetState()
+		strategy := (*actualState.Portfolios[0].PreviousStrategies)[0]
+
+		if len(strategy.OpenOffers) != 0 || len(strategy.ClosedOffers) != 1 {
+			t.Errorf("Expected the archived strategy's offer to be closed\nOpen: %+v\nClosed: %+v", strategy.OpenOffers, strategy.ClosedOffers)
+		}
+	})
+
+	t.Run("Closes the offers outside a grid but leaves its grid orders", func(t *testing.T) {
+		testFilename := "test-reconciler-state.json"
+		testRepo := state.StateRepositoryFactory(testFilename)
+
+		initialState := testRepo.InitState()
+		initialState.Portfolios = []types.Portfolio{
+			{
+				Name: "test",
+				Uuid: "test-portfolio-id",
+				CurrentStrategy: &types.Strategy{
+					Name:     types.GRID,
+					Currency: "ETH",
+					OpenOffers: []types.Offer{
+						{ClientOrderId: "grid-client-id", OrderId: "grid-order-id", Status: types.OPEN, GridLevel: 1},
+						{ClientOrderId: "sell-client-id", OrderId: "sell-order-id", Status: types.OPEN},
+					},
+				},
+			},
+		}
+
+		err := testRepo.Save(*initialState)
+
+		if err != nil {
+			t.Fatalf("Failed to save initial state\n%v", err)
+		}
+
+		defer func() {
+			pathToCreatedFile, _ := util.GetPathToDataFile(testFilename)
+			os.Remove(pathToCreatedFile)
+		}()
+
+		testExchange := &testExchange{
+			orders: map[string]*types.Order{
+				"grid-order-id": {OrderID: "grid-order-id", Status: types.FILLED},
+				"sell-order-id": {OrderID: "sell-order-id", Status: types.CANCELLED},
+			},
+		}
+
+		err = ReconcilerFactory(testExchange, testRepo).Reconcile()
+
+		if err != nil {
+			t.Fatalf("Failed to reconcile\n%v", err)
+		}
+
+		actualState, _ := testRepo.GetState()
+		strategy := actualState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.OpenOffers) != 1 || strategy.OpenOffers[0].OrderId != "grid-order-id" {
+			t.Errorf("Expected the grid order to be left to the grid, open: %+v", strategy.OpenOffers)
+		}
+
+		if len(strategy.ClosedOffers) != 1 || strategy.ClosedOffers[0].OrderId != "sell-order-id" {
+			t.Errorf("Expected the sell to be closed, closed: %+v", strategy.ClosedOffers)
+		}
+	})
+}
@@ -3,41 +3,66 @@ package server
 import (
 	"log"
 	"net/http"
+	"time"
 
-	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/config"
+	"github.com/iPopcorn/investment-manager/server/exchange"
 	"github.com/iPopcorn/investment-manager/server/handlers"
+	"github.com/iPopcorn/investment-manager/server/journal"
 	"github.com/iPopcorn/investment-manager/server/server_utils"
 	"github.com/iPopcorn/investment-manager/server/state"
 	"github.com/iPopcorn/investment-manager/types"
 )
 
 type InvestmentManagerHTTPServer struct {
-	client          infrastructure.InvestmentManagerExternalHttpClient
-	stateRepository *state.StateRepository
-	channels        []chan bool
+	exchange          exchange.Exchange
+	stateRepository   state.Repository
+	journal           *journal.Journal
+	channels          []chan bool
+	allowedCurrencies []string
+	orderSettings     types.OrderSettings
 }
 
 type InvestmentManagerHTTPServerArgs struct {
-	HttpClient      *infrastructure.InvestmentManagerExternalHttpClient
-	StateRepository *state.StateRepository
-	Channels        []chan bool
+	Exchange          exchange.Exchange
+	StateRepository   state.Repository
+	Journal           *journal.Journal // Orders are journaled by the exchange, the server adds strategy decisions
+	Channels          []chan bool
+	AllowedCurrencies []string            // Currencies strategies can trade, any currency with a product when empty
+	OrderSettings     types.OrderSettings // Commission rates and expiry strategies place orders with, defaults when zero
 }
 
 func GetDefaultInvestmentManagerHTTPServer() *InvestmentManagerHTTPServer {
-	httpClient := infrastructure.GetInvestmentManagerExternalHttpClient()
+	coinbaseExchange := exchange.GetDefaultCoinbaseExchange()
 	stateRepo := state.StateRepositoryFactory("")
+	orderJournal := journal.JournalFactory("")
+	var allowedCurrencies []string
+	var orderSettings types.OrderSettings
+	productCacheTTL := time.Hour
+
+	if cfg, err := config.GetConfig(); err == nil {
+		allowedCurrencies = cfg.AllowedCurrencies
+		orderSettings = cfg.GetOrderSettings()
+		productCacheTTL = cfg.ProductCacheTTL
+	}
 
 	return &InvestmentManagerHTTPServer{
-		client:          *httpClient,
-		stateRepository: stateRepo,
+		exchange:          exchange.JournaledExchangeFactory(exchange.ProductCatalogueFactory(coinbaseExchange, productCacheTTL), orderJournal),
+		stateRepository:   stateRepo,
+		journal:           orderJournal,
+		allowedCurrencies: allowedCurrencies,
+		orderSettings:     orderSettings,
 	}
 }
 
 func InvestmentManagerHttpServerFactory(args InvestmentManagerHTTPServerArgs) *InvestmentManagerHTTPServer {
 	return &InvestmentManagerHTTPServer{
-		client:          *args.HttpClient,
-		stateRepository: args.StateRepository,
-		channels:        args.Channels,
+		exchange:          args.Exchange,
+		stateRepository:   args.StateRepository,
+		journal:           args.Journal,
+		channels:          args.Channels,
+		allowedCurrencies: args.AllowedCurrencies,
+		orderSettings:     args.OrderSettings,
 	}
 }
 
@@ -50,10 +75,11 @@ func (s *InvestmentManagerHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.R
 
 	case string(types.Portfolios):
 		handlePortfolioArgs := handlers.HandlePortfolioArgs{
-			Client: &s.client,
-			Writer: w,
-			Req:    r,
-			Args:   args,
+			Exchange:        s.exchange,
+			Writer:          w,
+			Req:             r,
+			Args:            args,
+			StateRepository: s.stateRepository,
 		}
 
 		handlers.HandlePortfolio(handlePortfolioArgs)
@@ -61,12 +87,15 @@ func (s *InvestmentManagerHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.R
 
 	case string(types.ExecuteStrategy):
 		executeStrategyArgs := handlers.HandleExecuteStrategyArgs{
-			Client:          &s.client,
-			Writer:          w,
-			Req:             r,
-			Args:            args,
-			Channels:        s.channels,
-			StateRepository: s.stateRepository,
+			Exchange:          s.exchange,
+			Writer:            w,
+			Req:               r,
+			Args:              args,
+			Channels:          s.channels,
+			StateRepository:   s.stateRepository,
+			Journal:           s.journal,
+			AllowedCurrencies: s.allowedCurrencies,
+			OrderSettings:     s.orderSettings,
 		}
 
 		handlers.HandleExecuteStrategy(executeStrategyArgs)
@@ -74,14 +103,36 @@ func (s *InvestmentManagerHTTPServer) ServeHTTP(w http.ResponseWriter, r *http.R
 
 	case string(types.TransferFunds):
 		handleTransferFundsArgs := handlers.HandleTransferFundsArgs{
-			Client: &s.client,
-			Writer: w,
-			Req:    r,
+			Exchange: s.exchange,
+			Writer:   w,
+			Req:      r,
 		}
 
 		handlers.HandleTransferFunds(handleTransferFundsArgs)
 		return
 
+	case string(types.Orders):
+		handleOrdersArgs := handlers.HandleOrdersArgs{
+			Exchange:        s.exchange,
+			Writer:          w,
+			Req:             r,
+			Args:            args,
+			StateRepository: s.stateRepository,
+		}
+
+		handlers.HandleOrders(handleOrdersArgs)
+		return
+
+	case string(types.Journal):
+		handleJournalArgs := handlers.HandleJournalArgs{
+			Writer:  w,
+			Req:     r,
+			Journal: s.journal,
+		}
+
+		handlers.HandleJournal(handleJournalArgs)
+		return
+
 	default:
 		log.Printf("Route not found: %q\n", route)
 		w.WriteHeader(http.StatusNotFound)
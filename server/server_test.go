@@ -3,63 +3,186 @@ package server
 import (
 	"bytes"
 	"encoding/json"
-	"io"
+	"fmt"
+	"log"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"strings"
 	"testing"
 	"time"
 
-	"github.com/iPopcorn/investment-manager/infrastructure"
-	"github.com/iPopcorn/investment-manager/server/server_utils"
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/server/handlers"
+	"github.com/iPopcorn/investment-manager/server/journal"
 	"github.com/iPopcorn/investment-manager/server/state"
 	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
 )
 
-type testHttpClient struct {
-	getResponseMap map[string][]byte
+type testExchange struct {
+	portfolios        *types.PortfolioResponse
+	portfolioDetails  map[string]*types.PortfolioDetailsResponse
+	products          *types.ProductResponse
+	bestBidAsk        *types.BestBidAskResponse
+	orderPlaced       *types.CoinbaseOrderPlacedResponse
+	orderPreview      *types.CoinbaseOrderPreviewResponse
+	transfer          *types.TransferFundsResponse
+	orderStatuses     []types.OrderStatus // Status reported by each call to GetOrder, the last one repeats
+	placedOffers      []*types.Offer
+	openOrders        []types.Order
+	cancelledOrderIDs []string
 }
 
-type testReader struct {
-	data []byte
+type testServerArgs struct {
+	exchange          *testExchange
+	mockRepo          state.Repository
+	chans             []chan bool
+	allowedCurrencies []string
+	journal           *journal.Journal // Orders and transfers are journaled when set
+	orderSettings     types.OrderSettings
 }
 
-type testServerArgs struct {
-	expectedResponseMap map[string][]byte
-	mockRepo            *state.StateRepository
-	chans               []chan bool
+const testStateFilename = "test-execute-strategy-state.json"
+
+var errNoTestResponse = fmt.Errorf("no response configured for test exchange")
+
+func (e *testExchange) ListPortfolios() (*types.PortfolioResponse, error) {
+	if e.portfolios == nil {
+		return nil, errNoTestResponse
+	}
+
+	return e.portfolios, nil
+}
+
+func (e *testExchange) CreatePortfolio(name string) (*types.PortfolioCreatedResponse, error) {
+	return &types.PortfolioCreatedResponse{Portfolio: types.Portfolio{Name: name}}, nil
+}
+
+func (e *testExchange) PortfolioDetails(portfolioID string) (*types.PortfolioDetailsResponse, error) {
+	details, ok := e.portfolioDetails[portfolioID]
+
+	if !ok {
+		return nil, errNoTestResponse
+	}
+
+	return details, nil
+}
+
+func (e *testExchange) ListProducts(productIDs []string) (*types.ProductResponse, error) {
+	if e.products == nil {
+		return nil, errNoTestResponse
+	}
+
+	return e.products, nil
+}
+
+func (e *testExchange) GetBestBidAsk(productID string) (*types.BestBidAskResponse, error) {
+	if e.bestBidAsk == nil {
+		return nil, errNoTestResponse
+	}
+
+	return e.bestBidAsk, nil
+}
+
+func (e *testExchange) PlaceOrder(offer *types.Offer) (*types.CoinbaseOrderPlacedResponse, error) {
+	if e.orderPlaced == nil {
+		return nil, errNoTestResponse
+	}
+
+	e.placedOffers = append(e.placedOffers, offer)
+
+	return e.orderPlaced, nil
+}
+
+func (e *testExchange) PreviewOrder(offer *types.Offer) (*types.CoinbaseOrderPreviewResponse, error) {
+	if e.orderPreview == nil {
+		return nil, errNoTestResponse
+	}
+
+	return e.orderPreview, nil
+}
+
+func (e *testExchange) GetOrder(orderID string) (*types.Order, error) {
+	if len(e.orderStatuses) == 0 {
+		return nil, errNoTestResponse
+	}
+
+	status := e.orderStatuses[0]
+
+	if len(e.orderStatuses) > 1 {
+		e.orderStatuses = e.orderStatuses[1:]
+	}
+
+	return &types.Order{
+		OrderID:            orderID,
+		Status:             status,
+		FilledSize:         types.MustParseDecimal("0.04"),
+		AverageFilledPrice: types.MustParseDecimal("2349.55"),
+		TotalFees:          types.MustParseDecimal("0.4"),
+	}, nil
+}
+
+func (e *testExchange) ListOpenOrders(portfolioID string) ([]types.Order, error) {
+	return e.openOrders, nil
 }
 
-func (testClient testHttpClient) Do(req *http.Request) (*http.Response, error) {
-	_, args := server_utils.GetRouteAndArgsFromPath(req.URL.Path)
+func (e *testExchange) CancelOrders(orderIDs []string) (*types.CancelOrdersResponse, error) {
+	resp := &types.CancelOrdersResponse{}
+
+	for _, orderID := range orderIDs {
+		resp.Results = append(resp.Results, types.CancelOrderResult{Success: true, OrderID: orderID})
+	}
+
+	e.cancelledOrderIDs = append(e.cancelledOrderIDs, orderIDs...)
 
-	index := len(args) - 1
-	key := args[index]
-	responseData := testClient.getResponseMap[key]
+	return resp, nil
+}
 
-	resp := http.Response{
-		Body: io.NopCloser(bytes.NewReader(responseData)),
+func (e *testExchange) TransferFunds(req *types.TransferRequest) (*types.TransferFundsResponse, error) {
+	if e.transfer == nil {
+		return nil, errNoTestResponse
 	}
 
-	return &resp, nil
+	return e.transfer, nil
 }
 
 func getTestServer(args *testServerArgs) *InvestmentManagerHTTPServer {
-	testHttpClient := testHttpClient{
-		getResponseMap: args.expectedResponseMap,
+	var ex exchange.Exchange = args.exchange
+
+	if args.exchange == nil {
+		ex = &testExchange{}
 	}
-	testInvestmentManagerHTTPClient := infrastructure.InvestmentManagerExternalHttpClient{
-		HttpClient: testHttpClient,
+
+	if args.journal != nil {
+		ex = exchange.JournaledExchangeFactory(ex, args.journal)
 	}
 
 	serverArgs := InvestmentManagerHTTPServerArgs{
-		HttpClient:      &testInvestmentManagerHTTPClient,
-		StateRepository: args.mockRepo,
-		Channels:        args.chans,
+		Exchange:          ex,
+		StateRepository:   args.mockRepo,
+		Journal:           args.journal,
+		Channels:          args.chans,
+		AllowedCurrencies: args.allowedCurrencies,
+		OrderSettings:     args.orderSettings,
 	}
 
 	return InvestmentManagerHttpServerFactory(serverArgs)
 }
 
+func TestMain(m *testing.M) {
+	dataDir, err := os.MkdirTemp("", "investment-manager-test-")
+
+	if err != nil {
+		log.Fatalf("Failed to create data directory\n%v\n", err)
+	}
+
+	util.SetDataDir(dataDir)
+	code := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(code)
+}
+
 func TestGETPortfolios(t *testing.T) {
 	t.Run("Gets user's portfolios from coinbase", func(t *testing.T) {
 		portfolio1 := types.Portfolio{
@@ -78,19 +201,13 @@ func TestGETPortfolios(t *testing.T) {
 			Portfolios: []types.Portfolio{portfolio1, portfolio2},
 		}
 
-		serializedExpectedResponse, err := json.Marshal(expectedResponse)
-		if err != nil {
-			t.Fatalf("Failed to serialize expected response\n%v", err)
-		}
-
 		request, _ := http.NewRequest(http.MethodGet, "/portfolios", nil)
 		response := httptest.NewRecorder()
 
-		responseMap := make(map[string][]byte)
-		responseMap["portfolios"] = serializedExpectedResponse
-
 		testServerArgs := &testServerArgs{
-			expectedResponseMap: responseMap,
+			exchange: &testExchange{
+				portfolios: expectedResponse,
+			},
 		}
 
 		server := getTestServer(testServerArgs)
@@ -146,6 +263,11 @@ func TestGETPortfolios(t *testing.T) {
 }
 
 func TestExecuteStrategy(t *testing.T) {
+	t.Cleanup(func() {
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
 	t.Run("Executes the HODL strategy", func(t *testing.T) {
 		// Arrange
 		/*
@@ -154,128 +276,20 @@ func TestExecuteStrategy(t *testing.T) {
 				portfolios: []
 			}
 		*/
-		testPortfolio := &types.Portfolio{
-			Name:               "test",
-			Uuid:               "test-portfolio-id",
-			Type:               "test",
-			Deleted:            false,
-			CurrentStrategy:    nil,
-			PreviousStrategies: nil,
-		}
-
-		testPortfolioResponse := &types.PortfolioResponse{
-			Portfolios: []types.Portfolio{*testPortfolio},
-		}
-
-		testZeroBalance := types.Balance{
-			Value:    "0",
-			Currency: "GBP",
-		}
-
-		testPortfolioDetailsResponse := &types.PortfolioDetailsResponse{
-			Breakdown: types.Breakdown{
-				Portfolio: *testPortfolio,
-				PortfolioBalances: types.PortfolioBalances{
-					TotalBalance:               testZeroBalance,
-					TotalFuturesBalance:        testZeroBalance,
-					TotalCashEquivalentBalance: testZeroBalance,
-					TotalCryptoBalance:         testZeroBalance,
-					FuturesUnrealizedPnl:       testZeroBalance,
-					PerpUnrealizedPnl:          testZeroBalance,
-				},
-				SpotPositions: []types.SpotPositions{
-					{
-						Asset:                "GBP",
-						AccountUuid:          "test-account-uuid",
-						TotalBalanceFiat:     0,
-						TotalBalanceCrypto:   0,
-						AvailableToTradeFiat: 0,
-						Allocation:           0,
-						CostBasis:            testZeroBalance,
-						AssetImgUrl:          "",
-						IsCash:               true,
-					},
-				},
-			},
-		}
-
-		testProductResponse := &types.ProductResponse{
-			Products: []types.Product{
-				{
-					ProductID: "ETH-GBP",
-					Price:     "10",
-				},
-			},
-		}
-
-		testBestBidAskResponse := &types.BestBidAskResponse{
-			PriceBooks: []types.PriceBook{
-				{
-					ProductID: "ETH-GBP",
-					Bids: []types.Bid{
-						{
-							Price: "2349.55",
-							Size:  "0.0675",
-						},
-					},
-					Asks: []types.Bid{
-						{
-							Price: "2350.99",
-							Size:  "0.05",
-						},
-					},
-					Time: "2024-05-01T20:07:23.044653Z",
-				},
-			},
-		}
-
-		serializedPortfolioResponse, err := json.Marshal(testPortfolioResponse)
-		if err != nil {
-			t.Fatalf("Failed to create mock response for test server\n%v", err)
-		}
-
-		serializedPortfolioDetailsResponse, err := json.Marshal(testPortfolioDetailsResponse)
-
-		if err != nil {
-			t.Fatalf("Failed to create mock response for test server\n%v", err)
-		}
-
-		serializedTestProductResponse, err := json.Marshal(testProductResponse)
-
-		if err != nil {
-			t.Fatalf("Failed to create mock test product response for test server\n%v", err)
-		}
-
-		serializedBestBidAskResponse, err := json.Marshal(testBestBidAskResponse)
-
-		tradeSuccessResponse := make(map[string]string)
-		tradeSuccessResponse["order_total"] = "100"
-		serializedTestSuccessResponse, err := json.Marshal(tradeSuccessResponse)
-
-		if err != nil {
-			t.Fatalf("Failed to serialize data. Given: %+v\n%v\n", tradeSuccessResponse, err)
-		}
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
 
 		timeStart := time.Now()
 		formattedTimeStart := timeStart.Format(time.RFC3339)
 
-		testStateRepo := state.StateRepositoryFactory("test-state.json")
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
 
 		strategyExecutedChannel := make(chan bool)
 		testChans := []chan bool{strategyExecutedChannel}
 
-		responseMap := make(map[string][]byte)
-		responseMap["portfolios"] = serializedPortfolioResponse
-		responseMap["test-portfolio-id"] = serializedPortfolioDetailsResponse
-		responseMap["products"] = serializedTestProductResponse
-		responseMap["best_bid_ask"] = serializedBestBidAskResponse
-		responseMap["preview"] = serializedTestSuccessResponse
-		responseMap["orders"] = serializedTestSuccessResponse
-
 		testServerArgs := &testServerArgs{
-			expectedResponseMap: responseMap,
-			mockRepo:            testStateRepo,
-			chans:               testChans,
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    testChans,
 		}
 
 		testServer := getTestServer(testServerArgs)
@@ -389,24 +403,20 @@ func TestExecuteStrategy(t *testing.T) {
 		assertStringEquals("HODL", string(actualCurrentStrategy.Name), t)
 		assertStringEquals("ETH", string(actualCurrentStrategy.Currency), t)
 
-		if actualCurrentStrategy.OpenOffers == nil {
-			t.Fatalf(unexpectedUpdate + "no open offers found")
-		}
-
-		if actualCurrentStrategy.ClosedOffers != nil {
-			t.Errorf(unexpectedUpdate + "found closed offers when none expected")
+		if len(actualCurrentStrategy.OpenOffers) != 0 {
+			t.Errorf(unexpectedUpdate+"expected no open offers but found %d", len(actualCurrentStrategy.OpenOffers))
 		}
 
-		// Place a buy order for the given currency
-		if len(actualCurrentStrategy.OpenOffers) < 1 {
-			t.Fatalf(unexpectedUpdate + "open offers is empty")
+		// Place a buy order for the given currency and wait for it to be filled
+		if len(actualCurrentStrategy.ClosedOffers) < 1 {
+			t.Fatalf(unexpectedUpdate + "closed offers is empty")
 		}
 
-		if len(actualCurrentStrategy.OpenOffers) > 1 {
-			t.Errorf(unexpectedUpdate+"expected 1 open offer but found %d", len(actualCurrentStrategy.OpenOffers))
+		if len(actualCurrentStrategy.ClosedOffers) > 1 {
+			t.Errorf(unexpectedUpdate+"expected 1 closed offer but found %d", len(actualCurrentStrategy.ClosedOffers))
 		}
 
-		actualOpenOffer := actualCurrentStrategy.OpenOffers[0]
+		actualOpenOffer := actualCurrentStrategy.ClosedOffers[0]
 
 		if actualOpenOffer.ClientOrderId == "" {
 			t.Errorf(unexpectedUpdate + "client order id is empty")
@@ -414,14 +424,17 @@ func TestExecuteStrategy(t *testing.T) {
 
 		assertStringEquals("ETH-GBP", actualOpenOffer.ProductId, t)
 		assertStringEquals(string(types.BUY), string(actualOpenOffer.Side), t)
+		assertStringEquals("test-order-id", actualOpenOffer.OrderId, t)
+		assertStringEquals(string(types.FILLED), string(actualOpenOffer.Status), t)
+		assertStringEquals("0.04", actualOpenOffer.FilledSize.String(), t)
 
 		offerConfig := actualOpenOffer.Config
 
-		if offerConfig.LimitLimitGTD.BaseSize == "" {
+		if offerConfig.LimitLimitGTD.BaseSize.IsZero() {
 			t.Errorf(unexpectedUpdate + "base size is empty")
 		}
 
-		if offerConfig.LimitLimitGTD.LimitPrice == "" {
+		if offerConfig.LimitLimitGTD.LimitPrice.IsZero() {
 			t.Errorf(unexpectedUpdate + "limit price is empty")
 		}
 
@@ -432,132 +445,81 @@ func TestExecuteStrategy(t *testing.T) {
 		expectedEndTime := timeStart.Add(time.Minute * 5).Format(time.RFC3339)
 		assertStringEquals(expectedEndTime, offerConfig.LimitLimitGTD.EndTime, t)
 
-		// TODO: Match the current best bid
-		// TODO: Add the transaction to the state
-		// TODO: wait for the offer to be fulfilled
-		// TODO: update the state
 	})
-}
 
-func TestTransferFunds(t *testing.T) {
-	setup := func(senderAvailableToTradeFiat float64, t *testing.T) (senderID, receiverID string, testServer *InvestmentManagerHTTPServer) {
-		t.Helper()
-		senderPortfolioID := "test-sender-portfolio-id"
-		receiverPortfolioID := "test-receiver-portfolio-id"
-		testSenderPortfolio := &types.Portfolio{
-			Name:               "test-sender",
-			Uuid:               senderPortfolioID,
-			Type:               "test",
-			Deleted:            false,
-			CurrentStrategy:    nil,
-			PreviousStrategies: nil,
-		}
-		testReceiverPortfolio := &types.Portfolio{
-			Name:               "test-receiver",
-			Uuid:               receiverPortfolioID,
-			Type:               "test",
-			Deleted:            false,
-			CurrentStrategy:    nil,
-			PreviousStrategies: nil,
-		}
-		testZeroBalance := types.Balance{
-			Value:    "0",
-			Currency: "GBP",
-		}
+	t.Run("Re-prices expired HODL orders", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.EXPIRED, types.EXPIRED, types.FILLED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
 
-		testSenderPortfolioDetailsResponse := &types.PortfolioDetailsResponse{
-			Breakdown: types.Breakdown{
-				Portfolio: *testSenderPortfolio,
-				PortfolioBalances: types.PortfolioBalances{
-					TotalBalance:               testZeroBalance,
-					TotalFuturesBalance:        testZeroBalance,
-					TotalCashEquivalentBalance: testZeroBalance,
-					TotalCryptoBalance:         testZeroBalance,
-					FuturesUnrealizedPnl:       testZeroBalance,
-					PerpUnrealizedPnl:          testZeroBalance,
-				},
-				SpotPositions: []types.SpotPositions{
-					{
-						Asset:                "GBP",
-						AccountUuid:          "test-account-uuid",
-						TotalBalanceFiat:     0,
-						TotalBalanceCrypto:   0,
-						AvailableToTradeFiat: senderAvailableToTradeFiat,
-						Allocation:           0,
-						CostBasis:            testZeroBalance,
-						AssetImgUrl:          "",
-						IsCash:               true,
-					},
-				},
-			},
-		}
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
 
-		testReceiverPortfolioDetailsResponse := &types.PortfolioDetailsResponse{
-			Breakdown: types.Breakdown{
-				Portfolio: *testReceiverPortfolio,
-				PortfolioBalances: types.PortfolioBalances{
-					TotalBalance:               testZeroBalance,
-					TotalFuturesBalance:        testZeroBalance,
-					TotalCashEquivalentBalance: testZeroBalance,
-					TotalCryptoBalance:         testZeroBalance,
-					FuturesUnrealizedPnl:       testZeroBalance,
-					PerpUnrealizedPnl:          testZeroBalance,
-				},
-				SpotPositions: []types.SpotPositions{
-					{
-						Asset:                "GBP",
-						AccountUuid:          "test-account-uuid",
-						TotalBalanceFiat:     0,
-						TotalBalanceCrypto:   0,
-						AvailableToTradeFiat: 0,
-						Allocation:           0,
-						CostBasis:            testZeroBalance,
-						AssetImgUrl:          "",
-						IsCash:               true,
-					},
-				},
-			},
+		body := types.ExecuteStrategyRequest{
+			Portfolio:   testPortfolio.Name,
+			Strategy:    "HODL",
+			Currency:    "ETH",
+			MaxAttempts: 5,
 		}
 
-		transferFundsSuccessResponse := make(map[string]string)
-		transferFundsSuccessResponse["source_portfolio_uuid"] = senderPortfolioID
-		transferFundsSuccessResponse["target_portfolio_uuid"] = receiverPortfolioID
+		serializedBody, err := json.Marshal(body)
 
-		serializedTransferFundsSuccessResponse, err := json.Marshal(transferFundsSuccessResponse)
 		if err != nil {
-			t.Fatalf("Failed to serialize data\nGiven: %+v\n%v\n", transferFundsSuccessResponse, err)
+			t.Fatalf("Failed to create body for request\n%v", err)
 		}
 
-		serializedTestSenderPortfolioDetailsResponse, err := json.Marshal(testSenderPortfolioDetailsResponse)
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
 		if err != nil {
-			t.Fatalf("Failed to serialize data\nGiven: %+v\n%v\n", testSenderPortfolioDetailsResponse, err)
+			t.Fatalf("Failed to create http request\n%v", err)
 		}
 
-		serializedTestReceiverPortfolioDetailsResponse, err := json.Marshal(testReceiverPortfolioDetailsResponse)
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
 		if err != nil {
-			t.Fatalf("Failed to serialize data\nGiven: %+v\n%v\n", testReceiverPortfolioDetailsResponse, err)
+			t.Fatalf("Failed to retrieve state from repository")
 		}
 
-		responseMap := make(map[string][]byte)
-		responseMap[senderPortfolioID] = serializedTestSenderPortfolioDetailsResponse
-		responseMap[receiverPortfolioID] = serializedTestReceiverPortfolioDetailsResponse
-		responseMap["move_funds"] = serializedTransferFundsSuccessResponse
+		strategy := updatedState.Portfolios[0].CurrentStrategy
 
-		testServerArgs := &testServerArgs{
-			expectedResponseMap: responseMap,
-			mockRepo:            nil,
-			chans:               nil,
+		if len(strategy.OpenOffers) != 0 {
+			t.Errorf("Expected no open offers but found %d", len(strategy.OpenOffers))
 		}
 
-		return senderPortfolioID, receiverPortfolioID, getTestServer(testServerArgs)
-	}
-	t.Run("Fails to transfer if not enough funds", func(t *testing.T) {
+		if len(strategy.ClosedOffers) != 3 {
+			t.Fatalf("Expected 3 closed offers but found %d", len(strategy.ClosedOffers))
+		}
+
+		assertStringEquals(string(types.EXPIRED), string(strategy.ClosedOffers[0].Status), t)
+		assertStringEquals(string(types.EXPIRED), string(strategy.ClosedOffers[1].Status), t)
+		assertStringEquals(string(types.FILLED), string(strategy.ClosedOffers[2].Status), t)
+	})
+
+	t.Run("Places orders with the configured expiry", func(t *testing.T) {
 		// Arrange
-		senderID, receiverID, testServer := setup(0, t)
-		body := types.TransferRequest{
-			SenderID:   senderID,
-			ReceiverID: receiverID,
-			Amount:     "10",
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange:      testExchange,
+			mockRepo:      testStateRepo,
+			chans:         []chan bool{strategyExecutedChannel},
+			orderSettings: types.OrderSettings{OrderExpiry: time.Minute * 30},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "HODL",
+			Currency:  "ETH",
 		}
 
 		serializedBody, err := json.Marshal(body)
@@ -566,30 +528,50 @@ func TestTransferFunds(t *testing.T) {
 			t.Fatalf("Failed to create body for request\n%v", err)
 		}
 
-		request, err := http.NewRequest(http.MethodPost, "/"+string(types.TransferFunds), bytes.NewReader(serializedBody))
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
 
 		if err != nil {
 			t.Fatalf("Failed to create http request\n%v", err)
 		}
 
-		response := httptest.NewRecorder()
-
 		// Act
-		testServer.ServeHTTP(response, request)
+		timeStart := time.Now()
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
 
 		// Assert
-		if response.Code != http.StatusBadRequest {
-			t.Fatalf("Expected %d Received %d", http.StatusBadRequest, response.Code)
+		if len(testExchange.placedOffers) != 1 {
+			t.Fatalf("Expected 1 order to be placed but found %d", len(testExchange.placedOffers))
+		}
+
+		endTime, err := time.Parse(time.RFC3339, testExchange.placedOffers[0].Config.LimitLimitGTD.EndTime)
+
+		if err != nil {
+			t.Fatalf("Invalid end time\n%v", err)
+		}
+
+		if endTime.Before(timeStart.Add(time.Minute*29)) || endTime.After(time.Now().Add(time.Minute*30)) {
+			t.Errorf("Expected the order to expire in 30 minutes, expires at %s", endTime)
 		}
 	})
 
-	t.Run("Transfers funds as expected", func(t *testing.T) {
+	t.Run("Stops re-pricing after max attempts", func(t *testing.T) {
 		// Arrange
-		senderID, receiverID, testServer := setup(20, t)
-		body := types.TransferRequest{
-			SenderID:   senderID,
-			ReceiverID: receiverID,
-			Amount:     "10",
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.EXPIRED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio:   testPortfolio.Name,
+			Strategy:    "HODL",
+			Currency:    "ETH",
+			MaxAttempts: 2,
 		}
 
 		serializedBody, err := json.Marshal(body)
@@ -598,14 +580,1791 @@ func TestTransferFunds(t *testing.T) {
 			t.Fatalf("Failed to create body for request\n%v", err)
 		}
 
-		request, err := http.NewRequest(http.MethodPost, "/"+string(types.TransferFunds), bytes.NewReader(serializedBody))
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
 
 		if err != nil {
 			t.Fatalf("Failed to create http request\n%v", err)
 		}
 
-		response := httptest.NewRecorder()
-
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.ClosedOffers) != 2 {
+			t.Fatalf("Expected 2 closed offers but found %d", len(strategy.ClosedOffers))
+		}
+	})
+
+	t.Run("Executes HODL with a market order", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "HODL",
+			Currency:  "ETH",
+			OrderType: types.MarketOrder,
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		config := updatedState.Portfolios[0].CurrentStrategy.ClosedOffers[0].Config
+
+		if config.MarketMarketIOC == nil || config.LimitLimitGTD != nil {
+			t.Fatalf("Expected only a market order configuration, got %+v", config)
+		}
+
+		assertStringEquals("99.39", config.MarketMarketIOC.QuoteSize.String(), t)
+	})
+
+	t.Run("Executes HODL with the portfolio's quote currency", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testExchange.portfolioDetails[testPortfolio.Uuid].Breakdown.SpotPositions[0].Asset = "USD"
+		testExchange.products.Products[0].ProductID = "ETH-USD"
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "HODL",
+			Currency:  "ETH",
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.ClosedOffers) != 1 {
+			t.Fatalf("Expected 1 closed offer but found %d", len(strategy.ClosedOffers))
+		}
+
+		assertStringEquals("USD", strategy.QuoteCurrency, t)
+		assertStringEquals("ETH-USD", strategy.ClosedOffers[0].ProductId, t)
+	})
+
+	t.Run("Splits a HODL buy into TWAP slices", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "HODL",
+			Currency:  "ETH",
+			TWAP: &types.TWAPPlan{
+				Slices:   2,
+				Duration: "2ms",
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.ClosedOffers) != 2 {
+			t.Fatalf("Expected 2 closed offers but found %d", len(strategy.ClosedOffers))
+		}
+
+		// Half of the 0.04239109 ETH that 100 GBP buys after commission, rounded down to the base increment
+		assertStringEquals("0.02119554", strategy.ClosedOffers[0].Config.LimitLimitGTD.BaseSize.String(), t)
+
+		plan := strategy.TWAP
+
+		if plan == nil || plan.ParentId == "" || plan.SlicesCompleted != 2 {
+			t.Fatalf("Expected 2 completed TWAP slices, got %+v", plan)
+		}
+
+		// Saved so the plan can be resumed
+		assertStringEquals("ETH-GBP", plan.ProductID, t)
+
+		for _, offer := range strategy.ClosedOffers {
+			assertStringEquals(plan.ParentId, offer.ParentId, t)
+		}
+	})
+
+	t.Run("Executes the DCA strategy on schedule", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "DCA",
+			Currency:  "ETH",
+			DCA: &types.DCAPlan{
+				AmountPerBuy: types.NewDecimalFromInt(40),
+				Interval:     "1ms",
+				NumberOfBuys: 2,
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.ClosedOffers) != 2 {
+			t.Fatalf("Expected 2 closed offers but found %d", len(strategy.ClosedOffers))
+		}
+
+		assertStringEquals("0.01695643", strategy.ClosedOffers[0].Config.LimitLimitGTD.BaseSize.String(), t)
+
+		plan := strategy.DCA
+
+		if plan == nil || plan.BuysCompleted != 2 || !plan.IsComplete() {
+			t.Fatalf("Expected DCA plan to be complete after 2 buys, got %+v", plan)
+		}
+
+		assertStringEquals("ETH-GBP", plan.ProductID, t)
+	})
+
+	t.Run("Stops re-pricing a DCA buy once its amount is spent", func(t *testing.T) {
+		// Arrange
+		// Each expired order reports fills worth more than the amount per buy
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.EXPIRED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio:   testPortfolio.Name,
+			Strategy:    "DCA",
+			Currency:    "ETH",
+			MaxAttempts: 3,
+			DCA: &types.DCAPlan{
+				AmountPerBuy: types.NewDecimalFromInt(40),
+				Interval:     "1ms",
+				NumberOfBuys: 1,
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		if len(testExchange.placedOffers) != 1 {
+			t.Fatalf("Expected 1 order for the buy but found %d", len(testExchange.placedOffers))
+		}
+
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		plan := updatedState.Portfolios[0].CurrentStrategy.DCA
+
+		if plan == nil || plan.BuysCompleted != 1 || !plan.IsComplete() {
+			t.Fatalf("Expected DCA plan to be complete after 1 buy, got %+v", plan)
+		}
+	})
+
+	t.Run("Stops a DCA plan after buys in a row spend nothing", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testExchange.portfolioDetails[testPortfolio.Uuid].Breakdown.SpotPositions[0].AvailableToTradeFiat = types.NewDecimalFromInt(0)
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "DCA",
+			Currency:  "ETH",
+			DCA: &types.DCAPlan{
+				AmountPerBuy: types.NewDecimalFromInt(40),
+				Interval:     "1ms",
+				NumberOfBuys: 2,
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		plan := updatedState.Portfolios[0].CurrentStrategy.DCA
+
+		if plan == nil || plan.BuysCompleted != 0 || plan.SkippedBuys != types.MaxSkippedBuys || !plan.IsStopped() {
+			t.Fatalf("Expected DCA plan to stop after %d buys spent nothing, got %+v", types.MaxSkippedBuys, plan)
+		}
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders without fiat, placed %d", len(testExchange.placedOffers))
+		}
+	})
+
+	t.Run("Rejects DCA without a budget or number of buys", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: state.StateRepositoryFactory(testStateFilename),
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "DCA",
+			Currency:  "ETH",
+			DCA: &types.DCAPlan{
+				AmountPerBuy: types.NewDecimalFromInt(40),
+				Interval:     "24h",
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d but got %d", http.StatusBadRequest, recorder.Code)
+		}
+
+		var response types.ErrorResponse
+		json.Unmarshal(recorder.Body.Bytes(), &response)
+
+		if !strings.Contains(response.Error, "Invalid DCA plan") {
+			t.Errorf("Expected the response to say why the plan is invalid\nActual: %q", recorder.Body.String())
+		}
+	})
+
+	t.Run("Rejects currencies outside the allow-list or without a product", func(t *testing.T) {
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+
+		testServer := getTestServer(&testServerArgs{
+			exchange:          testExchange,
+			mockRepo:          state.StateRepositoryFactory(testStateFilename),
+			allowedCurrencies: []string{"BTC", "SOL"},
+		})
+
+		// ETH has a product but isn't allowed, SOL is allowed but has no product
+		for _, currency := range []types.SupportedCurrency{"ETH", "sol"} {
+			// Arrange
+			body := types.ExecuteStrategyRequest{
+				Portfolio: testPortfolio.Name,
+				Strategy:  "HODL",
+				Currency:  currency,
+			}
+
+			serializedBody, err := json.Marshal(body)
+
+			if err != nil {
+				t.Fatalf("Failed to create body for request\n%v", err)
+			}
+
+			request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+			if err != nil {
+				t.Fatalf("Failed to create http request\n%v", err)
+			}
+
+			recorder := httptest.NewRecorder()
+
+			// Act
+			testServer.ServeHTTP(recorder, request)
+
+			// Assert
+			if recorder.Code != http.StatusBadRequest {
+				t.Errorf("%s: Expected status %d but got %d", currency, http.StatusBadRequest, recorder.Code)
+			}
+		}
+	})
+
+	t.Run("Refuses to replace a strategy that has open offers", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		testState := testStateRepo.InitState()
+		testState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				CurrentStrategy: &types.Strategy{
+					Id:         "test-strategy-id",
+					Name:       types.GRID,
+					Currency:   "ETH",
+					OpenOffers: []types.Offer{{OrderId: "grid-order-id", Side: types.BUY, GridLevel: 1}},
+				},
+			},
+		}
+
+		err := testStateRepo.Save(*testState)
+
+		if err != nil {
+			t.Fatalf("Failed to save state\n%v", err)
+		}
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "HODL",
+			Currency:  "ETH",
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusConflict {
+			t.Errorf("Expected status %d but got %d", http.StatusConflict, recorder.Code)
+		}
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders to be placed, placed %d", len(testExchange.placedOffers))
+		}
+
+		strategy, _ := testStateRepo.GetStrategy(testPortfolio.Uuid)
+
+		if strategy == nil || strategy.Id != "test-strategy-id" {
+			t.Errorf("Expected the grid to stay the current strategy, found %+v", strategy)
+		}
+	})
+}
+
+func TestProtection(t *testing.T) {
+	t.Cleanup(func() {
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
+	setup := func(protection *types.Protection, t *testing.T) (*testExchange, *state.StateRepository) {
+		t.Helper()
+
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		details := testExchange.portfolioDetails[testPortfolio.Uuid]
+		details.Breakdown.SpotPositions = append(details.Breakdown.SpotPositions, types.SpotPositions{
+			Asset:              "ETH",
+			TotalBalanceFiat:   types.MustParseDecimal("234.955"),
+			TotalBalanceCrypto: types.MustParseDecimal("0.1"),
+			CostBasis:          types.Balance{Value: types.MustParseDecimal("300"), Currency: "GBP"},
+		})
+
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		testState := testStateRepo.InitState()
+		testState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				CurrentStrategy: &types.Strategy{
+					Id:         "test-strategy-id",
+					Name:       types.HODL,
+					Currency:   "ETH",
+					OpenOffers: []types.Offer{},
+					Protection: protection,
+				},
+			},
+		}
+
+		err := testStateRepo.Save(*testState)
+
+		if err != nil {
+			t.Fatalf("Failed to save state\n%v", err)
+		}
+
+		return testExchange, testStateRepo
+	}
+
+	t.Run("Sells the position when the stop-loss triggers", func(t *testing.T) {
+		// Arrange
+		testExchange, testStateRepo := setup(&types.Protection{StopLossPercent: 10}, t)
+
+		// Act
+		err := handlers.ProtectionMonitorFactory(testExchange, testStateRepo, types.OrderSettings{}).Check()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Failed to check protection\n%v", err)
+		}
+
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.OpenOffers) != 1 {
+			t.Fatalf("Expected 1 open offer but found %d", len(strategy.OpenOffers))
+		}
+
+		offer := strategy.OpenOffers[0]
+		assertStringEquals(string(types.SELL), string(offer.Side), t)
+		assertStringEquals("0.1", offer.Config.LimitLimitGTD.BaseSize.String(), t)
+		assertStringEquals("2349.55", offer.Config.LimitLimitGTD.LimitPrice.String(), t)
+		assertStringEquals(string(types.StopLoss), string(strategy.Protection.Triggered), t)
+	})
+
+	t.Run("Resets the trigger when the sell fails and sells once on the next check", func(t *testing.T) {
+		// Arrange
+		testExchange, testStateRepo := setup(&types.Protection{StopLossPercent: 10}, t)
+		orderPlaced := testExchange.orderPlaced
+		testExchange.orderPlaced = nil
+		monitor := handlers.ProtectionMonitorFactory(testExchange, testStateRepo, types.OrderSettings{})
+
+		// Act
+		err := monitor.Check()
+
+		if err != nil {
+			t.Fatalf("Failed to check protection\n%v", err)
+		}
+
+		failedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		if failedState.Portfolios[0].CurrentStrategy.Protection.Triggered != "" {
+			t.Fatalf("Expected the trigger to be reset after the sell failed")
+		}
+
+		testExchange.orderPlaced = orderPlaced
+
+		for i := 0; i < 2; i++ {
+			err = monitor.Check()
+
+			if err != nil {
+				t.Fatalf("Failed to check protection\n%v", err)
+			}
+		}
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(testExchange.placedOffers) != 1 || len(strategy.OpenOffers) != 1 {
+			t.Fatalf("Expected a single sell, placed %d and saved %d", len(testExchange.placedOffers), len(strategy.OpenOffers))
+		}
+
+		assertStringEquals(string(types.StopLoss), string(strategy.Protection.Triggered), t)
+	})
+
+	t.Run("Holds the position between the thresholds", func(t *testing.T) {
+		// Arrange
+		testExchange, testStateRepo := setup(&types.Protection{StopLossPercent: 30, TakeProfitPrice: types.NewDecimalFromInt(3500)}, t)
+
+		// Act
+		err := handlers.ProtectionMonitorFactory(testExchange, testStateRepo, types.OrderSettings{}).Check()
+
+		// Assert
+		if err != nil {
+			t.Fatalf("Failed to check protection\n%v", err)
+		}
+
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.OpenOffers) != 0 || strategy.Protection.Triggered != "" {
+			t.Errorf("Expected protection not to trigger, got %+v", strategy)
+		}
+	})
+
+	t.Run("Shows the protection in portfolio details", func(t *testing.T) {
+		// Arrange
+		testExchange, testStateRepo := setup(&types.Protection{StopLossPercent: 30}, t)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+		})
+
+		request, err := http.NewRequest(http.MethodGet, "/"+string(types.Portfolios)+"/test-portfolio-id", nil)
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		var details types.PortfolioDetailsResponse
+		err = json.Unmarshal(recorder.Body.Bytes(), &details)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize portfolio details\n%v", err)
+		}
+
+		if details.Strategy == nil || details.Strategy.Protection == nil || details.Strategy.Protection.StopLossPercent != 30 {
+			t.Errorf("Expected portfolio details to include the protection, got %+v", details.Strategy)
+		}
+	})
+}
+
+func TestTrailingStop(t *testing.T) {
+	t.Cleanup(func() {
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
+	t.Run("Moves the stop-limit order up as the price rises", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.OPEN, types.CANCELLED})
+		details := testExchange.portfolioDetails[testPortfolio.Uuid]
+		details.Breakdown.SpotPositions = append(details.Breakdown.SpotPositions, types.SpotPositions{
+			Asset:              "ETH",
+			TotalBalanceCrypto: types.MustParseDecimal("0.1"),
+		})
+
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		testState := testStateRepo.InitState()
+		testState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				CurrentStrategy: &types.Strategy{
+					Id:           "test-strategy-id",
+					Name:         types.HODL,
+					Currency:     "ETH",
+					OpenOffers:   []types.Offer{},
+					TrailingStop: &types.TrailingStop{Percent: 10},
+				},
+			},
+		}
+
+		err := testStateRepo.Save(*testState)
+
+		if err != nil {
+			t.Fatalf("Failed to save state\n%v", err)
+		}
+
+		monitor := handlers.ProtectionMonitorFactory(testExchange, testStateRepo, types.OrderSettings{})
+
+		// Act
+		err = monitor.Check()
+
+		if err != nil {
+			t.Fatalf("Failed to check trailing stop\n%v", err)
+		}
+
+		testExchange.bestBidAsk.PriceBooks[0].Bids[0].Price = "2500"
+
+		err = monitor.Check()
+
+		if err != nil {
+			t.Fatalf("Failed to check trailing stop\n%v", err)
+		}
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.OpenOffers) != 1 || len(strategy.ClosedOffers) != 1 {
+			t.Fatalf("Expected the first stop order to be replaced, got %d open and %d closed", len(strategy.OpenOffers), len(strategy.ClosedOffers))
+		}
+
+		assertStringEquals(string(types.CANCELLED), string(strategy.ClosedOffers[0].Status), t)
+
+		stopOrder := strategy.OpenOffers[0].Config.StopLimitStopLimitGTC
+
+		if stopOrder == nil {
+			t.Fatalf("Expected a stop-limit order, got %+v", strategy.OpenOffers[0].Config)
+		}
+
+		assertStringEquals("2250", stopOrder.StopPrice.String(), t)
+		assertStringEquals("0.1", stopOrder.BaseSize.String(), t)
+		assertStringEquals(string(types.StopDirectionDown), string(stopOrder.StopDirection), t)
+
+		assertStringEquals("2500", strategy.TrailingStop.PeakPrice.String(), t)
+	})
+
+	t.Run("Disarms the trailing stop when its order is cancelled outside the monitor", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.CANCELLED})
+		details := testExchange.portfolioDetails[testPortfolio.Uuid]
+		details.Breakdown.SpotPositions = append(details.Breakdown.SpotPositions, types.SpotPositions{
+			Asset:              "ETH",
+			TotalBalanceCrypto: types.MustParseDecimal("0.1"),
+		})
+
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		testState := testStateRepo.InitState()
+		testState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				CurrentStrategy: &types.Strategy{
+					Id:           "test-strategy-id",
+					Name:         types.HODL,
+					Currency:     "ETH",
+					OpenOffers:   []types.Offer{{OrderId: "stop-order-id", Side: types.SELL}},
+					TrailingStop: &types.TrailingStop{Percent: 10, PeakPrice: types.NewDecimalFromInt(2400), StopPrice: types.NewDecimalFromInt(2160), OrderId: "stop-order-id"},
+				},
+			},
+		}
+
+		err := testStateRepo.Save(*testState)
+
+		if err != nil {
+			t.Fatalf("Failed to save state\n%v", err)
+		}
+
+		monitor := handlers.ProtectionMonitorFactory(testExchange, testStateRepo, types.OrderSettings{})
+
+		// Act
+		for i := 0; i < 2; i++ {
+			err = monitor.Check()
+
+			if err != nil {
+				t.Fatalf("Failed to check trailing stop\n%v", err)
+			}
+		}
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected the cancelled stop not to be placed again, placed %d", len(testExchange.placedOffers))
+		}
+
+		if !strategy.TrailingStop.Disarmed || strategy.TrailingStop.Triggered {
+			t.Errorf("Expected the trailing stop to be disarmed, got %+v", strategy.TrailingStop)
+		}
+
+		if len(strategy.OpenOffers) != 0 || len(strategy.ClosedOffers) != 1 {
+			t.Errorf("Expected the stop order to be closed, got %d open and %d closed", len(strategy.OpenOffers), len(strategy.ClosedOffers))
+		}
+	})
+
+	t.Run("Cancels the trailing stop order of an archived strategy", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.OPEN, types.CANCELLED})
+		details := testExchange.portfolioDetails[testPortfolio.Uuid]
+		details.Breakdown.SpotPositions = append(details.Breakdown.SpotPositions, types.SpotPositions{
+			Asset:              "ETH",
+			TotalBalanceCrypto: types.MustParseDecimal("0.1"),
+		})
+
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		testState := testStateRepo.InitState()
+		testState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				PreviousStrategies: &[]types.Strategy{
+					{
+						Id:           "test-strategy-id",
+						Name:         types.HODL,
+						Currency:     "ETH",
+						OpenOffers:   []types.Offer{{OrderId: "stop-order-id", Side: types.SELL}},
+						TrailingStop: &types.TrailingStop{Percent: 10, PeakPrice: types.NewDecimalFromInt(2400), StopPrice: types.NewDecimalFromInt(2160), OrderId: "stop-order-id"},
+					},
+				},
+			},
+		}
+
+		err := testStateRepo.Save(*testState)
+
+		if err != nil {
+			t.Fatalf("Failed to save state\n%v", err)
+		}
+
+		monitor := handlers.ProtectionMonitorFactory(testExchange, testStateRepo, types.OrderSettings{})
+
+		// Act
+		for i := 0; i < 2; i++ {
+			err = monitor.Check()
+
+			if err != nil {
+				t.Fatalf("Failed to check trailing stop\n%v", err)
+			}
+		}
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := (*updatedState.Portfolios[0].PreviousStrategies)[0]
+
+		if len(testExchange.cancelledOrderIDs) != 1 || testExchange.cancelledOrderIDs[0] != "stop-order-id" {
+			t.Errorf("Expected the stop order to be cancelled, cancelled %v", testExchange.cancelledOrderIDs)
+		}
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected the archived stop not to be placed again, placed %d", len(testExchange.placedOffers))
+		}
+
+		if strategy.TrailingStop.OrderId != "" || len(strategy.OpenOffers) != 0 || len(strategy.ClosedOffers) != 1 {
+			t.Errorf("Expected the stop order to be closed, got %+v", strategy)
+		}
+	})
+
+	t.Run("Rejects a trailing stop combined with protection", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: state.StateRepositoryFactory(testStateFilename),
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio:    testPortfolio.Name,
+			Strategy:     "HODL",
+			Currency:     "ETH",
+			Protection:   &types.Protection{StopLossPercent: 10},
+			TrailingStop: &types.TrailingStop{Percent: 5},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		recorder := httptest.NewRecorder()
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusBadRequest || !strings.Contains(recorder.Body.String(), "trailing stop") {
+			t.Errorf("Expected a bad request saying why\nActual: %d %s", recorder.Code, recorder.Body.String())
+		}
+	})
+}
+
+func TestGrid(t *testing.T) {
+	t.Cleanup(func() {
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
+	t.Run("Places the opposite order one level away when an order fills", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED, types.FAILED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: testStateRepo,
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "GRID",
+			Currency:  "ETH",
+			Grid: &types.GridPlan{
+				LowerPrice: types.NewDecimalFromInt(2300),
+				UpperPrice: types.NewDecimalFromInt(2400),
+				Levels:     2,
+				Capital:    types.NewDecimalFromInt(100),
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		// Act
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := testStateRepo.GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.ClosedOffers) != 3 {
+			t.Fatalf("Expected 3 closed offers but found %d", len(strategy.ClosedOffers))
+		}
+
+		buy := strategy.ClosedOffers[0]
+		assertStringEquals(string(types.BUY), string(buy.Side), t)
+		assertStringEquals("2300", buy.Config.LimitLimitGTD.LimitPrice.String(), t)
+		assertStringEquals("0.02173913", buy.Config.LimitLimitGTD.BaseSize.String(), t)
+
+		replacement := strategy.ClosedOffers[2]
+		assertStringEquals(string(types.SELL), string(replacement.Side), t)
+		assertStringEquals("2400", replacement.Config.LimitLimitGTD.LimitPrice.String(), t)
+		assertStringEquals("0.04", replacement.Config.LimitLimitGTD.BaseSize.String(), t)
+
+		if replacement.GridLevel != 2 {
+			t.Errorf("Expected replacement order at level 2, got %d", replacement.GridLevel)
+		}
+	})
+
+	t.Run("Leaves the sells protection adds to a grid alone", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FAILED})
+		testStateRepo := state.StateRepositoryFactory(testStateFilename)
+
+		initialState := testStateRepo.InitState()
+		initialState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				CurrentStrategy: &types.Strategy{
+					Id:            "test-strategy-id",
+					Name:          types.GRID,
+					Currency:      "ETH",
+					QuoteCurrency: "GBP",
+					Grid: &types.GridPlan{
+						LowerPrice: types.NewDecimalFromInt(2300),
+						UpperPrice: types.NewDecimalFromInt(2400),
+						Levels:     2,
+						Capital:    types.NewDecimalFromInt(100),
+						ProductID:  "ETH-GBP",
+					},
+					OpenOffers: []types.Offer{
+						{
+							ClientOrderId: "stop-client-id",
+							OrderId:       "stop-order-id",
+							Side:          types.SELL,
+							Status:        types.OPEN,
+							Config: types.OrderConfiguration{
+								StopLimitStopLimitGTC: &types.StopLimitStopLimitGTC{BaseSize: types.MustParseDecimal("0.04")},
+							},
+						},
+						{
+							ClientOrderId: "grid-client-id",
+							OrderId:       "grid-order-id",
+							Side:          types.BUY,
+							Status:        types.OPEN,
+							GridLevel:     1,
+						},
+					},
+				},
+			},
+		}
+
+		err := testStateRepo.Save(*initialState)
+
+		if err != nil {
+			t.Fatalf("Failed to save initial state\n%v", err)
+		}
+
+		// Act
+		err = handlers.ResumeStrategies(testExchange, testStateRepo, nil, types.OrderSettings{})
+
+		if err != nil {
+			t.Fatalf("Failed to resume strategies\n%v", err)
+		}
+
+		var strategy *types.Strategy
+
+		for attempt := 0; attempt < 100; attempt++ {
+			strategy, _ = testStateRepo.GetStrategy(testPortfolio.Uuid)
+
+			if len(strategy.ClosedOffers) > 0 {
+				break
+			}
+
+			time.Sleep(time.Millisecond * 20)
+		}
+
+		// Assert
+		if len(strategy.ClosedOffers) != 1 || strategy.ClosedOffers[0].OrderId != "grid-order-id" {
+			t.Fatalf("Expected the failed grid order to be closed, got %+v", strategy.ClosedOffers)
+		}
+
+		if len(strategy.OpenOffers) != 1 || strategy.OpenOffers[0].OrderId != "stop-order-id" {
+			t.Errorf("Expected the sell to be left for the reconciler, got %+v", strategy.OpenOffers)
+		}
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders to be placed, placed %d", len(testExchange.placedOffers))
+		}
+	})
+}
+
+func TestRebalance(t *testing.T) {
+	t.Cleanup(func() {
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
+	setup := func(preview bool, t *testing.T) (*httptest.ResponseRecorder, *InvestmentManagerHTTPServer, *http.Request, chan bool) {
+		t.Helper()
+
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		testExchange.portfolioDetails[testPortfolio.Uuid].Breakdown.SpotPositions = []types.SpotPositions{
+			{
+				Asset:                "GBP",
+				TotalBalanceFiat:     types.NewDecimalFromInt(1000),
+				AvailableToTradeFiat: types.NewDecimalFromInt(1000),
+				Allocation:           0.5,
+				IsCash:               true,
+			},
+			{
+				Asset:              "ETH",
+				TotalBalanceFiat:   types.NewDecimalFromInt(1000),
+				TotalBalanceCrypto: types.MustParseDecimal("0.4"),
+				Allocation:         0.5,
+			},
+		}
+
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: state.StateRepositoryFactory(testStateFilename),
+			chans:    []chan bool{strategyExecutedChannel},
+		})
+
+		body := types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "REBALANCE",
+			Rebalance: &types.RebalancePlan{
+				Targets:   map[string]float64{"ETH": 30, "GBP": 70},
+				Tolerance: 5,
+				Preview:   preview,
+			},
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		return httptest.NewRecorder(), testServer, request, strategyExecutedChannel
+	}
+
+	t.Run("Previews the trades needed to reach the targets", func(t *testing.T) {
+		// Arrange
+		recorder, testServer, request, _ := setup(true, t)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		var plan types.RebalancePlan
+		err := json.Unmarshal(recorder.Body.Bytes(), &plan)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize rebalance plan\n%v", err)
+		}
+
+		if len(plan.Trades) != 1 {
+			t.Fatalf("Expected 1 trade but found %d", len(plan.Trades))
+		}
+
+		trade := plan.Trades[0]
+		assertStringEquals("ETH-GBP", trade.ProductID, t)
+		assertStringEquals(string(types.SELL), string(trade.Side), t)
+		assertStringEquals("2350.99", trade.LimitPrice.String(), t)
+		assertStringEquals("0.17014108", trade.BaseSize.String(), t)
+	})
+
+	t.Run("Places the planned trades", func(t *testing.T) {
+		// Arrange
+		recorder, testServer, request, strategyExecutedChannel := setup(false, t)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+		<-strategyExecutedChannel
+
+		// Assert
+		updatedState, err := state.StateRepositoryFactory(testStateFilename).GetState()
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.ClosedOffers) != 1 {
+			t.Fatalf("Expected 1 closed offer but found %d", len(strategy.ClosedOffers))
+		}
+
+		assertStringEquals(string(types.SELL), string(strategy.ClosedOffers[0].Side), t)
+		assertStringEquals(string(types.FILLED), string(strategy.ClosedOffers[0].Status), t)
+	})
+}
+
+func TestOrders(t *testing.T) {
+	setup := func(body types.PlaceOrderRequest, t *testing.T) (*httptest.ResponseRecorder, *InvestmentManagerHTTPServer, *http.Request, *testExchange) {
+		t.Helper()
+
+		testPortfolio, testExchange := getHODLTestExchange(nil)
+		testServer := getTestServer(&testServerArgs{exchange: testExchange})
+
+		body.PortfolioID = testPortfolio.Uuid
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.Orders), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		return httptest.NewRecorder(), testServer, request, testExchange
+	}
+
+	marketBuy := types.PlaceOrderRequest{
+		ProductID: "eth-gbp",
+		Side:      types.BUY,
+		OrderType: types.MarketOrder,
+		QuoteSize: types.NewDecimalFromInt(25),
+	}
+
+	t.Run("Previews an order without placing it", func(t *testing.T) {
+		// Arrange
+		body := marketBuy
+		body.Preview = true
+		recorder, testServer, request, testExchange := setup(body, t)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		var resp types.PlaceOrderResponse
+		err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize response\n%v", err)
+		}
+
+		if resp.Preview == nil || resp.Order != nil {
+			t.Fatalf("Expected only a preview, got %+v", resp)
+		}
+
+		assertStringEquals("100", resp.Preview.OrderTotal, t)
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders to be placed, placed %d", len(testExchange.placedOffers))
+		}
+	})
+
+	t.Run("Returns the exchange's preview errors", func(t *testing.T) {
+		// Arrange
+		recorder, testServer, request, testExchange := setup(marketBuy, t)
+		testExchange.orderPreview.Errors = []string{"PREVIEW_INSUFFICIENT_FUND"}
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Expected status %d, got %d", http.StatusUnprocessableEntity, recorder.Code)
+		}
+
+		var resp types.PlaceOrderResponse
+		err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize response\n%v", err)
+		}
+
+		if resp.Preview == nil || len(resp.Preview.Errors) != 1 || resp.Order != nil {
+			t.Fatalf("Expected the preview with its errors, got %+v", resp)
+		}
+
+		assertStringEquals("PREVIEW_INSUFFICIENT_FUND", resp.Preview.Errors[0], t)
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders to be placed, placed %d", len(testExchange.placedOffers))
+		}
+	})
+
+	t.Run("Places a confirmed order", func(t *testing.T) {
+		// Arrange
+		recorder, testServer, request, testExchange := setup(marketBuy, t)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		var resp types.PlaceOrderResponse
+		err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize response\n%v", err)
+		}
+
+		if resp.Order == nil {
+			t.Fatalf("Expected the placed order in the response, got %+v", resp)
+		}
+
+		assertStringEquals("test-order-id", resp.Order.GetOrderID(), t)
+
+		if len(testExchange.placedOffers) != 1 {
+			t.Fatalf("Expected 1 order to be placed, placed %d", len(testExchange.placedOffers))
+		}
+
+		offer := testExchange.placedOffers[0]
+		assertStringEquals("ETH-GBP", offer.ProductId, t)
+		assertStringEquals("25", offer.Config.MarketMarketIOC.QuoteSize.String(), t)
+	})
+
+	t.Run("Rejects a limit order without a limit price", func(t *testing.T) {
+		// Arrange
+		body := marketBuy
+		body.OrderType = types.LimitGTCOrder
+		body.QuoteSize = types.Decimal{}
+		body.BaseSize = types.MustParseDecimal("0.1")
+		recorder, testServer, request, testExchange := setup(body, t)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d got %d", http.StatusBadRequest, recorder.Code)
+		}
+
+		if !strings.Contains(recorder.Body.String(), "Invalid order") {
+			t.Errorf("Expected the response to say why the order is invalid\nActual: %s", recorder.Body.String())
+		}
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders to be placed, placed %d", len(testExchange.placedOffers))
+		}
+	})
+
+	t.Run("Rejects an order below the product's minimum size", func(t *testing.T) {
+		// Arrange
+		recorder, testServer, request, testExchange := setup(marketBuy, t)
+		testExchange.products.Products[0].QuoteMinSize = types.NewDecimalFromInt(50)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d got %d", http.StatusBadRequest, recorder.Code)
+		}
+
+		if len(testExchange.placedOffers) != 0 {
+			t.Errorf("Expected no orders to be placed, placed %d", len(testExchange.placedOffers))
+		}
+	})
+}
+
+func TestCancelOrders(t *testing.T) {
+	t.Cleanup(func() {
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
+	setup := func(t *testing.T) (*InvestmentManagerHTTPServer, *testExchange, *state.StateRepository) {
+		t.Helper()
+
+		testPortfolio, testExchange := getHODLTestExchange(nil)
+		testExchange.openOrders = []types.Order{
+			{OrderID: "strategy-order-id", Status: types.OPEN},
+			{OrderID: "manual-order-id", Status: types.OPEN},
+		}
+
+		stateRepository := state.StateRepositoryFactory(testStateFilename)
+		testState := stateRepository.InitState()
+		testState.Portfolios = []types.Portfolio{
+			{
+				Name: testPortfolio.Name,
+				Uuid: testPortfolio.Uuid,
+				CurrentStrategy: &types.Strategy{
+					Name: types.HODL,
+					OpenOffers: []types.Offer{
+						{ClientOrderId: "strategy-client-order-id", OrderId: "strategy-order-id", Status: types.OPEN},
+					},
+				},
+			},
+		}
+
+		err := stateRepository.Save(*testState)
+
+		if err != nil {
+			t.Fatalf("Failed to save test state\n%v", err)
+		}
+
+		testServer := getTestServer(&testServerArgs{exchange: testExchange, mockRepo: stateRepository})
+
+		return testServer, testExchange, stateRepository
+	}
+
+	t.Run("Lists open orders from the exchange and state", func(t *testing.T) {
+		// Arrange
+		testServer, _, _ := setup(t)
+		recorder := httptest.NewRecorder()
+		request, _ := http.NewRequest(http.MethodGet, "/"+string(types.Orders)+"/test-portfolio-id", nil)
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		var resp types.OpenOrdersResponse
+		err := json.Unmarshal(recorder.Body.Bytes(), &resp)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize response\n%v", err)
+		}
+
+		if len(resp.Orders) != 2 || len(resp.StrategyOffers) != 1 {
+			t.Fatalf("Expected 2 orders and 1 strategy offer, got %+v", resp)
+		}
+
+		assertStringEquals(string(types.HODL), string(resp.StrategyName), t)
+	})
+
+	t.Run("Cancels every open order and closes the strategy's offers", func(t *testing.T) {
+		// Arrange
+		testServer, testExchange, stateRepository := setup(t)
+		recorder := httptest.NewRecorder()
+		body, _ := json.Marshal(types.CancelOrdersRequest{PortfolioID: "test-portfolio-id", All: true})
+		request, _ := http.NewRequest(http.MethodPost, "/"+string(types.Orders)+"/cancel", bytes.NewReader(body))
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if len(testExchange.cancelledOrderIDs) != 2 {
+			t.Fatalf("Expected 2 orders to be cancelled, cancelled %v", testExchange.cancelledOrderIDs)
+		}
+
+		updatedState, err := stateRepository.GetState()
+
+		if err != nil {
+			t.Fatalf("Failed to retrieve state from repository")
+		}
+
+		strategy := updatedState.Portfolios[0].CurrentStrategy
+
+		if len(strategy.OpenOffers) != 0 || len(strategy.ClosedOffers) != 1 {
+			t.Fatalf("Expected the offer to be closed, got %+v", strategy)
+		}
+
+		assertStringEquals(string(types.CANCELLED), string(strategy.ClosedOffers[0].Status), t)
+	})
+
+	t.Run("Rejects a cancel without order ids", func(t *testing.T) {
+		// Arrange
+		testServer, testExchange, _ := setup(t)
+		recorder := httptest.NewRecorder()
+		body, _ := json.Marshal(types.CancelOrdersRequest{PortfolioID: "test-portfolio-id"})
+		request, _ := http.NewRequest(http.MethodPost, "/"+string(types.Orders)+"/cancel", bytes.NewReader(body))
+
+		// Act
+		testServer.ServeHTTP(recorder, request)
+
+		// Assert
+		if recorder.Code != http.StatusBadRequest {
+			t.Errorf("Expected status %d got %d", http.StatusBadRequest, recorder.Code)
+		}
+
+		if len(testExchange.cancelledOrderIDs) != 0 {
+			t.Errorf("Expected no orders to be cancelled, cancelled %v", testExchange.cancelledOrderIDs)
+		}
+	})
+}
+
+func getHODLTestExchange(orderStatuses []types.OrderStatus) (*types.Portfolio, *testExchange) {
+	testPortfolio := &types.Portfolio{
+		Name:               "test",
+		Uuid:               "test-portfolio-id",
+		Type:               "test",
+		Deleted:            false,
+		CurrentStrategy:    nil,
+		PreviousStrategies: nil,
+	}
+
+	testPortfolioResponse := &types.PortfolioResponse{
+		Portfolios: []types.Portfolio{*testPortfolio},
+	}
+
+	testZeroBalance := types.Balance{
+		Currency: "GBP",
+	}
+
+	testPortfolioDetailsResponse := &types.PortfolioDetailsResponse{
+		Breakdown: types.Breakdown{
+			Portfolio: *testPortfolio,
+			PortfolioBalances: types.PortfolioBalances{
+				TotalBalance:               testZeroBalance,
+				TotalFuturesBalance:        testZeroBalance,
+				TotalCashEquivalentBalance: testZeroBalance,
+				TotalCryptoBalance:         testZeroBalance,
+				FuturesUnrealizedPnl:       testZeroBalance,
+				PerpUnrealizedPnl:          testZeroBalance,
+			},
+			SpotPositions: []types.SpotPositions{
+				{
+					Asset:                "GBP",
+					AccountUuid:          "test-account-uuid",
+					TotalBalanceFiat:     types.NewDecimalFromInt(100),
+					TotalBalanceCrypto:   types.NewDecimalFromInt(100),
+					AvailableToTradeFiat: types.NewDecimalFromInt(100),
+					Allocation:           0,
+					CostBasis:            testZeroBalance,
+					AssetImgUrl:          "",
+					IsCash:               true,
+				},
+			},
+		},
+	}
+
+	testProductResponse := &types.ProductResponse{
+		Products: []types.Product{
+			{
+				ProductID:      "ETH-GBP",
+				Price:          "10",
+				BaseIncrement:  types.MustParseDecimal("0.00000001"),
+				QuoteIncrement: types.MustParseDecimal("0.01"),
+			},
+		},
+	}
+
+	testBestBidAskResponse := &types.BestBidAskResponse{
+		PriceBooks: []types.PriceBook{
+			{
+				ProductID: "ETH-GBP",
+				Bids: []types.Bid{
+					{
+						Price: "2349.55",
+						Size:  "0.0675",
+					},
+				},
+				Asks: []types.Bid{
+					{
+						Price: "2350.99",
+						Size:  "0.05",
+					},
+				},
+				Time: "2024-05-01T20:07:23.044653Z",
+			},
+		},
+	}
+
+	tradeSuccessResponse := &types.CoinbaseOrderPlacedResponse{
+		Success: true,
+		OrderID: "test-order-id",
+	}
+
+	tradePreviewResponse := &types.CoinbaseOrderPreviewResponse{
+		OrderTotal: "100",
+	}
+
+	return testPortfolio, &testExchange{
+		portfolios: testPortfolioResponse,
+		portfolioDetails: map[string]*types.PortfolioDetailsResponse{
+			testPortfolio.Uuid: testPortfolioDetailsResponse,
+		},
+		products:      testProductResponse,
+		bestBidAsk:    testBestBidAskResponse,
+		orderPlaced:   tradeSuccessResponse,
+		orderPreview:  tradePreviewResponse,
+		orderStatuses: orderStatuses,
+	}
+}
+
+func TestTransferFunds(t *testing.T) {
+	setup := func(senderAvailableToTradeFiat float64, t *testing.T) (senderID, receiverID string, testServer *InvestmentManagerHTTPServer) {
+		t.Helper()
+		senderPortfolioID := "test-sender-portfolio-id"
+		receiverPortfolioID := "test-receiver-portfolio-id"
+		testSenderPortfolio := &types.Portfolio{
+			Name:               "test-sender",
+			Uuid:               senderPortfolioID,
+			Type:               "test",
+			Deleted:            false,
+			CurrentStrategy:    nil,
+			PreviousStrategies: nil,
+		}
+		testReceiverPortfolio := &types.Portfolio{
+			Name:               "test-receiver",
+			Uuid:               receiverPortfolioID,
+			Type:               "test",
+			Deleted:            false,
+			CurrentStrategy:    nil,
+			PreviousStrategies: nil,
+		}
+		testZeroBalance := types.Balance{
+			Currency: "GBP",
+		}
+
+		testSenderPortfolioDetailsResponse := &types.PortfolioDetailsResponse{
+			Breakdown: types.Breakdown{
+				Portfolio: *testSenderPortfolio,
+				PortfolioBalances: types.PortfolioBalances{
+					TotalBalance:               testZeroBalance,
+					TotalFuturesBalance:        testZeroBalance,
+					TotalCashEquivalentBalance: testZeroBalance,
+					TotalCryptoBalance:         testZeroBalance,
+					FuturesUnrealizedPnl:       testZeroBalance,
+					PerpUnrealizedPnl:          testZeroBalance,
+				},
+				SpotPositions: []types.SpotPositions{
+					{
+						Asset:                "GBP",
+						AccountUuid:          "test-account-uuid",
+						AvailableToTradeFiat: types.NewDecimalFromFloat(senderAvailableToTradeFiat),
+						Allocation:           0,
+						CostBasis:            testZeroBalance,
+						AssetImgUrl:          "",
+						IsCash:               true,
+					},
+				},
+			},
+		}
+
+		testReceiverPortfolioDetailsResponse := &types.PortfolioDetailsResponse{
+			Breakdown: types.Breakdown{
+				Portfolio: *testReceiverPortfolio,
+				PortfolioBalances: types.PortfolioBalances{
+					TotalBalance:               testZeroBalance,
+					TotalFuturesBalance:        testZeroBalance,
+					TotalCashEquivalentBalance: testZeroBalance,
+					TotalCryptoBalance:         testZeroBalance,
+					FuturesUnrealizedPnl:       testZeroBalance,
+					PerpUnrealizedPnl:          testZeroBalance,
+				},
+				SpotPositions: []types.SpotPositions{
+					{
+						Asset:       "GBP",
+						AccountUuid: "test-account-uuid",
+						Allocation:  0,
+						CostBasis:   testZeroBalance,
+						AssetImgUrl: "",
+						IsCash:      true,
+					},
+				},
+			},
+		}
+
+		transferFundsSuccessResponse := &types.TransferFundsResponse{
+			SenderID:   senderPortfolioID,
+			ReceiverID: receiverPortfolioID,
+		}
+
+		testServerArgs := &testServerArgs{
+			exchange: &testExchange{
+				portfolioDetails: map[string]*types.PortfolioDetailsResponse{
+					senderPortfolioID:   testSenderPortfolioDetailsResponse,
+					receiverPortfolioID: testReceiverPortfolioDetailsResponse,
+				},
+				transfer: transferFundsSuccessResponse,
+			},
+			mockRepo: nil,
+			chans:    nil,
+		}
+
+		return senderPortfolioID, receiverPortfolioID, getTestServer(testServerArgs)
+	}
+	t.Run("Fails to transfer if not enough funds", func(t *testing.T) {
+		// Arrange
+		senderID, receiverID, testServer := setup(0, t)
+		body := types.TransferRequest{
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     types.NewDecimalFromInt(10),
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.TransferFunds), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		response := httptest.NewRecorder()
+
+		// Act
+		testServer.ServeHTTP(response, request)
+
+		// Assert
+		if response.Code != http.StatusBadRequest {
+			t.Fatalf("Expected %d Received %d", http.StatusBadRequest, response.Code)
+		}
+	})
+
+	t.Run("Fails to transfer a currency the sender does not hold", func(t *testing.T) {
+		// Arrange
+		senderID, receiverID, testServer := setup(20, t)
+		body := types.TransferRequest{
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     types.NewDecimalFromInt(10),
+			Currency:   "USD",
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.TransferFunds), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		response := httptest.NewRecorder()
+
+		// Act
+		testServer.ServeHTTP(response, request)
+
+		// Assert
+		if response.Code != http.StatusBadRequest {
+			t.Fatalf("Expected %d Received %d", http.StatusBadRequest, response.Code)
+		}
+	})
+
+	t.Run("Transfers funds as expected", func(t *testing.T) {
+		// Arrange
+		senderID, receiverID, testServer := setup(20, t)
+		body := types.TransferRequest{
+			SenderID:   senderID,
+			ReceiverID: receiverID,
+			Amount:     types.NewDecimalFromInt(10),
+		}
+
+		serializedBody, err := json.Marshal(body)
+
+		if err != nil {
+			t.Fatalf("Failed to create body for request\n%v", err)
+		}
+
+		request, err := http.NewRequest(http.MethodPost, "/"+string(types.TransferFunds), bytes.NewReader(serializedBody))
+
+		if err != nil {
+			t.Fatalf("Failed to create http request\n%v", err)
+		}
+
+		response := httptest.NewRecorder()
+
 		// Act
 		testServer.ServeHTTP(response, request)
 
@@ -616,6 +2375,104 @@ func TestTransferFunds(t *testing.T) {
 	})
 }
 
+func TestJournal(t *testing.T) {
+	const testJournalFilename = "test-journal.jsonl"
+
+	pathToJournal, _ := util.GetPathToDataFile(testJournalFilename)
+	os.Remove(pathToJournal)
+
+	t.Cleanup(func() {
+		os.Remove(pathToJournal)
+
+		pathToCreatedFile, _ := util.GetPathToDataFile(testStateFilename)
+		os.Remove(pathToCreatedFile)
+	})
+
+	t.Run("Records the strategy decision and the order it placed", func(t *testing.T) {
+		// Arrange
+		testPortfolio, testExchange := getHODLTestExchange([]types.OrderStatus{types.FILLED})
+		strategyExecutedChannel := make(chan bool)
+
+		testServer := getTestServer(&testServerArgs{
+			exchange: testExchange,
+			mockRepo: state.StateRepositoryFactory(testStateFilename),
+			chans:    []chan bool{strategyExecutedChannel},
+			journal:  journal.JournalFactory(testJournalFilename),
+		})
+
+		serializedBody, _ := json.Marshal(types.ExecuteStrategyRequest{
+			Portfolio: testPortfolio.Name,
+			Strategy:  "HODL",
+			Currency:  "ETH",
+		})
+
+		request, _ := http.NewRequest(http.MethodPost, "/"+string(types.ExecuteStrategy), bytes.NewReader(serializedBody))
+		testServer.ServeHTTP(httptest.NewRecorder(), request)
+		<-strategyExecutedChannel
+
+		// Act
+		request, _ = http.NewRequest(http.MethodGet, "/"+string(types.Journal)+"?portfolio_id="+testPortfolio.Uuid, nil)
+		response := httptest.NewRecorder()
+		testServer.ServeHTTP(response, request)
+
+		// Assert
+		var journalResp types.JournalResponse
+		err := json.Unmarshal(response.Body.Bytes(), &journalResp)
+
+		if err != nil {
+			t.Fatalf("Failed to deserialize response\n%v\n%s", err, response.Body.String())
+		}
+
+		if len(journalResp.Entries) != 2 {
+			t.Fatalf("Expected a decision and an order, found %+v", journalResp.Entries)
+		}
+
+		decision, placed := journalResp.Entries[0], journalResp.Entries[1]
+
+		assertStringEquals(string(types.StrategyDecision), string(decision.Event), t)
+		assertStringEquals("HODL", string(decision.Strategy), t)
+
+		if decision.Inputs == nil || decision.Inputs.BestBid == "" || decision.Inputs.AvailableFunds == "" || decision.Inputs.CommissionEstimate == "" {
+			t.Errorf("Expected the decision to record its inputs, found %+v", decision.Inputs)
+		}
+
+		assertStringEquals(string(types.OrderPlaced), string(placed.Event), t)
+		assertStringEquals("test-order-id", placed.OrderID, t)
+		assertStringEquals("ETH-GBP", placed.ProductID, t)
+
+		if placed.Order == nil || len(placed.Response) == 0 {
+			t.Errorf("Expected the order and the exchange's response, found %+v", placed)
+		}
+	})
+
+	t.Run("Filters entries by event", func(t *testing.T) {
+		testServer := getTestServer(&testServerArgs{journal: journal.JournalFactory(testJournalFilename)})
+
+		request, _ := http.NewRequest(http.MethodGet, "/"+string(types.Journal)+"?event="+string(types.OrderPlaced), nil)
+		response := httptest.NewRecorder()
+		testServer.ServeHTTP(response, request)
+
+		var journalResp types.JournalResponse
+		json.Unmarshal(response.Body.Bytes(), &journalResp)
+
+		if len(journalResp.Entries) != 1 || journalResp.Entries[0].Event != types.OrderPlaced {
+			t.Errorf("Expected only the placed order, found %+v", journalResp.Entries)
+		}
+	})
+
+	t.Run("Rejects an invalid since", func(t *testing.T) {
+		testServer := getTestServer(&testServerArgs{journal: journal.JournalFactory(testJournalFilename)})
+
+		request, _ := http.NewRequest(http.MethodGet, "/"+string(types.Journal)+"?since=yesterday", nil)
+		response := httptest.NewRecorder()
+		testServer.ServeHTTP(response, request)
+
+		if response.Code != http.StatusBadRequest {
+			t.Errorf("Expected %d, got %d", http.StatusBadRequest, response.Code)
+		}
+	})
+}
+
 func assertStringEquals(expected, actual string, t *testing.T) {
 	t.Helper()
 	if expected != actual {
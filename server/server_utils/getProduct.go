@@ -0,0 +1,26 @@
+package server_utils
+
+import (
+	"fmt"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// GetProduct looks up a single product, orders need its increments and limits to be sized correctly.
+// Lookups are cached when ex is a ProductCatalogue.
+func GetProduct(ex exchange.Exchange, productID string) (*types.Product, error) {
+	productResponse, err := ex.ListProducts([]string{productID})
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, product := range productResponse.Products {
+		if product.ProductID == productID {
+			return &product, nil
+		}
+	}
+
+	return nil, fmt.Errorf("Could not find product\nProductID: %q\n", productID)
+}
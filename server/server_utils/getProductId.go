@@ -1,44 +1,43 @@
 package server_utils
 
 import (
-	"fmt"
-	"log"
 	"strings"
 
-	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/server/exchange"
 	"github.com/iPopcorn/investment-manager/types"
-	"github.com/iPopcorn/investment-manager/types/mappers"
 )
 
+// GetProductID returns the id of the product trading baseCurrency for quoteCurrency,
+// or an error if the product doesn't exist or can't currently be traded.
+// The portfolio's quote currency is detected when quoteCurrency is empty.
 func GetProductID(
-	client *infrastructure.InvestmentManagerExternalHttpClient,
+	ex exchange.Exchange,
 	portfolioDetails *types.PortfolioDetailsResponse,
 	baseCurrency string,
+	quoteCurrency string,
 ) (string, error) {
-	quoteCurrencyID := portfolioDetails.Breakdown.PortfolioBalances.TotalCashEquivalentBalance.Currency
+	var err error
 
-	productID := strings.ToUpper(baseCurrency) + "-" + quoteCurrencyID
+	if quoteCurrency == "" {
+		quoteCurrency, err = GetQuoteCurrency(portfolioDetails)
 
-	url := fmt.Sprintf("https://api.coinbase.com/api/v3/brokerage/products?product_type=SPOT&product_ids=%s", productID)
-	resp, err := client.Get(url)
-
-	if err != nil {
-		log.Printf("Error retrieving product list from URL: %q\nError: %v", url, err)
-		return "", err
+		if err != nil {
+			return "", err
+		}
 	}
 
-	productResponse, err := mappers.MapProductResponse(resp)
+	productID := strings.ToUpper(baseCurrency) + "-" + strings.ToUpper(quoteCurrency)
+
+	product, err := GetProduct(ex, productID)
 
 	if err != nil {
-		return "", fmt.Errorf("Failed to map product response to object\n%v\n", err)
+		return "", err
 	}
 
-	if len(productResponse.Products) < 1 {
-		return "", fmt.Errorf("Invalid product id, no products in list\nProductID: %q", productID)
-	}
+	err = product.CheckTradable()
 
-	if len(productResponse.Products) > 1 {
-		fmt.Println("Warning, found multiple products for given id")
+	if err != nil {
+		return "", err
 	}
 
 	return productID, nil
@@ -0,0 +1,49 @@
+package server_utils
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// GetQuoteCurrency detects the fiat currency a portfolio trades with.
+// A portfolio holding a single cash currency trades with it, otherwise the currency
+// Coinbase reports the portfolio's cash balance in is used.
+func GetQuoteCurrency(portfolioDetails *types.PortfolioDetailsResponse) (string, error) {
+	preferred := portfolioDetails.Breakdown.PortfolioBalances.TotalCashEquivalentBalance.Currency
+	cash := []string{}
+
+	for _, position := range portfolioDetails.Breakdown.SpotPositions {
+		if position.IsCash {
+			cash = append(cash, strings.ToUpper(position.Asset))
+		}
+	}
+
+	if len(cash) == 1 {
+		return cash[0], nil
+	}
+
+	for _, currency := range cash {
+		if currency == strings.ToUpper(preferred) {
+			return currency, nil
+		}
+	}
+
+	if preferred == "" {
+		return "", fmt.Errorf("Could not detect the quote currency of portfolio %q, cash held: %v\n", portfolioDetails.Breakdown.Portfolio.Name, cash)
+	}
+
+	return strings.ToUpper(preferred), nil
+}
+
+// GetAvailableFunds returns how much of currency the positions can trade or transfer.
+func GetAvailableFunds(positions []types.SpotPositions, currency string) types.Decimal {
+	for _, position := range positions {
+		if strings.EqualFold(position.Asset, currency) {
+			return position.AvailableToTradeFiat
+		}
+	}
+
+	return types.Decimal{}
+}
@@ -1,103 +1,74 @@
 package server_utils
 
 import (
-	"encoding/json"
 	"fmt"
 	"log"
 
-	"github.com/iPopcorn/investment-manager/infrastructure"
+	"github.com/iPopcorn/investment-manager/server/exchange"
 	"github.com/iPopcorn/investment-manager/types"
-	"github.com/iPopcorn/investment-manager/util"
 )
 
 type PlaceOrderArgs struct {
-	Client  *infrastructure.InvestmentManagerExternalHttpClient
-	Offer   *types.Offer
-	Preview bool
+	Exchange exchange.Exchange
+	Offer    *types.Offer
+	Preview  bool
 }
 
-type previewOffer struct {
-	ProductId         string                   `json:"product_id"`
-	Side              types.Side               `json:"side"`
-	Config            types.OrderConfiguration `json:"order_configuration"`
-	RetailPortfolioId string                   `json:"retail_portfolio_id"`
+// Only one of Order or Preview is set, depending on PlaceOrderArgs.Preview.
+// A preview the exchange rejects isn't an error, its Errors say why so they can be shown to the user.
+type PlaceOrderResult struct {
+	Order   *types.CoinbaseOrderPlacedResponse
+	Preview *types.CoinbaseOrderPreviewResponse
 }
 
-func PlaceOrder(args *PlaceOrderArgs) ([]byte, error) {
-	url := "https://api.coinbase.com/api/v3/brokerage/orders"
-	var serializedRequest []byte
-	var err error
+func PlaceOrder(args *PlaceOrderArgs) (*PlaceOrderResult, error) {
+	// Coinbase rejects orders with more or less than one order configuration
+	orderType, err := args.Offer.Config.OrderType()
 
-	if args.Preview {
-		log.Printf("Preview mode is on!\n")
-
-		url += "/preview"
-
-		previewReq := previewOffer{
-			ProductId:         args.Offer.ProductId,
-			Side:              args.Offer.Side,
-			Config:            args.Offer.Config,
-			RetailPortfolioId: args.Offer.RetailPortfolioId,
-		}
-
-		serializedRequest, err = json.Marshal(previewReq)
-
-		if err != nil {
-			log.Printf("Failed to serialize coinbase request\nrequest: %+v\nerror: %v\n", previewReq, err)
-			return nil, err
-		}
-	} else {
-		serializedRequest, err = json.Marshal(args.Offer)
-
-		if err != nil {
-			log.Printf("Failed to serialize coinbase request\nrequest: %+v\nerror: %v\n", args.Offer, err)
-			return nil, err
-		}
+	if err != nil {
+		return nil, err
 	}
 
-	log.Printf("Sending create order request to coinbase\nreq: %s\n", string(serializedRequest))
-	resp, err := args.Client.Post(url, serializedRequest)
+	log.Printf("Placing %s %s order for %s\n", orderType, args.Offer.Side, args.Offer.ProductId)
+
+	// Catch orders the exchange would reject before sending them
+	product, err := GetProduct(args.Exchange, args.Offer.ProductId)
 
 	if err != nil {
-		log.Printf("Error when sending req to coinbase\n%v\n", err)
 		return nil, err
 	}
 
-	err = util.HandleErrorResponse(resp)
+	err = product.ValidateOrder(args.Offer.Config)
 
 	if err != nil {
-		fmt.Printf("Received error from coinbase while placing order\n%v\n", err)
-
-		return nil, err
+		return nil, fmt.Errorf("Invalid order for %s\n%v", args.Offer.ProductId, err)
 	}
 
-	log.Printf("Received resp from coinbase\nresp: %s\n", string(resp))
-
 	if args.Preview {
-		var coinbaseResp types.CoinbaseOrderPreviewResponse
+		log.Printf("Preview mode is on!\n")
 
-		err = json.Unmarshal(resp, &coinbaseResp)
+		previewResp, err := args.Exchange.PreviewOrder(args.Offer)
 
 		if err != nil {
-			fmt.Printf("Failed to parse coinbase preview response\n%v\n", err)
-
 			return nil, err
 		}
 
-		if len(coinbaseResp.Errors) > 0 {
-			return nil, fmt.Errorf("Received errors from coinbase: %v", coinbaseResp.Errors)
+		if len(previewResp.Errors) > 0 {
+			log.Printf("Received errors from exchange: %v\n", previewResp.Errors)
 		}
-	} else {
-		var coinbaseResp types.CoinbaseOrderPlacedResponse
 
-		err = json.Unmarshal(resp, &coinbaseResp)
+		return &PlaceOrderResult{Preview: previewResp}, nil
+	}
 
-		if err != nil {
-			fmt.Printf("Failed to parse coinbase response\n%v\n", err)
+	orderResp, err := args.Exchange.PlaceOrder(args.Offer)
 
-			return nil, err
-		}
-		return resp, nil
+	if err != nil {
+		return nil, err
 	}
-	return resp, nil
+
+	if !orderResp.Success {
+		return nil, fmt.Errorf("Exchange failed to place order\nreason: %s\nerror: %+v\n", orderResp.FailureReason, orderResp.ErrorResponse)
+	}
+
+	return &PlaceOrderResult{Order: orderResp}, nil
 }
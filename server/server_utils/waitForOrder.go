@@ -0,0 +1,36 @@
+package server_utils
+
+import (
+	"log"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/server/exchange"
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+const maxOrderPollFailures = 5
+
+// WaitForOrder polls the exchange until the order reaches a terminal status.
+// It gives up after maxOrderPollFailures failed polls in a row.
+func WaitForOrder(ex exchange.Exchange, orderID string, pollInterval time.Duration) (*types.Order, error) {
+	failures := 0
+
+	for {
+		order, err := ex.GetOrder(orderID)
+
+		if err != nil {
+			failures++
+			log.Printf("Failed to get status of order %q (%d/%d)\n%v\n", orderID, failures, maxOrderPollFailures, err)
+
+			if failures >= maxOrderPollFailures {
+				return nil, err
+			}
+		} else if order.Status.IsTerminal() {
+			return order, nil
+		} else {
+			failures = 0
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
@@ -1,9 +1,12 @@
 package server_utils
 
 import (
+	"encoding/json"
 	"fmt"
 	"log"
 	"net/http"
+
+	"github.com/iPopcorn/investment-manager/types"
 )
 
 func WriteResponse(w http.ResponseWriter, response []byte, err error) {
@@ -22,3 +25,24 @@ func WriteResponse(w http.ResponseWriter, response []byte, err error) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+// WriteErrorResponse responds with the given status and the error, so the CLI can show why a request was rejected.
+func WriteErrorResponse(w http.ResponseWriter, status int, err error) {
+	log.Printf("Error: %v\n", err)
+
+	serializedResponse, _ := json.Marshal(types.ErrorResponse{Error: err.Error()})
+
+	w.WriteHeader(status)
+	w.Write(serializedResponse)
+}
+
+func WriteJSONResponse(w http.ResponseWriter, response any, err error) {
+	if err != nil {
+		WriteResponse(w, nil, err)
+		return
+	}
+
+	serializedResponse, err := json.Marshal(response)
+
+	WriteResponse(w, serializedResponse, err)
+}
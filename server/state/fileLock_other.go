@@ -0,0 +1,42 @@
+//go:build !unix
+
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const (
+	lockRetryInterval = 10 * time.Millisecond
+	lockTimeout       = 30 * time.Second
+)
+
+// lockFile takes an exclusive lock by creating the file at filepath, waiting for any other holder,
+// and returns a function to release it. Unlike flock, a lock left by a crashed process has to be
+// removed by hand.
+func lockFile(filepath string) (func(), error) {
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		file, err := os.OpenFile(filepath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0666)
+
+		if err == nil {
+			file.Close()
+
+			return func() { os.Remove(filepath) }, nil
+		}
+
+		if !errors.Is(err, os.ErrExist) {
+			return nil, err
+		}
+
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("Timed out waiting for %s, remove it if no server is running\n", filepath)
+		}
+
+		time.Sleep(lockRetryInterval)
+	}
+}
@@ -0,0 +1,30 @@
+//go:build unix
+
+package state
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive lock on the file at filepath, waiting for any other holder,
+// and returns a function to release it. The lock is released by the OS if the process dies.
+func lockFile(filepath string) (func(), error) {
+	file, err := os.OpenFile(filepath, os.O_CREATE|os.O_RDWR, 0666)
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX)
+
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+
+	return func() {
+		syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+		file.Close()
+	}, nil
+}
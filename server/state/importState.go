@@ -0,0 +1,31 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// ImportState copies state from one repository into another, e.g. from state.json into SQLite.
+// It only runs once, a destination that already has state is left alone.
+func ImportState(from, to Repository) error {
+	location := "ImportState()\n"
+
+	_, err := to.GetState()
+
+	if err == nil {
+		return fmt.Errorf(location + "Destination already has state, not importing\n")
+	}
+
+	if !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	importedState, err := from.GetState()
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to read state to import\n%v\n", err)
+	}
+
+	return to.Save(*importedState)
+}
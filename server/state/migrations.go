@@ -0,0 +1,174 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fossoreslp/go-uuid-v4"
+)
+
+// CurrentSchemaVersion is the version of the state this build reads and writes.
+// State without a schema_version is version 0.
+const CurrentSchemaVersion = 1
+
+// migration upgrades state from the version before it to version. It works on the raw JSON,
+// so it still runs when the old state no longer fits types.State, and returns what it changed.
+type migration struct {
+	version     int
+	description string
+	migrate     func(rawState map[string]any) ([]string, error)
+}
+
+// migrations must be in version order and end at CurrentSchemaVersion.
+var migrations = []migration{
+	{
+		version:     1,
+		description: "Give every strategy an id, so a new strategy can be told apart from the one it replaces",
+		migrate:     addStrategyIDs,
+	},
+}
+
+type MigrationReport struct {
+	FromVersion int
+	ToVersion   int
+	Migrations  []MigrationResult
+}
+
+type MigrationResult struct {
+	Version     int
+	Description string
+	Changes     []string
+}
+
+// migrateState runs every migration newer than the version of the given state.
+// The state is returned as it is when it's already up to date.
+func migrateState(data []byte) ([]byte, *MigrationReport, error) {
+	location := "migrateState()\n"
+	var rawState map[string]any
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	err := decoder.Decode(&rawState)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf(location+"Failed to de-serialize state\n%v\n", err)
+	}
+
+	version := 0
+
+	if number, ok := rawState["schema_version"].(json.Number); ok {
+		parsed, err := number.Int64()
+
+		if err != nil {
+			return nil, nil, fmt.Errorf(location+"Invalid schema_version: %s\n", number)
+		}
+
+		version = int(parsed)
+	}
+
+	report := &MigrationReport{FromVersion: version, ToVersion: version}
+
+	if version > CurrentSchemaVersion {
+		return nil, report, fmt.Errorf(location+"State has schema version %d but this build only supports up to %d, upgrade the server\n", version, CurrentSchemaVersion)
+	}
+
+	for _, m := range migrations {
+		if m.version <= version {
+			continue
+		}
+
+		changes, err := m.migrate(rawState)
+
+		if err != nil {
+			return nil, report, fmt.Errorf(location+"Migration to version %d failed\n%v\n", m.version, err)
+		}
+
+		rawState["schema_version"] = m.version
+		report.ToVersion = m.version
+		report.Migrations = append(report.Migrations, MigrationResult{
+			Version:     m.version,
+			Description: m.description,
+			Changes:     changes,
+		})
+	}
+
+	if len(report.Migrations) == 0 {
+		return data, report, nil
+	}
+
+	migrated, err := json.Marshal(rawState)
+
+	return migrated, report, err
+}
+
+// migratePlan migrates a single strategy saved at the given version, by running the
+// migrations over a state holding only that strategy.
+func migratePlan(version int, plan []byte) ([]byte, error) {
+	location := "migratePlan()\n"
+
+	if version == CurrentSchemaVersion {
+		return plan, nil
+	}
+
+	wrapped := fmt.Sprintf(`{"schema_version": %d, "portfolios": [{"current_strategy": %s}]}`, version, plan)
+	migrated, _, err := migrateState([]byte(wrapped))
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rawState struct {
+		Portfolios []struct {
+			CurrentStrategy json.RawMessage `json:"current_strategy"`
+		} `json:"portfolios"`
+	}
+
+	err = json.Unmarshal(migrated, &rawState)
+
+	if err != nil || len(rawState.Portfolios) != 1 {
+		return nil, fmt.Errorf(location+"Failed to unwrap migrated strategy\n%v\n", err)
+	}
+
+	return rawState.Portfolios[0].CurrentStrategy, nil
+}
+
+func addStrategyIDs(rawState map[string]any) ([]string, error) {
+	changes := []string{}
+	portfolios, _ := rawState["portfolios"].([]any)
+
+	for _, p := range portfolios {
+		portfolio, ok := p.(map[string]any)
+
+		if !ok {
+			continue
+		}
+
+		strategies := []any{portfolio["current_strategy"]}
+		previous, _ := portfolio["previous_strategies"].([]any)
+		strategies = append(strategies, previous...)
+
+		for _, s := range strategies {
+			strategy, ok := s.(map[string]any)
+
+			if !ok {
+				continue
+			}
+
+			if id, _ := strategy["id"].(string); id != "" {
+				continue
+			}
+
+			id, err := uuid.NewString()
+
+			if err != nil {
+				return nil, err
+			}
+
+			strategy["id"] = id
+			changes = append(changes, fmt.Sprintf("Portfolio %q: %v strategy given id %s", portfolio["name"], strategy["name"], id))
+		}
+	}
+
+	return changes, nil
+}
@@ -0,0 +1,104 @@
+package state
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+func TestMigrate(t *testing.T) {
+	const filename = "test-migrate-state.json"
+
+	pathToFixture, _ := util.GetPathToDataFile("test-state.json")
+	pathToFile, _ := util.GetPathToDataFile(filename)
+	pathToBackup := pathToFile + ".v0.bak"
+
+	setup := func(t *testing.T) *StateRepository {
+		data, err := os.ReadFile(pathToFixture)
+
+		if err != nil {
+			t.Fatalf("Failed to read file\n%v\n", err)
+		}
+
+		os.WriteFile(pathToFile, data, 0666)
+		os.Remove(pathToBackup)
+
+		t.Cleanup(func() {
+			os.Remove(pathToFile)
+			os.Remove(pathToFile + ".lock")
+			os.Remove(pathToBackup)
+		})
+
+		return StateRepositoryFactory(filename)
+	}
+
+	t.Run("Ends the registry at the current version", func(t *testing.T) {
+		if last := migrations[len(migrations)-1].version; last != CurrentSchemaVersion {
+			t.Errorf("Expected the last migration to be version %d, found %d", CurrentSchemaVersion, last)
+		}
+	})
+
+	t.Run("Reports what would change without writing on a dry run", func(t *testing.T) {
+		testRepo := setup(t)
+		before, _ := os.ReadFile(pathToFile)
+
+		report, err := testRepo.Migrate(true)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if report.FromVersion != 0 || report.ToVersion != CurrentSchemaVersion || len(report.Migrations[0].Changes) != 1 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+
+		after, _ := os.ReadFile(pathToFile)
+
+		if string(before) != string(after) {
+			t.Errorf("Expected a dry run to leave the file alone")
+		}
+
+		if _, err = os.Stat(pathToBackup); err == nil {
+			t.Errorf("Expected a dry run not to make a backup")
+		}
+	})
+
+	t.Run("Backs up the old file and migrates it", func(t *testing.T) {
+		testRepo := setup(t)
+		before, _ := os.ReadFile(pathToFile)
+
+		_, err := testRepo.Migrate(false)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		backup, err := os.ReadFile(pathToBackup)
+
+		if err != nil || string(backup) != string(before) {
+			t.Errorf("Expected the old file to be backed up\n%v", err)
+		}
+
+		migratedState, _ := testRepo.GetState()
+
+		if migratedState.SchemaVersion != CurrentSchemaVersion || migratedState.Portfolios[0].CurrentStrategy.Id == "" {
+			t.Errorf("Expected the strategy to be given an id, found %+v", migratedState.Portfolios[0].CurrentStrategy)
+		}
+
+		report, _ := testRepo.Migrate(true)
+
+		if len(report.Migrations) != 0 {
+			t.Errorf("Expected migrated state to be up to date, found %+v", report)
+		}
+	})
+
+	t.Run("Refuses state from a newer version", func(t *testing.T) {
+		_, _, err := migrateState([]byte(`{"schema_version": 99, "portfolios": []}`))
+
+		if err == nil || !strings.Contains(err.Error(), "upgrade the server") {
+			t.Errorf("Expected an error asking to upgrade, got %v", err)
+		}
+	})
+}
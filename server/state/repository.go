@@ -0,0 +1,211 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+// ErrNoChanges can be returned from an Update to leave state as it is.
+var ErrNoChanges = errors.New("No changes to state")
+
+// ErrOpenOffers is returned when a strategy that still has open offers would be archived.
+// Its orders would keep trading with nothing running the strategy, so they are cancelled first.
+var ErrOpenOffers = errors.New("Strategy has open offers, cancel them first")
+
+// Repository stores the strategies running against each portfolio.
+// GetState returns an error wrapping os.ErrNotExist until state is first saved.
+type Repository interface {
+	GetState() (*types.State, error)
+	Save(newState types.State) error
+	Update(fn func(*types.State) error) error
+	InitState() *types.State
+	GetPortfolio(portfolioUUID string) (*types.Portfolio, error)
+	GetStrategy(portfolioUUID string) (*types.Strategy, error)
+	UpsertStrategy(portfolio types.Portfolio, strategy *types.Strategy) error
+	UpdateStrategy(portfolioUUID, strategyID string, fn func(*types.Strategy) error) error
+	ArchiveStrategy(portfolioUUID string) error
+}
+
+// getPortfolio returns the portfolio with the given uuid from state, or nil if it isn't in state.
+func getPortfolio(r Repository, portfolioUUID string) (*types.Portfolio, error) {
+	currentState, err := r.GetState()
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	i := findPortfolio(currentState, portfolioUUID)
+
+	if i < 0 {
+		return nil, nil
+	}
+
+	return &currentState.Portfolios[i], nil
+}
+
+// getStrategy returns the current strategy of the given portfolio, or nil if there isn't one.
+func getStrategy(r Repository, portfolioUUID string) (*types.Strategy, error) {
+	portfolio, err := r.GetPortfolio(portfolioUUID)
+
+	if err != nil || portfolio == nil {
+		return nil, err
+	}
+
+	return portfolio.CurrentStrategy, nil
+}
+
+// upsertStrategy saves strategy against the given portfolio, leaving every other portfolio as it is.
+// A strategy with a different id to the current one is a new strategy, the current one is archived
+// into PreviousStrategies first. Archived strategies are only changed through updateStrategy.
+func upsertStrategy(r Repository, portfolio types.Portfolio, strategy *types.Strategy) error {
+	return r.Update(func(newState *types.State) error {
+		i := findPortfolio(newState, portfolio.Uuid)
+
+		if i < 0 {
+			newState.Portfolios = append(newState.Portfolios, types.Portfolio{
+				Name:    portfolio.Name,
+				Uuid:    portfolio.Uuid,
+				Type:    portfolio.Type,
+				Deleted: portfolio.Deleted,
+			})
+			i = len(newState.Portfolios) - 1
+		}
+
+		saved := &newState.Portfolios[i]
+		current := saved.CurrentStrategy
+
+		switch {
+		case current == nil || current.Id == strategy.Id:
+			saved.CurrentStrategy = strategy
+		case findStrategy(newState, portfolio.Uuid, strategy.Id) != nil:
+			return fmt.Errorf("Strategy %q is archived and can't be made current again\n", strategy.Id)
+		case len(current.OpenOffers) > 0:
+			return fmt.Errorf("%w\n%s strategy %q has %d open offers\n", ErrOpenOffers, current.Name, current.Id, len(current.OpenOffers))
+		default:
+			archiveCurrentStrategy(saved)
+			saved.CurrentStrategy = strategy
+		}
+
+		touchState(newState)
+
+		return nil
+	})
+}
+
+// updateStrategy lets fn change the saved strategy with the given id, current or archived, in one Update.
+// Strategies, the protection monitor and the reconciler each own some of a strategy's fields, so every
+// writer only changes its own fields on the saved copy instead of saving its whole copy over it.
+func updateStrategy(r Repository, portfolioUUID, strategyID string, fn func(*types.Strategy) error) error {
+	return r.Update(func(newState *types.State) error {
+		strategy := findStrategy(newState, portfolioUUID, strategyID)
+
+		if strategy == nil {
+			return fmt.Errorf("Strategy %q not found in portfolio %q\n", strategyID, portfolioUUID)
+		}
+
+		err := fn(strategy)
+
+		if err != nil {
+			return err
+		}
+
+		touchState(newState)
+
+		return nil
+	})
+}
+
+// archiveStrategy moves the current strategy of the given portfolio into PreviousStrategies.
+func archiveStrategy(r Repository, portfolioUUID string) error {
+	return r.Update(func(newState *types.State) error {
+		i := findPortfolio(newState, portfolioUUID)
+
+		if i < 0 {
+			return fmt.Errorf("Portfolio %q not found in state\n", portfolioUUID)
+		}
+
+		current := newState.Portfolios[i].CurrentStrategy
+
+		if current == nil {
+			return ErrNoChanges
+		}
+
+		if len(current.OpenOffers) > 0 {
+			return fmt.Errorf("%w\n%s strategy %q has %d open offers\n", ErrOpenOffers, current.Name, current.Id, len(current.OpenOffers))
+		}
+
+		archiveCurrentStrategy(&newState.Portfolios[i])
+		newState.LastUpdated = time.Now().Format(time.RFC3339)
+
+		return nil
+	})
+}
+
+func findPortfolio(currentState *types.State, portfolioUUID string) int {
+	for i, portfolio := range currentState.Portfolios {
+		if portfolio.Uuid == portfolioUUID {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// findStrategy returns the strategy with the given id, whether it's the current one or archived.
+func findStrategy(currentState *types.State, portfolioUUID, strategyID string) *types.Strategy {
+	i := findPortfolio(currentState, portfolioUUID)
+
+	if i < 0 || strategyID == "" {
+		return nil
+	}
+
+	portfolio := &currentState.Portfolios[i]
+
+	if portfolio.CurrentStrategy != nil && portfolio.CurrentStrategy.Id == strategyID {
+		return portfolio.CurrentStrategy
+	}
+
+	if portfolio.PreviousStrategies == nil {
+		return nil
+	}
+
+	previous := *portfolio.PreviousStrategies
+
+	for j := range previous {
+		if previous[j].Id == strategyID {
+			return &previous[j]
+		}
+	}
+
+	return nil
+}
+
+// touchState moves LastUpdated on. It's a second ahead so a change made within the second
+// state was read in still shows as an update.
+func touchState(newState *types.State) {
+	newState.LastUpdated = time.Now().Add(time.Second).Format(time.RFC3339)
+}
+
+func archiveCurrentStrategy(portfolio *types.Portfolio) {
+	if portfolio.CurrentStrategy == nil {
+		return
+	}
+
+	previous := []types.Strategy{}
+
+	if portfolio.PreviousStrategies != nil {
+		previous = *portfolio.PreviousStrategies
+	}
+
+	previous = append(previous, *portfolio.CurrentStrategy)
+	portfolio.PreviousStrategies = &previous
+	portfolio.CurrentStrategy = nil
+}
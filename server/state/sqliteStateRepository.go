@@ -0,0 +1,776 @@
+package state
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// The current state is kept in portfolios, strategies and offers, only the rows that change are rewritten.
+// fills are only ever added to and snapshots are pruned, so they keep the history state.json loses.
+const sqliteSchema = `
+CREATE TABLE IF NOT EXISTS state (
+	id INTEGER PRIMARY KEY CHECK (id = 1),
+	schema_version INTEGER NOT NULL,
+	last_updated TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS portfolios (
+	uuid TEXT PRIMARY KEY,
+	position INTEGER NOT NULL,
+	name TEXT NOT NULL,
+	type TEXT NOT NULL,
+	deleted INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS strategies (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	portfolio_uuid TEXT NOT NULL REFERENCES portfolios (uuid) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	is_current INTEGER NOT NULL,
+	strategy_id TEXT NOT NULL,
+	name TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	quote_currency TEXT NOT NULL,
+	plan TEXT NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS strategies_position ON strategies (portfolio_uuid, position);
+
+CREATE TABLE IF NOT EXISTS offers (
+	strategy INTEGER NOT NULL REFERENCES strategies (id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	is_open INTEGER NOT NULL,
+	client_order_id TEXT NOT NULL,
+	order_id TEXT NOT NULL,
+	product_id TEXT NOT NULL,
+	side TEXT NOT NULL,
+	status TEXT NOT NULL,
+	offer TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS fills (
+	client_order_id TEXT PRIMARY KEY,
+	order_id TEXT NOT NULL,
+	portfolio_uuid TEXT NOT NULL,
+	strategy_id TEXT NOT NULL,
+	product_id TEXT NOT NULL,
+	side TEXT NOT NULL,
+	status TEXT NOT NULL,
+	filled_size TEXT NOT NULL,
+	average_filled_price TEXT NOT NULL,
+	total_fees TEXT NOT NULL,
+	recorded_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS snapshots (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	taken_at TEXT NOT NULL,
+	state TEXT NOT NULL
+);
+`
+
+const (
+	defaultSnapshotInterval = time.Hour
+	defaultMaxSnapshots     = 24 * 7
+)
+
+// SQLiteStateRepository stores state in an SQLite database, one row per portfolio, strategy and offer.
+// Updates also record the fills of closed offers and, at most once per snapshotInterval, a snapshot
+// of the whole state. Only the latest maxSnapshots are kept.
+type SQLiteStateRepository struct {
+	db     *sql.DB // Updates, which take the write lock up front so other processes using the file wait their turn
+	readDB *sql.DB // Reads, in deferred transactions so they don't wait behind updates
+	mu     sync.Mutex
+
+	filepath string
+
+	snapshotInterval time.Duration
+	maxSnapshots     int
+}
+
+func SQLiteStateRepositoryFactory(filename string) (*SQLiteStateRepository, error) {
+	location := "SQLiteStateRepositoryFactory()\n"
+
+	if filename == "" {
+		filename = "state.db"
+	}
+
+	filepath, err := util.GetPathToDataFile(filename)
+
+	if err != nil {
+		fmt.Printf(location+"Failed to get path to file\n%v\n", err)
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+filepath+"?_txlock=immediate&_busy_timeout=5000&_foreign_keys=on")
+
+	if err != nil {
+		return nil, fmt.Errorf(location+"Failed to open database\n%v\n", err)
+	}
+
+	db.SetMaxOpenConns(1)
+
+	readDB, err := sql.Open("sqlite3", "file:"+filepath+"?_txlock=deferred&_busy_timeout=5000&_foreign_keys=on")
+
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf(location+"Failed to open database\n%v\n", err)
+	}
+
+	_, err = db.Exec(sqliteSchema)
+
+	if err != nil {
+		db.Close()
+		readDB.Close()
+		return nil, fmt.Errorf(location+"Failed to create tables\n%v\n", err)
+	}
+
+	err = addSchemaVersionColumn(db)
+
+	if err != nil {
+		db.Close()
+		readDB.Close()
+		return nil, fmt.Errorf(location+"Failed to add schema version\n%v\n", err)
+	}
+
+	return &SQLiteStateRepository{
+		db:               db,
+		readDB:           readDB,
+		filepath:         filepath,
+		snapshotInterval: defaultSnapshotInterval,
+		maxSnapshots:     defaultMaxSnapshots,
+	}, nil
+}
+
+// addSchemaVersionColumn upgrades databases created before the schema version was stored,
+// their rows were all written at version 1.
+func addSchemaVersionColumn(db *sql.DB) error {
+	var columns int
+
+	err := db.QueryRow("SELECT COUNT(*) FROM pragma_table_info('state') WHERE name = 'schema_version'").Scan(&columns)
+
+	if err != nil || columns > 0 {
+		return err
+	}
+
+	_, err = db.Exec("ALTER TABLE state ADD COLUMN schema_version INTEGER NOT NULL DEFAULT 1")
+
+	return err
+}
+
+func (r *SQLiteStateRepository) Close() error {
+	r.readDB.Close()
+
+	return r.db.Close()
+}
+
+func (r *SQLiteStateRepository) GetState() (*types.State, error) {
+	tx, err := r.readDB.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	currentState, _, err := readSQLiteState(tx)
+
+	return currentState, err
+}
+
+// Save replaces the whole of state with newState.
+func (r *SQLiteStateRepository) Save(newState types.State) error {
+	return r.Update(func(currentState *types.State) error {
+		*currentState = newState
+
+		return nil
+	})
+}
+
+// Update reads state, lets fn change it, then writes it back in a single transaction.
+// State is initialized if there isn't any yet. If fn returns an error nothing is written,
+// ErrNoChanges isn't passed on.
+func (r *SQLiteStateRepository) Update(fn func(*types.State) error) error {
+	location := "SQLiteStateRepository.Update()\n"
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tx, err := r.db.Begin()
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to begin transaction\n%v\n", err)
+	}
+
+	defer tx.Rollback()
+
+	currentState, version, err := readSQLiteState(tx)
+
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No state found, initializing\n")
+		currentState, version, err = r.InitState(), CurrentSchemaVersion, nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Rows saved by an older version are all rewritten, otherwise only the ones fn changes
+	var previousRows map[sqliteRowKey]sqliteRow
+	lastUpdated := currentState.LastUpdated
+
+	if version == CurrentSchemaVersion {
+		previousRows, err = getSQLiteRows(currentState)
+
+		if err != nil {
+			return fmt.Errorf(location+"Failed to read rows\n%v\n", err)
+		}
+	}
+
+	err = fn(currentState)
+
+	if errors.Is(err, ErrNoChanges) {
+		return nil
+	}
+
+	if err != nil {
+		return err
+	}
+
+	changed, err := writeSQLiteState(tx, previousRows, currentState)
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to write state\n%v\n", err)
+	}
+
+	if !changed && currentState.LastUpdated == lastUpdated {
+		return nil
+	}
+
+	_, err = tx.Exec("INSERT INTO state (id, schema_version, last_updated) VALUES (1, ?, ?) ON CONFLICT (id) DO UPDATE SET schema_version = excluded.schema_version, last_updated = excluded.last_updated",
+		CurrentSchemaVersion, currentState.LastUpdated)
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to write state\n%v\n", err)
+	}
+
+	err = r.snapshot(tx, currentState)
+
+	if err != nil {
+		return fmt.Errorf(location+"Failed to snapshot state\n%v\n", err)
+	}
+
+	return tx.Commit()
+}
+
+// Migrate brings state saved by an older version up to CurrentSchemaVersion, backing up the
+// database first. With dryRun nothing is written, the report says what would change.
+func (r *SQLiteStateRepository) Migrate(dryRun bool) (*MigrationReport, error) {
+	location := "SQLiteStateRepository.Migrate()\n"
+	report, err := r.getMigrationReport()
+
+	if err != nil || dryRun || len(report.Migrations) == 0 {
+		return report, err
+	}
+
+	backup := fmt.Sprintf("%s.v%d.bak", r.filepath, report.FromVersion)
+	os.Remove(backup)
+
+	_, err = r.db.Exec("VACUUM INTO ?", backup)
+
+	if err != nil {
+		return report, fmt.Errorf(location+"Failed to back up state before migrating it\n%v\n", err)
+	}
+
+	// Rows saved at an older version are migrated as they're read, and an update rewrites them all
+	err = r.Update(func(*types.State) error {
+		return nil
+	})
+
+	if err != nil {
+		return report, err
+	}
+
+	fmt.Printf("Migrated state from version %d to %d, the old state is in %s\n", report.FromVersion, report.ToVersion, backup)
+
+	return report, nil
+}
+
+// getMigrationReport runs the migrations over the saved plans to report what migrating them would change.
+func (r *SQLiteStateRepository) getMigrationReport() (*MigrationReport, error) {
+	tx, err := r.readDB.Begin()
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer tx.Rollback()
+
+	var version int
+
+	err = tx.QueryRow("SELECT schema_version FROM state WHERE id = 1").Scan(&version)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("No state saved yet\n%w", os.ErrNotExist)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	type rawPortfolio struct {
+		Uuid               string            `json:"uuid"`
+		Name               string            `json:"name"`
+		PreviousStrategies []json.RawMessage `json:"previous_strategies"`
+	}
+
+	rows, err := tx.Query("SELECT portfolios.uuid, portfolios.name, strategies.plan FROM strategies JOIN portfolios ON portfolios.uuid = strategies.portfolio_uuid ORDER BY portfolios.position, strategies.position")
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer rows.Close()
+
+	portfolios := []*rawPortfolio{}
+
+	for rows.Next() {
+		var uuid, name, plan string
+
+		err = rows.Scan(&uuid, &name, &plan)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(portfolios) == 0 || portfolios[len(portfolios)-1].Uuid != uuid {
+			portfolios = append(portfolios, &rawPortfolio{Uuid: uuid, Name: name})
+		}
+
+		portfolio := portfolios[len(portfolios)-1]
+		portfolio.PreviousStrategies = append(portfolio.PreviousStrategies, json.RawMessage(plan))
+	}
+
+	data, err := json.Marshal(map[string]any{"schema_version": version, "portfolios": portfolios})
+
+	if err != nil {
+		return nil, err
+	}
+
+	_, report, err := migrateState(data)
+
+	return report, err
+}
+
+// snapshot records the whole state if the last snapshot is older than snapshotInterval,
+// then prunes all but the latest maxSnapshots.
+func (r *SQLiteStateRepository) snapshot(tx *sql.Tx, newState *types.State) error {
+	var takenAt string
+
+	err := tx.QueryRow("SELECT taken_at FROM snapshots ORDER BY id DESC LIMIT 1").Scan(&takenAt)
+
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	if lastSnapshot, parseErr := time.Parse(time.RFC3339, takenAt); err == nil && parseErr == nil && time.Since(lastSnapshot) < r.snapshotInterval {
+		return nil
+	}
+
+	snapshot, err := json.Marshal(newState)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("INSERT INTO snapshots (taken_at, state) VALUES (?, ?)", time.Now().Format(time.RFC3339), string(snapshot))
+
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("DELETE FROM snapshots WHERE id NOT IN (SELECT id FROM snapshots ORDER BY id DESC LIMIT ?)", r.maxSnapshots)
+
+	return err
+}
+
+func (r *SQLiteStateRepository) InitState() *types.State {
+	return &types.State{
+		SchemaVersion: CurrentSchemaVersion,
+		LastUpdated:   time.Now().Format(time.RFC3339),
+		Portfolios:    []types.Portfolio{},
+	}
+}
+
+func (r *SQLiteStateRepository) GetPortfolio(portfolioUUID string) (*types.Portfolio, error) {
+	return getPortfolio(r, portfolioUUID)
+}
+
+func (r *SQLiteStateRepository) GetStrategy(portfolioUUID string) (*types.Strategy, error) {
+	return getStrategy(r, portfolioUUID)
+}
+
+func (r *SQLiteStateRepository) UpsertStrategy(portfolio types.Portfolio, strategy *types.Strategy) error {
+	return upsertStrategy(r, portfolio, strategy)
+}
+
+func (r *SQLiteStateRepository) UpdateStrategy(portfolioUUID, strategyID string, fn func(*types.Strategy) error) error {
+	return updateStrategy(r, portfolioUUID, strategyID, fn)
+}
+
+func (r *SQLiteStateRepository) ArchiveStrategy(portfolioUUID string) error {
+	return archiveStrategy(r, portfolioUUID)
+}
+
+// readSQLiteState returns the state and the schema version it was saved at. Plans saved at an
+// older version are migrated as they're read, they're only rewritten by the next update.
+func readSQLiteState(tx *sql.Tx) (*types.State, int, error) {
+	currentState := &types.State{SchemaVersion: CurrentSchemaVersion, Portfolios: []types.Portfolio{}}
+	var version int
+
+	err := tx.QueryRow("SELECT schema_version, last_updated FROM state WHERE id = 1").Scan(&version, &currentState.LastUpdated)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, 0, fmt.Errorf("No state saved yet\n%w", os.ErrNotExist)
+	}
+
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if version > CurrentSchemaVersion {
+		return nil, version, fmt.Errorf("State has schema version %d but this build only supports up to %d, upgrade the server\n", version, CurrentSchemaVersion)
+	}
+
+	rows, err := tx.Query("SELECT uuid, name, type, deleted FROM portfolios ORDER BY position")
+
+	if err != nil {
+		return nil, version, err
+	}
+
+	for rows.Next() {
+		var portfolio types.Portfolio
+
+		err = rows.Scan(&portfolio.Uuid, &portfolio.Name, &portfolio.Type, &portfolio.Deleted)
+
+		if err != nil {
+			rows.Close()
+			return nil, version, err
+		}
+
+		currentState.Portfolios = append(currentState.Portfolios, portfolio)
+	}
+
+	rows.Close()
+
+	for i := range currentState.Portfolios {
+		err = readSQLiteStrategies(tx, version, &currentState.Portfolios[i])
+
+		if err != nil {
+			return nil, version, err
+		}
+	}
+
+	return currentState, version, nil
+}
+
+func readSQLiteStrategies(tx *sql.Tx, version int, portfolio *types.Portfolio) error {
+	rows, err := tx.Query("SELECT id, is_current, plan FROM strategies WHERE portfolio_uuid = ? ORDER BY position", portfolio.Uuid)
+
+	if err != nil {
+		return err
+	}
+
+	type strategyRow struct {
+		id        int64
+		isCurrent bool
+		strategy  types.Strategy
+	}
+
+	strategyRows := []strategyRow{}
+
+	for rows.Next() {
+		var row strategyRow
+		var plan []byte
+
+		err = rows.Scan(&row.id, &row.isCurrent, &plan)
+
+		if err == nil {
+			plan, err = migratePlan(version, plan)
+		}
+
+		if err == nil {
+			err = json.Unmarshal(plan, &row.strategy)
+		}
+
+		if err != nil {
+			rows.Close()
+			return err
+		}
+
+		strategyRows = append(strategyRows, row)
+	}
+
+	rows.Close()
+
+	for _, row := range strategyRows {
+		strategy := row.strategy
+		strategy.OpenOffers, strategy.ClosedOffers, err = readSQLiteOffers(tx, row.id)
+
+		if err != nil {
+			return err
+		}
+
+		if row.isCurrent {
+			portfolio.CurrentStrategy = &strategy
+			continue
+		}
+
+		if portfolio.PreviousStrategies == nil {
+			portfolio.PreviousStrategies = &[]types.Strategy{}
+		}
+
+		*portfolio.PreviousStrategies = append(*portfolio.PreviousStrategies, strategy)
+	}
+
+	return nil
+}
+
+func readSQLiteOffers(tx *sql.Tx, strategyRowID int64) ([]types.Offer, []types.Offer, error) {
+	rows, err := tx.Query("SELECT is_open, offer FROM offers WHERE strategy = ? ORDER BY position", strategyRowID)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer rows.Close()
+
+	openOffers := []types.Offer{}
+	var closedOffers []types.Offer
+
+	for rows.Next() {
+		var isOpen bool
+		var data string
+		var offer types.Offer
+
+		err = rows.Scan(&isOpen, &data)
+
+		if err == nil {
+			err = json.Unmarshal([]byte(data), &offer)
+		}
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isOpen {
+			openOffers = append(openOffers, offer)
+		} else {
+			closedOffers = append(closedOffers, offer)
+		}
+	}
+
+	return openOffers, closedOffers, rows.Err()
+}
+
+// sqliteRowKey identifies a portfolio row, or one of its strategy rows by position.
+type sqliteRowKey struct {
+	portfolioUUID string
+	strategy      int
+}
+
+const portfolioRow = -1
+
+// sqliteRow holds what a row is written from. data is compared to tell whether the row changed,
+// for a strategy it includes the offers so they're rewritten with it.
+type sqliteRow struct {
+	portfolio *types.Portfolio
+	position  int
+	strategy  *types.Strategy
+	isCurrent bool
+	data      string
+}
+
+// getSQLiteRows lists the portfolio and strategy rows of the given state.
+// Previous strategies come first and the current strategy last.
+func getSQLiteRows(currentState *types.State) (map[sqliteRowKey]sqliteRow, error) {
+	rows := map[sqliteRowKey]sqliteRow{}
+
+	for i := range currentState.Portfolios {
+		portfolio := &currentState.Portfolios[i]
+		data := fmt.Sprintf("%d|%s|%s|%v", i, portfolio.Name, portfolio.Type, portfolio.Deleted)
+		rows[sqliteRowKey{portfolio.Uuid, portfolioRow}] = sqliteRow{portfolio: portfolio, position: i, data: data}
+
+		strategies := []*types.Strategy{}
+
+		if portfolio.PreviousStrategies != nil {
+			for j := range *portfolio.PreviousStrategies {
+				strategies = append(strategies, &(*portfolio.PreviousStrategies)[j])
+			}
+		}
+
+		if portfolio.CurrentStrategy != nil {
+			strategies = append(strategies, portfolio.CurrentStrategy)
+		}
+
+		for j, strategy := range strategies {
+			isCurrent := strategy == portfolio.CurrentStrategy
+			data, err := json.Marshal(strategy)
+
+			if err != nil {
+				return nil, err
+			}
+
+			rows[sqliteRowKey{portfolio.Uuid, j}] = sqliteRow{
+				portfolio: portfolio,
+				position:  j,
+				strategy:  strategy,
+				isCurrent: isCurrent,
+				data:      fmt.Sprintf("%v|%s", isCurrent, data),
+			}
+		}
+	}
+
+	return rows, nil
+}
+
+// writeSQLiteState writes the rows of newState that differ from previousRows and deletes the ones
+// newState no longer has. Every row is rewritten when previousRows is nil. It returns whether
+// anything was written.
+func writeSQLiteState(tx *sql.Tx, previousRows map[sqliteRowKey]sqliteRow, newState *types.State) (bool, error) {
+	rows, err := getSQLiteRows(newState)
+
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+
+	if previousRows == nil {
+		// Deleting a portfolio cascades to its strategies and offers
+		_, err = tx.Exec("DELETE FROM portfolios")
+
+		if err != nil {
+			return false, err
+		}
+
+		previousRows = map[sqliteRowKey]sqliteRow{}
+		changed = true
+	}
+
+	for key := range previousRows {
+		if _, ok := rows[key]; ok {
+			continue
+		}
+
+		if key.strategy == portfolioRow {
+			_, err = tx.Exec("DELETE FROM portfolios WHERE uuid = ?", key.portfolioUUID)
+		} else {
+			_, err = tx.Exec("DELETE FROM strategies WHERE portfolio_uuid = ? AND position = ?", key.portfolioUUID, key.strategy)
+		}
+
+		if err != nil {
+			return false, err
+		}
+
+		changed = true
+	}
+
+	// Portfolios go first so their strategies have something to reference
+	for _, writePortfolios := range []bool{true, false} {
+		for key, row := range rows {
+			if (key.strategy == portfolioRow) != writePortfolios || previousRows[key].data == row.data {
+				continue
+			}
+
+			if writePortfolios {
+				_, err = tx.Exec("INSERT INTO portfolios (uuid, position, name, type, deleted) VALUES (?, ?, ?, ?, ?) ON CONFLICT (uuid) DO UPDATE SET position = excluded.position, name = excluded.name, type = excluded.type, deleted = excluded.deleted",
+					row.portfolio.Uuid, row.position, row.portfolio.Name, row.portfolio.Type, row.portfolio.Deleted)
+			} else {
+				err = writeSQLiteStrategy(tx, row.portfolio.Uuid, row.position, row.isCurrent, row.strategy)
+			}
+
+			if err != nil {
+				return false, err
+			}
+
+			changed = true
+		}
+	}
+
+	return changed, nil
+}
+
+func writeSQLiteStrategy(tx *sql.Tx, portfolioUUID string, position int, isCurrent bool, strategy *types.Strategy) error {
+	// Offers have their own table, the rest of the strategy is kept as it is
+	plan := *strategy
+	plan.OpenOffers = nil
+	plan.ClosedOffers = nil
+
+	data, err := json.Marshal(plan)
+
+	if err != nil {
+		return err
+	}
+
+	// Deleting the old row cascades to its offers, they're written again below
+	_, err = tx.Exec("DELETE FROM strategies WHERE portfolio_uuid = ? AND position = ?", portfolioUUID, position)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := tx.Exec("INSERT INTO strategies (portfolio_uuid, position, is_current, strategy_id, name, currency, quote_currency, plan) VALUES (?, ?, ?, ?, ?, ?, ?, ?)",
+		portfolioUUID, position, isCurrent, strategy.Id, string(strategy.Name), string(strategy.Currency), strategy.QuoteCurrency, string(data))
+
+	if err != nil {
+		return err
+	}
+
+	strategyRowID, err := result.LastInsertId()
+
+	if err != nil {
+		return err
+	}
+
+	offers := append(append([]types.Offer{}, strategy.OpenOffers...), strategy.ClosedOffers...)
+
+	for i, offer := range offers {
+		isOpen := i < len(strategy.OpenOffers)
+		data, err := json.Marshal(offer)
+
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Exec("INSERT INTO offers (strategy, position, is_open, client_order_id, order_id, product_id, side, status, offer) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			strategyRowID, i, isOpen, offer.ClientOrderId, offer.OrderId, offer.ProductId, string(offer.Side), string(offer.Status), string(data))
+
+		if err != nil {
+			return err
+		}
+
+		if isOpen || offer.FilledSize.IsZero() {
+			continue
+		}
+
+		_, err = tx.Exec("INSERT OR IGNORE INTO fills (client_order_id, order_id, portfolio_uuid, strategy_id, product_id, side, status, filled_size, average_filled_price, total_fees, recorded_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			offer.ClientOrderId, offer.OrderId, portfolioUUID, strategy.Id, offer.ProductId, string(offer.Side), string(offer.Status),
+			offer.FilledSize.String(), offer.AverageFilledPrice.String(), offer.TotalFees.String(), time.Now().Format(time.RFC3339))
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package state
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/iPopcorn/investment-manager/types"
+	"github.com/iPopcorn/investment-manager/util"
+)
+
+func TestSQLiteStateRepository(t *testing.T) {
+	const filename = "test-sqlite-state.db"
+
+	setup := func() *SQLiteStateRepository {
+		pathToFile, _ := util.GetPathToDataFile(filename)
+		os.Remove(pathToFile)
+
+		testRepo, err := SQLiteStateRepositoryFactory(filename)
+
+		if err != nil {
+			t.Fatalf("Failed to open database\n%v\n", err)
+		}
+
+		testRepo.snapshotInterval = 0
+
+		t.Cleanup(func() {
+			testRepo.Close()
+			os.Remove(pathToFile)
+		})
+
+		return testRepo
+	}
+
+	t.Run("Imports state from state.json", func(t *testing.T) {
+		testRepo := setup()
+		jsonRepo := StateRepositoryFactory("test-state.json")
+
+		if _, err := testRepo.GetState(); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("Expected no state before importing, got %v", err)
+		}
+
+		err := ImportState(jsonRepo, testRepo)
+
+		if err != nil {
+			t.Fatalf("Failed to import state\n%v\n", err)
+		}
+
+		expectedState, _ := jsonRepo.GetState()
+		actualState, err := testRepo.GetState()
+
+		if err != nil {
+			t.Fatalf("Failed to get state\n%v\n", err)
+		}
+
+		AssertStateEqual(expectedState, actualState, t)
+
+		if err = ImportState(jsonRepo, testRepo); err == nil {
+			t.Errorf("Expected importing twice to fail")
+		}
+	})
+
+	t.Run("Keeps previous strategies and records fills", func(t *testing.T) {
+		testRepo := setup()
+		portfolioA := types.Portfolio{Name: "a", Uuid: "portfolio-a"}
+		portfolioB := types.Portfolio{Name: "b", Uuid: "portfolio-b"}
+		filled := types.Offer{ClientOrderId: "filled", OrderId: "order-1", Status: types.FILLED, FilledSize: types.MustParseDecimal("0.04")}
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL, ClosedOffers: []types.Offer{filled}})
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "second", Name: types.DCA, DCA: &types.DCAPlan{AmountPerBuy: types.NewDecimalFromInt(10)}})
+		err := testRepo.UpsertStrategy(portfolioB, &types.Strategy{Id: "third", Name: types.HODL})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		portfolio, err := testRepo.GetPortfolio(portfolioA.Uuid)
+
+		if err != nil || portfolio == nil {
+			t.Fatalf("Expected portfolio a in state, got %v", err)
+		}
+
+		if portfolio.CurrentStrategy == nil || portfolio.CurrentStrategy.DCA == nil || portfolio.CurrentStrategy.DCA.AmountPerBuy.String() != "10" {
+			t.Errorf("Expected the DCA strategy to be current, found %+v", portfolio.CurrentStrategy)
+		}
+
+		if portfolio.PreviousStrategies == nil || len(*portfolio.PreviousStrategies) != 1 || len((*portfolio.PreviousStrategies)[0].ClosedOffers) != 1 {
+			t.Errorf("Expected the HODL strategy and its offer to be archived, found %+v", portfolio.PreviousStrategies)
+		}
+
+		if strategy, _ := testRepo.GetStrategy(portfolioB.Uuid); strategy == nil || strategy.Id != "third" {
+			t.Errorf("Expected portfolio b to keep its strategy, found %+v", strategy)
+		}
+
+		var fills, snapshots int
+		testRepo.db.QueryRow("SELECT COUNT(*) FROM fills WHERE portfolio_uuid = ? AND filled_size = ?", portfolioA.Uuid, "0.04").Scan(&fills)
+		testRepo.db.QueryRow("SELECT COUNT(*) FROM snapshots").Scan(&snapshots)
+
+		if fills != 1 {
+			t.Errorf("Expected 1 fill, found %d", fills)
+		}
+
+		if snapshots != 3 {
+			t.Errorf("Expected a snapshot per update, found %d", snapshots)
+		}
+	})
+	t.Run("Only rewrites the rows that change", func(t *testing.T) {
+		testRepo := setup()
+		portfolioA := types.Portfolio{Name: "a", Uuid: "portfolio-a"}
+		portfolioB := types.Portfolio{Name: "b", Uuid: "portfolio-b"}
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL})
+		testRepo.UpsertStrategy(portfolioB, &types.Strategy{Id: "second", Name: types.HODL})
+
+		getRowID := func(portfolioUUID string) int64 {
+			var id int64
+			testRepo.db.QueryRow("SELECT id FROM strategies WHERE portfolio_uuid = ? AND is_current = 1", portfolioUUID).Scan(&id)
+
+			return id
+		}
+
+		rowA, rowB := getRowID(portfolioA.Uuid), getRowID(portfolioB.Uuid)
+
+		err := testRepo.UpdateStrategy(portfolioA.Uuid, "first", func(strategy *types.Strategy) error {
+			strategy.OpenOffers = append(strategy.OpenOffers, types.Offer{ClientOrderId: "open"})
+
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if getRowID(portfolioA.Uuid) == rowA {
+			t.Errorf("Expected the changed strategy to be rewritten")
+		}
+
+		if getRowID(portfolioB.Uuid) != rowB {
+			t.Errorf("Expected the unchanged strategy to be left alone")
+		}
+
+		if strategy, _ := testRepo.GetStrategy(portfolioA.Uuid); strategy == nil || len(strategy.OpenOffers) != 1 {
+			t.Errorf("Expected the offer to be saved, found %+v", strategy)
+		}
+
+		var snapshots int
+		testRepo.db.QueryRow("SELECT COUNT(*) FROM snapshots").Scan(&snapshots)
+
+		err = testRepo.Update(func(currentState *types.State) error {
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		var snapshotsAfter int
+		testRepo.db.QueryRow("SELECT COUNT(*) FROM snapshots").Scan(&snapshotsAfter)
+
+		if snapshotsAfter != snapshots {
+			t.Errorf("Expected no snapshot without changes, found %d then %d", snapshots, snapshotsAfter)
+		}
+	})
+
+	t.Run("Keeps a bounded snapshot history", func(t *testing.T) {
+		testRepo := setup()
+		testRepo.maxSnapshots = 2
+		portfolio := types.Portfolio{Name: "a", Uuid: "portfolio-a"}
+
+		for _, id := range []string{"first", "second", "third", "fourth"} {
+			err := testRepo.UpsertStrategy(portfolio, &types.Strategy{Id: id, Name: types.HODL})
+
+			if err != nil {
+				t.Fatalf("Unexpected error\n%v", err)
+			}
+		}
+
+		var snapshots int
+		testRepo.db.QueryRow("SELECT COUNT(*) FROM snapshots").Scan(&snapshots)
+
+		if snapshots != 2 {
+			t.Errorf("Expected the 2 latest snapshots to be kept, found %d", snapshots)
+		}
+
+		testRepo.snapshotInterval = time.Hour
+		testRepo.UpsertStrategy(portfolio, &types.Strategy{Id: "fifth", Name: types.HODL})
+
+		var latest string
+		testRepo.db.QueryRow("SELECT state FROM snapshots ORDER BY id DESC LIMIT 1").Scan(&latest)
+
+		if strings.Contains(latest, "fifth") {
+			t.Errorf("Expected no snapshot within the snapshot interval")
+		}
+	})
+
+	t.Run("Migrates plans saved at an older version", func(t *testing.T) {
+		testRepo := setup()
+		pathToBackup := testRepo.filepath + ".v0.bak"
+
+		t.Cleanup(func() {
+			os.Remove(pathToBackup)
+		})
+
+		// Saved before strategies had ids
+		for _, statement := range []string{
+			"INSERT INTO state (id, schema_version, last_updated) VALUES (1, 0, '2024-01-01T00:00:00Z')",
+			"INSERT INTO portfolios (uuid, position, name, type, deleted) VALUES ('portfolio-a', 0, 'a', 'DEFAULT', 0)",
+			`INSERT INTO strategies (portfolio_uuid, position, is_current, strategy_id, name, currency, quote_currency, plan)
+				VALUES ('portfolio-a', 0, 1, '', 'DCA', 'BTC', 'USD', '{"name": "DCA", "dca": {"amount_per_buy": "40.5"}}')`,
+		} {
+			if _, err := testRepo.db.Exec(statement); err != nil {
+				t.Fatalf("Failed to save old state\n%v\n", err)
+			}
+		}
+
+		report, err := testRepo.Migrate(true)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if report.FromVersion != 0 || report.ToVersion != CurrentSchemaVersion || len(report.Migrations) != 1 || len(report.Migrations[0].Changes) != 1 {
+			t.Errorf("Unexpected report: %+v", report)
+		}
+
+		var version int
+		testRepo.db.QueryRow("SELECT schema_version FROM state").Scan(&version)
+
+		if version != 0 {
+			t.Errorf("Expected a dry run to leave the database alone, found version %d", version)
+		}
+
+		_, err = testRepo.Migrate(false)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		var strategyID string
+		testRepo.db.QueryRow("SELECT schema_version FROM state").Scan(&version)
+		testRepo.db.QueryRow("SELECT strategy_id FROM strategies").Scan(&strategyID)
+		strategy, _ := testRepo.GetStrategy("portfolio-a")
+
+		if version != CurrentSchemaVersion || strategyID == "" || strategy == nil || strategy.Id != strategyID {
+			t.Errorf("Expected the plan to be rewritten at version %d with an id, found version %d and %+v", CurrentSchemaVersion, version, strategy)
+		}
+
+		if strategy != nil && (strategy.DCA == nil || strategy.DCA.AmountPerBuy.String() != "40.5") {
+			t.Errorf("Expected the DCA plan to be kept, found %+v", strategy.DCA)
+		}
+
+		if _, err = os.Stat(pathToBackup); err != nil {
+			t.Errorf("Expected the old database to be backed up\n%v", err)
+		}
+
+		report, _ = testRepo.Migrate(true)
+
+		if len(report.Migrations) != 0 {
+			t.Errorf("Expected migrated state to be up to date, found %+v", report)
+		}
+	})
+
+	t.Run("Reads state while an update holds the write lock", func(t *testing.T) {
+		testRepo := setup()
+		testRepo.UpsertStrategy(types.Portfolio{Name: "a", Uuid: "portfolio-a"}, &types.Strategy{Id: "first", Name: types.HODL})
+
+		updating := make(chan bool)
+		release := make(chan bool)
+		read := make(chan error, 1)
+
+		go testRepo.Update(func(currentState *types.State) error {
+			updating <- true
+			<-release
+
+			return ErrNoChanges
+		})
+
+		<-updating
+
+		go func() {
+			_, err := testRepo.GetState()
+			read <- err
+		}()
+
+		select {
+		case err := <-read:
+			if err != nil {
+				t.Errorf("Unexpected error\n%v", err)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Expected state to be read without waiting for the update")
+		}
+
+		release <- true
+	})
+
+	t.Run("Refuses state from a newer version", func(t *testing.T) {
+		testRepo := setup()
+		testRepo.db.Exec("INSERT INTO state (id, schema_version, last_updated) VALUES (1, ?, '2024-01-01T00:00:00Z')", CurrentSchemaVersion+1)
+
+		_, err := testRepo.GetState()
+
+		if err == nil || !strings.Contains(err.Error(), "upgrade the server") {
+			t.Errorf("Expected an error asking to upgrade the server\nActual: %v", err)
+		}
+	})
+}
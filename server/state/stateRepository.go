@@ -2,16 +2,23 @@ package state
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"path"
+	"sync"
 	"time"
 
 	"github.com/iPopcorn/investment-manager/types"
 	"github.com/iPopcorn/investment-manager/util"
 )
 
+// StateRepository stores state as JSON in a single file. Writes go through Update, which holds an in-process
+// mutex and a lock on the file so strategies, the reconciler and other servers sharing the file
+// take turns, and replaces the file atomically so a crash can't leave it truncated.
 type StateRepository struct {
 	filename string
+	mu       sync.Mutex
 }
 
 func StateRepositoryFactory(filename string) *StateRepository {
@@ -30,42 +37,154 @@ func StateRepositoryFactory(filename string) *StateRepository {
 
 func (r *StateRepository) GetState() (*types.State, error) {
 	location := "StateRepository.GetState()\n"
-	filepath, err := util.GetPathToFile("/server/state", r.filename)
+	filepath, err := util.GetPathToDataFile(r.filename)
 
 	if err != nil {
 		fmt.Printf(location+"Failed to get path to file\n%v\n", err)
 		return nil, err
 	}
 
-	data, err := os.ReadFile(filepath)
+	currentState, _, err := readState(location, filepath)
+
+	return currentState, err
+}
+
+// Save replaces the whole of state with newState.
+func (r *StateRepository) Save(newState types.State) error {
+	return r.Update(func(currentState *types.State) error {
+		*currentState = newState
+
+		return nil
+	})
+}
+
+// Update reads state, lets fn change it, then writes it back, without any other Update running
+// in between. State is initialized if there isn't any yet, and migrated if it's from an older
+// version. If fn returns an error nothing is written, ErrNoChanges isn't passed on.
+func (r *StateRepository) Update(fn func(*types.State) error) error {
+	location := "StateRepository.Update()\n"
+	filepath, unlock, err := r.lock(location)
 
 	if err != nil {
-		fmt.Printf(location+"Failed to read file\n%v\n", err)
-		return nil, err
+		return err
 	}
 
-	var state types.State
+	defer unlock()
 
-	err = json.Unmarshal(data, &state)
+	currentState, report, err := readState(location, filepath)
+
+	if errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("No state found, initializing\n")
+		currentState, err = r.InitState(), nil
+	}
 
 	if err != nil {
-		fmt.Printf(location+"Failed to de-serialize state.\nGiven: %s\n%v\n", string(data), err)
+		return err
+	}
+
+	err = fn(currentState)
+	migrated := report != nil && len(report.Migrations) > 0
+
+	// Migrated state is written even without changes, so it's only migrated once
+	if errors.Is(err, ErrNoChanges) && !migrated {
+		return nil
+	}
+
+	if err != nil && !errors.Is(err, ErrNoChanges) {
+		return err
+	}
+
+	return writeState(location, filepath, currentState, report)
+}
+
+// Migrate brings the state file up to CurrentSchemaVersion, backing up the old file first.
+// With dryRun nothing is written, the report says what would change.
+func (r *StateRepository) Migrate(dryRun bool) (*MigrationReport, error) {
+	location := "StateRepository.Migrate()\n"
+	filepath, unlock, err := r.lock(location)
 
+	if err != nil {
 		return nil, err
 	}
 
-	return &state, nil
+	defer unlock()
+
+	currentState, report, err := readState(location, filepath)
+
+	if err != nil || dryRun || len(report.Migrations) == 0 {
+		return report, err
+	}
+
+	return report, writeState(location, filepath, currentState, report)
 }
 
-func (r *StateRepository) Save(newState types.State) error {
-	location := "StateRepository.Save()\n"
-	filepath, err := util.GetPathToFile("/server/state", r.filename)
+// lock returns the path to the state file once this process has it to itself.
+func (r *StateRepository) lock(location string) (string, func(), error) {
+	filepath, err := util.GetPathToDataFile(r.filename)
 
 	if err != nil {
 		fmt.Printf(location+"Failed to get path to file\n%v\n", err)
-		return err
+		return "", nil, err
+	}
+
+	r.mu.Lock()
+
+	unlockFile, err := lockFile(filepath + ".lock")
+
+	if err != nil {
+		r.mu.Unlock()
+		return "", nil, fmt.Errorf(location+"Failed to lock state\n%v\n", err)
+	}
+
+	return filepath, func() {
+		unlockFile()
+		r.mu.Unlock()
+	}, nil
+}
+
+// readState reads and migrates the state file, the report is nil if there's no file.
+func readState(location, filepath string) (*types.State, *MigrationReport, error) {
+	data, err := os.ReadFile(filepath)
+
+	if err != nil {
+		fmt.Printf(location+"Failed to read file\n%v\n", err)
+		return nil, nil, err
+	}
+
+	migrated, report, err := migrateState(data)
+
+	if err != nil {
+		fmt.Printf(location+"Failed to migrate state\n%v\n", err)
+		return nil, report, err
+	}
+
+	var state types.State
+
+	err = json.Unmarshal(migrated, &state)
+
+	if err != nil {
+		fmt.Printf(location+"Failed to de-serialize state.\nGiven: %s\n%v\n", string(migrated), err)
+
+		return nil, report, err
+	}
+
+	return &state, report, nil
+}
+
+// writeState replaces the state file, keeping a copy of it first if it was migrated.
+func writeState(location, filepath string, newState *types.State, report *MigrationReport) error {
+	if report != nil && len(report.Migrations) > 0 {
+		backup := fmt.Sprintf("%s.v%d.bak", filepath, report.FromVersion)
+		err := copyFile(filepath, backup)
+
+		if err != nil {
+			return fmt.Errorf(location+"Failed to back up state before migrating it\n%v\n", err)
+		}
+
+		fmt.Printf("Migrated state from version %d to %d, the old state is in %s\n", report.FromVersion, report.ToVersion, backup)
 	}
 
+	newState.SchemaVersion = CurrentSchemaVersion
 	data, err := json.Marshal(newState)
 
 	if err != nil {
@@ -73,14 +192,79 @@ func (r *StateRepository) Save(newState types.State) error {
 		return err
 	}
 
-	return os.WriteFile(filepath, data, 0666)
+	return writeFileAtomic(filepath, data)
+}
+
+func copyFile(from, to string) error {
+	data, err := os.ReadFile(from)
+
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(to, data)
+}
+
+// writeFileAtomic writes data to a temporary file next to filepath, then renames it over filepath,
+// so readers see either the old file or the new one and never part of either.
+func writeFileAtomic(filepath string, data []byte) error {
+	tmp, err := os.CreateTemp(path.Dir(filepath), path.Base(filepath)+".tmp-*")
+
+	if err != nil {
+		return fmt.Errorf("Failed to create temporary file\n%v\n", err)
+	}
+
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+
+	if err == nil {
+		err = tmp.Sync()
+	}
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return fmt.Errorf("Failed to write temporary file\n%v\n", err)
+	}
+
+	err = os.Chmod(tmp.Name(), 0666)
+
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), filepath)
 }
 
 func (r *StateRepository) InitState() *types.State {
 	initialState := &types.State{
-		LastUpdated: time.Now().Format(time.RFC3339),
-		Portfolios:  []types.Portfolio{},
+		SchemaVersion: CurrentSchemaVersion,
+		LastUpdated:   time.Now().Format(time.RFC3339),
+		Portfolios:    []types.Portfolio{},
 	}
 
 	return initialState
 }
+
+func (r *StateRepository) GetPortfolio(portfolioUUID string) (*types.Portfolio, error) {
+	return getPortfolio(r, portfolioUUID)
+}
+
+func (r *StateRepository) GetStrategy(portfolioUUID string) (*types.Strategy, error) {
+	return getStrategy(r, portfolioUUID)
+}
+
+func (r *StateRepository) UpsertStrategy(portfolio types.Portfolio, strategy *types.Strategy) error {
+	return upsertStrategy(r, portfolio, strategy)
+}
+
+func (r *StateRepository) UpdateStrategy(portfolioUUID, strategyID string, fn func(*types.Strategy) error) error {
+	return updateStrategy(r, portfolioUUID, strategyID, fn)
+}
+
+func (r *StateRepository) ArchiveStrategy(portfolioUUID string) error {
+	return archiveStrategy(r, portfolioUUID)
+}
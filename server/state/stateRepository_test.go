@@ -2,17 +2,48 @@ package state
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"os"
+	"path/filepath"
 	"reflect"
+	"sync"
 	"testing"
 
 	"github.com/iPopcorn/investment-manager/types"
 	"github.com/iPopcorn/investment-manager/util"
 )
 
+// TestMain keeps test state out of the real data directory, starting from the fixture in testdata.
+func TestMain(m *testing.M) {
+	dataDir, err := os.MkdirTemp("", "investment-manager-test-")
+
+	if err != nil {
+		log.Fatalf("Failed to create data directory\n%v\n", err)
+	}
+
+	fixture, err := os.ReadFile(filepath.Join("testdata", "test-state.json"))
+
+	if err != nil {
+		log.Fatalf("Failed to read fixture\n%v\n", err)
+	}
+
+	err = os.WriteFile(filepath.Join(dataDir, "test-state.json"), fixture, 0666)
+
+	if err != nil {
+		log.Fatalf("Failed to copy fixture\n%v\n", err)
+	}
+
+	util.SetDataDir(dataDir)
+	code := m.Run()
+	os.RemoveAll(dataDir)
+	os.Exit(code)
+}
+
 func TestStateRepository(t *testing.T) {
 	// Arrange
-	pathToTestFile, err := util.GetPathToFile("/server/state", "test-state.json")
+	pathToTestFile, err := util.GetPathToDataFile("test-state.json")
 
 	if err != nil {
 		t.Fatalf("Failed to get path to file\n%v\n", err)
@@ -55,7 +86,7 @@ func TestStateRepository(t *testing.T) {
 	AssertStateEqual(&expectedState, actualState, t)
 
 	// Clean up
-	pathToCreatedFile, err := util.GetPathToFile("/server/state", "test-save-state.json")
+	pathToCreatedFile, err := util.GetPathToDataFile("test-save-state.json")
 
 	err = os.Remove(pathToCreatedFile)
 	if err != nil {
@@ -129,3 +160,266 @@ func AssertStringEqual(expected, actual string, t *testing.T) {
 		t.Errorf("Strings do not match\nExpected: %q\nActual: %q\n", expected, actual)
 	}
 }
+
+func TestUpsertStrategy(t *testing.T) {
+	const filename = "test-upsert-state.json"
+
+	portfolioA := types.Portfolio{Name: "a", Uuid: "portfolio-a"}
+	portfolioB := types.Portfolio{Name: "b", Uuid: "portfolio-b"}
+
+	setup := func() *StateRepository {
+		pathToFile, _ := util.GetPathToDataFile(filename)
+		os.Remove(pathToFile)
+
+		return StateRepositoryFactory(filename)
+	}
+
+	t.Cleanup(func() {
+		pathToFile, _ := util.GetPathToDataFile(filename)
+		os.Remove(pathToFile)
+	})
+
+	t.Run("Keeps the strategies of other portfolios", func(t *testing.T) {
+		testRepo := setup()
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "strategy-a", Name: types.HODL})
+		err := testRepo.UpsertStrategy(portfolioB, &types.Strategy{Id: "strategy-b", Name: types.DCA})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		strategyA, _ := testRepo.GetStrategy(portfolioA.Uuid)
+		strategyB, _ := testRepo.GetStrategy(portfolioB.Uuid)
+
+		if strategyA == nil || strategyA.Id != "strategy-a" {
+			t.Errorf("Expected portfolio a to keep its strategy, found %+v", strategyA)
+		}
+
+		if strategyB == nil || strategyB.Id != "strategy-b" {
+			t.Errorf("Expected portfolio b to have its strategy, found %+v", strategyB)
+		}
+	})
+
+	t.Run("Archives the current strategy when a new one starts", func(t *testing.T) {
+		testRepo := setup()
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL, ClosedOffers: []types.Offer{{ClientOrderId: "last"}}})
+		err := testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "second", Name: types.DCA})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		portfolio, _ := testRepo.GetPortfolio(portfolioA.Uuid)
+
+		if portfolio.CurrentStrategy == nil || portfolio.CurrentStrategy.Id != "second" {
+			t.Fatalf("Expected the new strategy to be current, found %+v", portfolio.CurrentStrategy)
+		}
+
+		if portfolio.PreviousStrategies == nil || len(*portfolio.PreviousStrategies) != 1 {
+			t.Fatalf("Expected 1 previous strategy, found %+v", portfolio.PreviousStrategies)
+		}
+
+		previous := (*portfolio.PreviousStrategies)[0]
+
+		if previous.Id != "first" || len(previous.ClosedOffers) != 1 {
+			t.Errorf("Expected the first strategy to be archived, found %+v", previous)
+		}
+	})
+
+	t.Run("Refuses to archive a strategy with open offers", func(t *testing.T) {
+		testRepo := setup()
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.GRID, OpenOffers: []types.Offer{{ClientOrderId: "resting"}}})
+		err := testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "second", Name: types.DCA})
+
+		if !errors.Is(err, ErrOpenOffers) {
+			t.Errorf("Expected ErrOpenOffers replacing the strategy, got %v", err)
+		}
+
+		err = testRepo.ArchiveStrategy(portfolioA.Uuid)
+
+		if !errors.Is(err, ErrOpenOffers) {
+			t.Errorf("Expected ErrOpenOffers archiving the strategy, got %v", err)
+		}
+
+		strategy, _ := testRepo.GetStrategy(portfolioA.Uuid)
+
+		if strategy == nil || strategy.Id != "first" {
+			t.Errorf("Expected the strategy with open offers to stay current, found %+v", strategy)
+		}
+	})
+
+	t.Run("Refuses to make an archived strategy current again", func(t *testing.T) {
+		testRepo := setup()
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL})
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "second", Name: types.DCA})
+		err := testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL})
+
+		if err == nil {
+			t.Fatalf("Expected an error saving an archived strategy")
+		}
+
+		strategy, _ := testRepo.GetStrategy(portfolioA.Uuid)
+
+		if strategy == nil || strategy.Id != "second" {
+			t.Errorf("Expected the new strategy to stay current, found %+v", strategy)
+		}
+	})
+
+	t.Run("Archives a strategy on request", func(t *testing.T) {
+		testRepo := setup()
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL})
+		err := testRepo.ArchiveStrategy(portfolioA.Uuid)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		portfolio, _ := testRepo.GetPortfolio(portfolioA.Uuid)
+
+		if portfolio.CurrentStrategy != nil || portfolio.PreviousStrategies == nil || len(*portfolio.PreviousStrategies) != 1 {
+			t.Errorf("Expected the strategy to be archived, found %+v", portfolio)
+		}
+	})
+
+	t.Run("Only changes what an update touches", func(t *testing.T) {
+		testRepo := setup()
+
+		running := &types.Strategy{Id: "first", Name: types.DCA, Protection: &types.Protection{StopLossPercent: 10}}
+		testRepo.UpsertStrategy(portfolioA, running)
+
+		// The protection monitor triggers while the strategy is running with its own copy
+		testRepo.UpdateStrategy(portfolioA.Uuid, "first", func(saved *types.Strategy) error {
+			saved.Protection.Triggered = types.StopLoss
+
+			return nil
+		})
+
+		err := testRepo.UpdateStrategy(portfolioA.Uuid, running.Id, func(saved *types.Strategy) error {
+			saved.OpenOffers = append(saved.OpenOffers, types.Offer{ClientOrderId: "buy"})
+
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		strategy, _ := testRepo.GetStrategy(portfolioA.Uuid)
+
+		if strategy.Protection.Triggered != types.StopLoss {
+			t.Errorf("Expected the trigger to be kept, found %+v", strategy.Protection)
+		}
+
+		if len(strategy.OpenOffers) != 1 {
+			t.Errorf("Expected the offer to be added, found %+v", strategy.OpenOffers)
+		}
+	})
+
+	t.Run("Updates an archived strategy and fails for an unknown one", func(t *testing.T) {
+		testRepo := setup()
+
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "first", Name: types.HODL})
+		testRepo.UpsertStrategy(portfolioA, &types.Strategy{Id: "second", Name: types.DCA})
+
+		err := testRepo.UpdateStrategy(portfolioA.Uuid, "first", func(saved *types.Strategy) error {
+			saved.ClosedOffers = append(saved.ClosedOffers, types.Offer{ClientOrderId: "last"})
+
+			return nil
+		})
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		portfolio, _ := testRepo.GetPortfolio(portfolioA.Uuid)
+
+		if previous := (*portfolio.PreviousStrategies)[0]; len(previous.ClosedOffers) != 1 {
+			t.Errorf("Expected the archived strategy to be updated, found %+v", previous)
+		}
+
+		err = testRepo.UpdateStrategy(portfolioA.Uuid, "missing", func(saved *types.Strategy) error { return nil })
+
+		if err == nil {
+			t.Errorf("Expected an error for a strategy that isn't in state")
+		}
+	})
+}
+
+func TestUpdate(t *testing.T) {
+	const filename = "test-update-state.json"
+
+	pathToFile, _ := util.GetPathToDataFile(filename)
+	os.Remove(pathToFile)
+
+	t.Cleanup(func() {
+		os.Remove(pathToFile)
+		os.Remove(pathToFile + ".lock")
+	})
+
+	t.Run("Concurrent updates do not clobber each other", func(t *testing.T) {
+		// Two repositories share the file, like two servers would
+		testRepos := []*StateRepository{StateRepositoryFactory(filename), StateRepositoryFactory(filename)}
+		var wg sync.WaitGroup
+
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+
+			go func(i int) {
+				defer wg.Done()
+
+				err := testRepos[i%2].Update(func(currentState *types.State) error {
+					currentState.Portfolios = append(currentState.Portfolios, types.Portfolio{Uuid: fmt.Sprintf("portfolio-%d", i)})
+
+					return nil
+				})
+
+				if err != nil {
+					t.Errorf("Unexpected error\n%v", err)
+				}
+			}(i)
+		}
+
+		wg.Wait()
+
+		actualState, err := testRepos[0].GetState()
+
+		if err != nil {
+			t.Fatalf("Failed to get state\n%v\n", err)
+		}
+
+		if len(actualState.Portfolios) != 20 {
+			t.Errorf("Expected 20 portfolios, found %d", len(actualState.Portfolios))
+		}
+	})
+
+	t.Run("Leaves state as it is when the update fails", func(t *testing.T) {
+		testRepo := StateRepositoryFactory(filename)
+
+		err := testRepo.Update(func(currentState *types.State) error {
+			currentState.Portfolios = nil
+
+			return fmt.Errorf("Update failed")
+		})
+
+		if err == nil {
+			t.Fatalf("Expected error but did not receive one")
+		}
+
+		actualState, _ := testRepo.GetState()
+
+		if len(actualState.Portfolios) != 20 {
+			t.Errorf("Expected state to be unchanged, found %d portfolios", len(actualState.Portfolios))
+		}
+
+		matches, _ := filepath.Glob(pathToFile + ".tmp-*")
+
+		if len(matches) != 0 {
+			t.Errorf("Expected temporary files to be cleaned up, found %v", matches)
+		}
+	})
+}
@@ -0,0 +1,204 @@
+package types
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// decimalPlaces is the number of digits a Decimal keeps after the point.
+// Coinbase increments go down to 8 decimal places, so 18 leaves room for intermediate results.
+const decimalPlaces = 18
+
+var decimalScale = new(big.Int).Exp(big.NewInt(10), big.NewInt(decimalPlaces), nil)
+
+// Decimal is a fixed-point number used for money and order sizes.
+// float64 can't represent most prices exactly, which caused penny drift in balances
+// and order sizes the exchange rejected for not matching the product's increments.
+// The zero value is 0 and Decimals are never modified in place.
+type Decimal struct {
+	units *big.Int // value * 10^decimalPlaces, nil means zero
+}
+
+// ParseDecimal accepts plain decimals ("0.01") and scientific notation ("1e-8").
+// Digits past decimalPlaces are truncated.
+func ParseDecimal(s string) (Decimal, error) {
+	value, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+
+	if !ok {
+		return Decimal{}, fmt.Errorf("Invalid decimal\nGiven: %q\n", s)
+	}
+
+	units := new(big.Int).Mul(value.Num(), decimalScale)
+	units.Quo(units, value.Denom())
+
+	return Decimal{units: units}, nil
+}
+
+// MustParseDecimal is ParseDecimal for values known to be valid, it panics otherwise.
+func MustParseDecimal(s string) Decimal {
+	d, err := ParseDecimal(s)
+
+	if err != nil {
+		panic(err)
+	}
+
+	return d
+}
+
+// NewDecimalFromFloat uses the shortest representation of f, so 0.1 becomes exactly 0.1.
+func NewDecimalFromFloat(f float64) Decimal {
+	return MustParseDecimal(strconv.FormatFloat(f, 'f', -1, 64))
+}
+
+func NewDecimalFromInt(i int64) Decimal {
+	return Decimal{units: new(big.Int).Mul(big.NewInt(i), decimalScale)}
+}
+
+func (d Decimal) getUnits() *big.Int {
+	if d.units == nil {
+		return new(big.Int)
+	}
+
+	return d.units
+}
+
+func (d Decimal) Add(other Decimal) Decimal {
+	return Decimal{units: new(big.Int).Add(d.getUnits(), other.getUnits())}
+}
+
+func (d Decimal) Sub(other Decimal) Decimal {
+	return Decimal{units: new(big.Int).Sub(d.getUnits(), other.getUnits())}
+}
+
+// Mul truncates the result to decimalPlaces.
+func (d Decimal) Mul(other Decimal) Decimal {
+	units := new(big.Int).Mul(d.getUnits(), other.getUnits())
+
+	return Decimal{units: units.Quo(units, decimalScale)}
+}
+
+// Div truncates the result to decimalPlaces and panics if other is zero, the same as integer division.
+func (d Decimal) Div(other Decimal) Decimal {
+	units := new(big.Int).Mul(d.getUnits(), decimalScale)
+
+	return Decimal{units: units.Quo(units, other.getUnits())}
+}
+
+func (d Decimal) Neg() Decimal {
+	return Decimal{units: new(big.Int).Neg(d.getUnits())}
+}
+
+// Cmp returns -1 if d < other, 0 if d == other and 1 if d > other.
+func (d Decimal) Cmp(other Decimal) int {
+	return d.getUnits().Cmp(other.getUnits())
+}
+
+func (d Decimal) Equal(other Decimal) bool {
+	return d.Cmp(other) == 0
+}
+
+func (d Decimal) LessThan(other Decimal) bool {
+	return d.Cmp(other) < 0
+}
+
+func (d Decimal) GreaterThan(other Decimal) bool {
+	return d.Cmp(other) > 0
+}
+
+// Sign returns -1, 0 or 1.
+func (d Decimal) Sign() int {
+	return d.getUnits().Sign()
+}
+
+func (d Decimal) IsZero() bool {
+	return d.Sign() == 0
+}
+
+func MinDecimal(a, b Decimal) Decimal {
+	if a.LessThan(b) {
+		return a
+	}
+
+	return b
+}
+
+// RoundDown rounds towards negative infinity to a multiple of increment, e.g. a product's base_increment.
+// A zero increment leaves the value unchanged.
+func (d Decimal) RoundDown(increment Decimal) Decimal {
+	if increment.Sign() <= 0 {
+		return d
+	}
+
+	// Div is euclidean, with a positive increment it rounds down for negative values too
+	steps := new(big.Int).Div(d.getUnits(), increment.getUnits())
+
+	return Decimal{units: steps.Mul(steps, increment.getUnits())}
+}
+
+// RoundUp rounds towards positive infinity to a multiple of increment.
+// A zero increment leaves the value unchanged.
+func (d Decimal) RoundUp(increment Decimal) Decimal {
+	roundedDown := d.RoundDown(increment)
+
+	if roundedDown.Equal(d) {
+		return d
+	}
+
+	return roundedDown.Add(increment)
+}
+
+func (d Decimal) Float64() float64 {
+	f, _ := new(big.Rat).SetFrac(d.getUnits(), decimalScale).Float64()
+
+	return f
+}
+
+// String formats d without trailing zeros, e.g. "0.1" or "25".
+func (d Decimal) String() string {
+	s := d.StringFixed(decimalPlaces)
+
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+
+	if s == "-0" {
+		return "0"
+	}
+
+	return s
+}
+
+// StringFixed formats d with exactly places digits after the point, rounding half away from zero.
+func (d Decimal) StringFixed(places int) string {
+	return new(big.Rat).SetFrac(d.getUnits(), decimalScale).FloatString(places)
+}
+
+// MarshalJSON writes a string, the same as Coinbase does for sizes and prices.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(d.String())), nil
+}
+
+// UnmarshalJSON accepts strings and numbers since Coinbase uses both.
+// Empty strings and null are zero.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	data = bytes.Trim(data, `"`)
+
+	if len(data) == 0 || string(data) == "null" {
+		*d = Decimal{}
+		return nil
+	}
+
+	parsed, err := ParseDecimal(string(data))
+
+	if err != nil {
+		return err
+	}
+
+	*d = parsed
+
+	return nil
+}
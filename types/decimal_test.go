@@ -0,0 +1,82 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestDecimal(t *testing.T) {
+	t.Run("Adds without float drift", func(t *testing.T) {
+		sum := NewDecimalFromFloat(0.1).Add(NewDecimalFromFloat(0.2))
+
+		if sum.String() != "0.3" {
+			t.Errorf("Expected: %q Actual: %q", "0.3", sum.String())
+		}
+	})
+
+	t.Run("Parses scientific notation", func(t *testing.T) {
+		d := MustParseDecimal("1e-8")
+
+		if d.String() != "0.00000001" {
+			t.Errorf("Expected: %q Actual: %q", "0.00000001", d.String())
+		}
+	})
+
+	t.Run("Rounds to an increment", func(t *testing.T) {
+		size := MustParseDecimal("0.123456789")
+		increment := MustParseDecimal("0.00001")
+
+		if actual := size.RoundDown(increment).String(); actual != "0.12345" {
+			t.Errorf("RoundDown Expected: %q Actual: %q", "0.12345", actual)
+		}
+
+		if actual := size.RoundUp(increment).String(); actual != "0.12346" {
+			t.Errorf("RoundUp Expected: %q Actual: %q", "0.12346", actual)
+		}
+
+		if actual := MustParseDecimal("-1.5").RoundDown(NewDecimalFromInt(1)).String(); actual != "-2" {
+			t.Errorf("RoundDown negative Expected: %q Actual: %q", "-2", actual)
+		}
+
+		if actual := size.RoundDown(Decimal{}); !actual.Equal(size) {
+			t.Errorf("Expected a zero increment to leave %s unchanged, got %s", size, actual)
+		}
+	})
+
+	t.Run("Divides and truncates", func(t *testing.T) {
+		quotient := NewDecimalFromInt(100).Div(NewDecimalFromInt(3))
+
+		if actual := quotient.StringFixed(2); actual != "33.33" {
+			t.Errorf("Expected: %q Actual: %q", "33.33", actual)
+		}
+	})
+
+	t.Run("Reads JSON strings, numbers and empty values", func(t *testing.T) {
+		var values struct {
+			String Decimal `json:"string"`
+			Number Decimal `json:"number"`
+			Empty  Decimal `json:"empty"`
+			Null   Decimal `json:"null"`
+		}
+
+		err := json.Unmarshal([]byte(`{"string": "2349.55", "number": 0.0675, "empty": "", "null": null}`), &values)
+
+		if err != nil {
+			t.Fatalf("Unexpected error\n%v", err)
+		}
+
+		if values.String.String() != "2349.55" || values.Number.String() != "0.0675" {
+			t.Errorf("Unexpected values: %s %s", values.String, values.Number)
+		}
+
+		if !values.Empty.IsZero() || !values.Null.IsZero() {
+			t.Errorf("Expected empty values to be zero, got %s %s", values.Empty, values.Null)
+		}
+
+		serialized, _ := json.Marshal(values.String)
+
+		if string(serialized) != `"2349.55"` {
+			t.Errorf("Expected: %q Actual: %q", `"2349.55"`, string(serialized))
+		}
+	})
+}
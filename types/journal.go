@@ -0,0 +1,80 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type JournalEvent string
+
+const (
+	StrategyDecision JournalEvent = "strategy_decision" // A strategy chose to place an order
+	OrderPlaced      JournalEvent = "order_placed"
+	OrderFailed      JournalEvent = "order_failed" // The exchange rejected the order or couldn't be reached
+	FundsTransferred JournalEvent = "funds_transferred"
+	TransferFailed   JournalEvent = "transfer_failed"
+)
+
+// JournalEntry is one line of the journal. Entries are only ever appended, so together they
+// record why every order was placed and what the exchange said about it.
+type JournalEntry struct {
+	Time          string              `json:"time"` // RFC3339 Timestamp
+	Event         JournalEvent        `json:"event"`
+	PortfolioID   string              `json:"portfolio_id,omitempty"`
+	Strategy      StrategyName        `json:"strategy,omitempty"`
+	StrategyID    string              `json:"strategy_id,omitempty"`
+	ProductID     string              `json:"product_id,omitempty"`
+	Side          Side                `json:"side,omitempty"`
+	ClientOrderID string              `json:"client_order_id,omitempty"`
+	OrderID       string              `json:"order_id,omitempty"`
+	Inputs        *JournalInputs      `json:"inputs,omitempty"`   // What a strategy decision was based on
+	Order         *OrderConfiguration `json:"order,omitempty"`    // Order that was decided on or placed
+	Transfer      *TransferRequest    `json:"transfer,omitempty"` // Transfer that was requested
+	Response      json.RawMessage     `json:"response,omitempty"` // The exchange's response as it was
+	Error         string              `json:"error,omitempty"`
+}
+
+type JournalInputs struct {
+	BestBid            string `json:"best_bid,omitempty"`
+	BestAsk            string `json:"best_ask,omitempty"`
+	AvailableFunds     string `json:"available_funds,omitempty"`     // Quote currency available to the strategy
+	CommissionEstimate string `json:"commission_estimate,omitempty"` // Commission the order was sized to leave room for
+	Reason             string `json:"reason,omitempty"`              // Why the strategy placed the order
+}
+
+// JournalFilter selects journal entries, empty fields match everything.
+type JournalFilter struct {
+	PortfolioID string       `json:"portfolio_id,omitempty"`
+	Event       JournalEvent `json:"event,omitempty"`
+	ProductID   string       `json:"product_id,omitempty"`
+	Since       string       `json:"since,omitempty"` // RFC3339 Timestamp
+}
+
+func (f JournalFilter) Matches(entry JournalEntry) bool {
+	if f.PortfolioID != "" && entry.PortfolioID != f.PortfolioID {
+		return false
+	}
+
+	if f.Event != "" && entry.Event != f.Event {
+		return false
+	}
+
+	if f.ProductID != "" && entry.ProductID != f.ProductID {
+		return false
+	}
+
+	if f.Since != "" {
+		since, err := time.Parse(time.RFC3339, f.Since)
+		entryTime, entryErr := time.Parse(time.RFC3339, entry.Time)
+
+		if err == nil && entryErr == nil && entryTime.Before(since) {
+			return false
+		}
+	}
+
+	return true
+}
+
+type JournalResponse struct {
+	Entries []JournalEntry `json:"entries"`
+}
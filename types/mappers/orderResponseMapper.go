@@ -0,0 +1,23 @@
+package mappers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/iPopcorn/investment-manager/types"
+)
+
+func MapOrderResponse(httpResponse []byte) (*types.Order, error) {
+	var resp types.OrderResponse
+	err := json.Unmarshal(httpResponse, &resp)
+
+	if err != nil {
+		return nil, fmt.Errorf("Failed to map http response to object\n%v", err)
+	}
+
+	if resp.Order.OrderID == "" {
+		return nil, fmt.Errorf("Failed to map order from response\nGiven: %q\n", string(httpResponse))
+	}
+
+	return &resp.Order, nil
+}
@@ -30,5 +30,14 @@ func MapPortfolioDetailsResponse(httpResponse []byte) (*types.PortfolioDetailsRe
 		return nil, fmt.Errorf("Failed to map Portfolio details from response\nGiven: %q\n", string(httpResponse))
 	}
 
+	// Keep the positions empty rather than nil when the response leaves them out
+	if resp.Breakdown.PerpPositions == nil {
+		resp.Breakdown.PerpPositions = []types.PerpPositions{}
+	}
+
+	if resp.Breakdown.FuturesPositions == nil {
+		resp.Breakdown.FuturesPositions = []types.FuturesPositions{}
+	}
+
 	return &resp, nil
 }
@@ -0,0 +1,37 @@
+package types
+
+// PlaceOrderRequest describes a one-off order placed outside of a strategy.
+// Zero sizes and prices are not set.
+type PlaceOrderRequest struct {
+	PortfolioID string    `json:"portfolio_id"`
+	ProductID   string    `json:"product_id"`
+	Side        Side      `json:"side"`
+	OrderType   OrderType `json:"order_type"`
+	BaseSize    Decimal   `json:"base_size"`           // Amount of base currency to buy/sell
+	QuoteSize   Decimal   `json:"quote_size"`          // Amount of quote currency to spend, market orders only
+	LimitPrice  Decimal   `json:"limit_price"`         // Required by every order type except market
+	StopPrice   Decimal   `json:"stop_price"`          // Required by stop-limit orders
+	EndTime     string    `json:"end_time,omitempty"`  // RFC3339 Timestamp, required by GTD orders
+	PostOnly    bool      `json:"post_only,omitempty"` // Limit orders only
+	Preview     bool      `json:"preview"`             // If true the order is previewed but not placed
+}
+
+// Preview is always set, Order is only set once the order is placed.
+type PlaceOrderResponse struct {
+	Preview *CoinbaseOrderPreviewResponse `json:"preview"`
+	Order   *CoinbaseOrderPlacedResponse  `json:"order,omitempty"`
+}
+
+// OpenOrdersResponse lists a portfolio's open orders as the exchange and state.json see them.
+type OpenOrdersResponse struct {
+	Orders         []Order      `json:"orders"`          // Open orders reported by the exchange
+	StrategyName   StrategyName `json:"strategy_name"`   // Current strategy of the portfolio, if any
+	StrategyOffers []Offer      `json:"strategy_offers"` // Open offers of the current strategy
+}
+
+// CancelOrdersRequest cancels the given orders, or every open order in the portfolio when All is set.
+type CancelOrdersRequest struct {
+	PortfolioID string   `json:"portfolio_id"`
+	OrderIDs    []string `json:"order_ids,omitempty"`
+	All         bool     `json:"all,omitempty"`
+}
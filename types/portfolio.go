@@ -3,7 +3,13 @@ package types
 type TransferRequest struct {
 	SenderID   string
 	ReceiverID string
-	Amount     string
+	Amount     Decimal
+	Currency   string // Detected from the sender portfolio if empty
+}
+
+type TransferFundsResponse struct {
+	SenderID   string `json:"source_portfolio_uuid"`
+	ReceiverID string `json:"target_portfolio_uuid"`
 }
 
 type PortfolioResponse struct {
@@ -30,22 +36,23 @@ type Portfolio struct {
 
 type PortfolioDetailsResponse struct {
 	Breakdown Breakdown `json:"breakdown"`
+	Strategy  *Strategy `json:"strategy,omitempty"` // Current strategy from state, not part of the exchange's response
 }
 
 type Breakdown struct {
-	Portfolio         Portfolio         `json:"portfolio"`
-	PortfolioBalances PortfolioBalances `json:"portfolio_balances"`
-	SpotPositions     []SpotPositions   `json:"spot_positions"`
-	// PerpPositions     []PerpPositions    `json:"perp_positions"`    // TODO - implement
-	// FuturesPositions  []FuturesPositions `json:"futures_positions"` // TODO - implement
+	Portfolio         Portfolio          `json:"portfolio"`
+	PortfolioBalances PortfolioBalances  `json:"portfolio_balances"`
+	SpotPositions     []SpotPositions    `json:"spot_positions"`
+	PerpPositions     []PerpPositions    `json:"perp_positions"`    // Perpetual futures, held in INTX portfolios
+	FuturesPositions  []FuturesPositions `json:"futures_positions"` // Dated futures
 }
 
 type SpotPositions struct {
 	Asset                string  `json:"asset"`
 	AccountUuid          string  `json:"account_uuid"`
-	TotalBalanceFiat     float64 `json:"total_balance_fiat"`
-	TotalBalanceCrypto   float64 `json:"total_balance_crypto"`
-	AvailableToTradeFiat float64 `json:"available_to_trade_fiat"`
+	TotalBalanceFiat     Decimal `json:"total_balance_fiat"`
+	TotalBalanceCrypto   Decimal `json:"total_balance_crypto"`
+	AvailableToTradeFiat Decimal `json:"available_to_trade_fiat"`
 	Allocation           float64 `json:"allocation"`
 	OneDayChange         float64 `json:"one_day_change"`
 	CostBasis            Balance `json:"cost_basis"`
@@ -53,6 +60,56 @@ type SpotPositions struct {
 	IsCash               bool    `json:"is_cash"`
 }
 
+// PerpPositions is an open perpetual futures position.
+// Amounts are given in the user's native currency and in the currency the product is priced in.
+type PerpPositions struct {
+	ProductID             string        `json:"product_id"`
+	ProductUuid           string        `json:"product_uuid"`
+	Symbol                string        `json:"symbol"`
+	PositionSide          PositionSide  `json:"position_side"`
+	NetSize               Decimal       `json:"net_size"` // Contracts held, negative when short
+	Leverage              Decimal       `json:"leverage"`
+	Vwap                  NativeBalance `json:"vwap"` // Volume weighted average entry price
+	MarkPrice             NativeBalance `json:"mark_price"`
+	UnrealizedPnl         NativeBalance `json:"unrealized_pnl"`
+	LiquidationPrice      NativeBalance `json:"liquidation_price"`
+	PositionNotional      NativeBalance `json:"position_notional"`
+	MarginType            string        `json:"margin_type"` // MARGIN_TYPE_CROSS or MARGIN_TYPE_ISOLATED
+	LiquidationBuffer     Decimal       `json:"liquidation_buffer"`
+	LiquidationPercentage Decimal       `json:"liquidation_percentage"`
+	AssetImgUrl           string        `json:"asset_img_url"`
+}
+
+// FuturesPositions is an open position in a dated futures contract.
+type FuturesPositions struct {
+	ProductID       string       `json:"product_id"`
+	ProductName     string       `json:"product_name"`
+	UnderlyingAsset string       `json:"underlying_asset"`
+	Side            PositionSide `json:"side"`
+	Amount          Decimal      `json:"amount"`        // Number of contracts held
+	ContractSize    Decimal      `json:"contract_size"` // Amount of the underlying asset in each contract
+	AvgEntryPrice   Decimal      `json:"avg_entry_price"`
+	CurrentPrice    Decimal      `json:"current_price"`
+	UnrealizedPnl   Decimal      `json:"unrealized_pnl"`
+	NotionalValue   Decimal      `json:"notional_value"`
+	Expiry          string       `json:"expiry"` // RFC3339 Timestamp
+	Venue           string       `json:"venue"`
+	AssetImgUrl     string       `json:"asset_img_url"`
+}
+
+type PositionSide string
+
+const (
+	PositionSideLong  PositionSide = "FUTURES_POSITION_SIDE_LONG"
+	PositionSideShort PositionSide = "FUTURES_POSITION_SIDE_SHORT"
+)
+
+// NativeBalance is an amount converted to the user's currency alongside the raw amount.
+type NativeBalance struct {
+	UserNativeCurrency Balance `json:"userNativeCurrency"`
+	RawCurrency        Balance `json:"rawCurrency"`
+}
+
 type PortfolioBalances struct {
 	TotalBalance               Balance `json:"total_balance"`
 	TotalFuturesBalance        Balance `json:"total_futures_balance"`
@@ -63,6 +120,6 @@ type PortfolioBalances struct {
 }
 
 type Balance struct {
-	Value    string
+	Value    Decimal
 	Currency string
 }
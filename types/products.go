@@ -1,13 +1,48 @@
 package types
 
+import (
+	"fmt"
+	"strings"
+)
+
 type ProductResponse struct {
 	Products []Product `json:"products"`
 }
 
-// TODO: Add fields as needed (https://docs.cloud.coinbase.com/advanced-trade/reference/retailbrokerageapi_getproducts)
+// Product is a trading pair and the limits the exchange places on its orders.
+// See https://docs.cloud.coinbase.com/advanced-trade/reference/retailbrokerageapi_getproducts
+// Zero increments and sizes mean the exchange didn't report a limit.
 type Product struct {
-	ProductID string `json:"product_id"`
-	Price     string `json:"price"`
+	ProductID       string  `json:"product_id"`
+	Price           string  `json:"price"`
+	BaseCurrencyID  string  `json:"base_currency_id"`
+	QuoteCurrencyID string  `json:"quote_currency_id"`
+	BaseIncrement   Decimal `json:"base_increment"`   // Order base sizes must be a multiple of this
+	QuoteIncrement  Decimal `json:"quote_increment"`  // Order quote sizes must be a multiple of this
+	PriceIncrement  Decimal `json:"price_increment"`  // Order prices must be a multiple of this, the quote increment if not set
+	BaseMinSize     Decimal `json:"base_min_size"`    // Smallest base size an order can have
+	BaseMaxSize     Decimal `json:"base_max_size"`    // Largest base size an order can have
+	QuoteMinSize    Decimal `json:"quote_min_size"`   // Smallest quote value an order can have
+	QuoteMaxSize    Decimal `json:"quote_max_size"`   // Largest quote value an order can have
+	Status          string  `json:"status"`           // "online" when the product can be traded
+	TradingDisabled bool    `json:"trading_disabled"` // Set by Coinbase when trading is halted
+	IsDisabled      bool    `json:"is_disabled"`      // Set when the product is delisted
+	CancelOnly      bool    `json:"cancel_only"`      // Open orders can be cancelled but no new orders placed
+	LimitOnly       bool    `json:"limit_only"`       // Market orders are rejected
+	PostOnly        bool    `json:"post_only"`        // Only orders that make liquidity are accepted
+}
+
+const ProductStatusOnline = "online"
+
+// GetQuoteCurrency returns the currency the product is priced in, e.g. "GBP" for "ETH-GBP".
+func (p *Product) GetQuoteCurrency() string {
+	if p.QuoteCurrencyID != "" {
+		return p.QuoteCurrencyID
+	}
+
+	_, quoteCurrency, _ := strings.Cut(p.ProductID, "-")
+
+	return quoteCurrency
 }
 
 type BestBidAskResponse struct {
@@ -25,3 +60,177 @@ type Bid struct {
 	Price string `json:"price"`
 	Size  string `json:"size"`
 }
+
+// Coinbase doesn't allow decimal precision > 8, used when a product doesn't report an increment
+var defaultIncrement = MustParseDecimal("0.00000001")
+
+// RoundBaseSize rounds size down to the product's base increment so the exchange accepts it
+// and the order never needs more funds than were sized for.
+func (p *Product) RoundBaseSize(size Decimal) Decimal {
+	return size.RoundDown(getIncrement(p.BaseIncrement))
+}
+
+// RoundQuoteSize rounds a quote currency amount down to the product's quote increment.
+func (p *Product) RoundQuoteSize(size Decimal) Decimal {
+	return size.RoundDown(getIncrement(p.QuoteIncrement))
+}
+
+// RoundPrice rounds price to the product's price increment in our favour,
+// down when buying and up when selling.
+func (p *Product) RoundPrice(price Decimal, side Side) Decimal {
+	if side == SELL {
+		return price.RoundUp(p.getPriceIncrement())
+	}
+
+	return price.RoundDown(p.getPriceIncrement())
+}
+
+func (p *Product) getPriceIncrement() Decimal {
+	if p.PriceIncrement.Sign() > 0 {
+		return p.PriceIncrement
+	}
+
+	return getIncrement(p.QuoteIncrement)
+}
+
+func getIncrement(increment Decimal) Decimal {
+	if increment.Sign() <= 0 {
+		return defaultIncrement
+	}
+
+	return increment
+}
+
+// CheckTradable returns an error explaining why new orders can't be placed for the product.
+func (p *Product) CheckTradable() error {
+	if p.TradingDisabled || p.IsDisabled {
+		return fmt.Errorf("Trading is disabled for %s\n", p.ProductID)
+	}
+
+	if p.CancelOnly {
+		return fmt.Errorf("%s is in cancel only mode, open orders can be cancelled but no new orders placed\n", p.ProductID)
+	}
+
+	if p.Status != "" && p.Status != ProductStatusOnline {
+		return fmt.Errorf("%s is not online\nStatus: %q\n", p.ProductID, p.Status)
+	}
+
+	return nil
+}
+
+// ValidateOrder checks an order against the product's trading status, order type restrictions,
+// increments and size limits so it can be rejected before it's sent to the exchange.
+func (p *Product) ValidateOrder(config OrderConfiguration) error {
+	err := p.CheckTradable()
+
+	if err != nil {
+		return err
+	}
+
+	orderType, err := config.OrderType()
+
+	if err != nil {
+		return err
+	}
+
+	terms, err := getOrderTerms(config)
+
+	if err != nil {
+		return err
+	}
+
+	if p.LimitOnly && orderType == MarketOrder {
+		return fmt.Errorf("%s is in limit only mode, market orders are not accepted\n", p.ProductID)
+	}
+
+	if p.PostOnly && !terms.postOnly {
+		return fmt.Errorf("%s is in post only mode, only post only limit orders are accepted\n", p.ProductID)
+	}
+
+	if !terms.baseSize.IsZero() {
+		err = checkAmount("Base size", terms.baseSize, getIncrement(p.BaseIncrement), p.BaseMinSize, p.BaseMaxSize)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if !terms.quoteSize.IsZero() {
+		err = checkAmount("Quote size", terms.quoteSize, getIncrement(p.QuoteIncrement), p.QuoteMinSize, p.QuoteMaxSize)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, price := range []Decimal{terms.limitPrice, terms.stopPrice} {
+		if price.IsZero() {
+			continue
+		}
+
+		err = checkAmount("Price", price, p.getPriceIncrement(), Decimal{}, Decimal{})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	if terms.limitPrice.IsZero() {
+		return nil
+	}
+
+	// Limit orders are sized in base currency but the exchange also limits their value in quote currency
+	return checkAmount("Order value", terms.baseSize.Mul(terms.limitPrice), Decimal{}, p.QuoteMinSize, p.QuoteMaxSize)
+}
+
+// checkAmount ignores zero increments and limits.
+func checkAmount(name string, amount, increment, min, max Decimal) error {
+	if amount.Sign() <= 0 {
+		return fmt.Errorf("%s must be positive\nGiven: %s\n", name, amount)
+	}
+
+	if !amount.RoundDown(increment).Equal(amount) {
+		return fmt.Errorf("%s %s is not a multiple of the increment %s\n", name, amount, increment)
+	}
+
+	if min.Sign() > 0 && amount.LessThan(min) {
+		return fmt.Errorf("%s %s is below the minimum %s\n", name, amount, min)
+	}
+
+	if max.Sign() > 0 && amount.GreaterThan(max) {
+		return fmt.Errorf("%s %s is above the maximum %s\n", name, amount, max)
+	}
+
+	return nil
+}
+
+type orderTerms struct {
+	baseSize   Decimal
+	quoteSize  Decimal
+	limitPrice Decimal
+	stopPrice  Decimal
+	postOnly   bool
+}
+
+func getOrderTerms(config OrderConfiguration) (orderTerms, error) {
+	switch {
+	case config.MarketMarketIOC != nil:
+		return orderTerms{baseSize: config.MarketMarketIOC.BaseSize, quoteSize: config.MarketMarketIOC.QuoteSize}, nil
+	case config.SorLimitIOC != nil:
+		return orderTerms{baseSize: config.SorLimitIOC.BaseSize, limitPrice: config.SorLimitIOC.LimitPrice}, nil
+	case config.LimitLimitGTC != nil:
+		c := config.LimitLimitGTC
+		return orderTerms{baseSize: c.BaseSize, limitPrice: c.LimitPrice, postOnly: c.PostOnly}, nil
+	case config.LimitLimitGTD != nil:
+		c := config.LimitLimitGTD
+		return orderTerms{baseSize: c.BaseSize, limitPrice: c.LimitPrice, postOnly: c.PostOnly}, nil
+	case config.StopLimitStopLimitGTC != nil:
+		c := config.StopLimitStopLimitGTC
+		return orderTerms{baseSize: c.BaseSize, limitPrice: c.LimitPrice, stopPrice: c.StopPrice}, nil
+	case config.StopLimitStopLimitGTD != nil:
+		c := config.StopLimitStopLimitGTD
+		return orderTerms{baseSize: c.BaseSize, limitPrice: c.LimitPrice, stopPrice: c.StopPrice}, nil
+	}
+
+	return orderTerms{}, fmt.Errorf("No order configuration\n")
+}
@@ -6,4 +6,6 @@ const (
 	Portfolios      Route = "portfolios"
 	ExecuteStrategy Route = "execute-strategy"
 	TransferFunds   Route = "transfer-funds"
+	Orders          Route = "orders"
+	Journal         Route = "journal"
 )
@@ -1,6 +1,7 @@
 package types
 
 type State struct {
-	LastUpdated string      `json:"last_updated"`
-	Portfolios  []Portfolio `json:"portfolios"`
+	SchemaVersion int         `json:"schema_version"` // Bumped by every migration of the persisted state
+	LastUpdated   string      `json:"last_updated"`
+	Portfolios    []Portfolio `json:"portfolios"`
 }
@@ -1,16 +1,264 @@
 package types
 
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
 type ExecuteStrategyRequest struct {
-	Portfolio string            `json:"portfolio"`
-	Strategy  StrategyName      `json:"strategy"`
-	Currency  SupportedCurrency `json:"currency"`
+	Portfolio     string            `json:"portfolio"`
+	Strategy      StrategyName      `json:"strategy"`
+	Currency      SupportedCurrency `json:"currency"`
+	MaxAttempts   int               `json:"max_attempts,omitempty"`    // Max number of orders to place before giving up
+	MaxPriceDrift float64           `json:"max_price_drift,omitempty"` // Max percent the price can rise from the first quote when re-pricing
+	DCA           *DCAPlan          `json:"dca,omitempty"`             // Required when Strategy is DCA
+	Rebalance     *RebalancePlan    `json:"rebalance,omitempty"`       // Required when Strategy is REBALANCE
+	Grid          *GridPlan         `json:"grid,omitempty"`            // Required when Strategy is GRID
+	Protection    *Protection       `json:"protection,omitempty"`      // Optional stop-loss and take-profit for the strategy's position
+	TrailingStop  *TrailingStop     `json:"trailing_stop,omitempty"`   // Optional trailing stop for the strategy's position
+	TWAP          *TWAPPlan         `json:"twap,omitempty"`            // Optional, splits a HODL buy into slices over time
+	OrderType     OrderType         `json:"order_type,omitempty"`      // Order type HODL and DCA buy with, defaults to a post-only limit GTD order
+	QuoteCurrency string            `json:"quote_currency,omitempty"`  // Fiat currency to trade with, detected from the portfolio if empty
 }
 
 type Strategy struct {
-	Name         StrategyName      `json:"name"`
-	Currency     SupportedCurrency `json:"currency"`
-	OpenOffers   []Offer           `json:"open_offers"`
-	ClosedOffers []Offer           `json:"closed_offers"`
+	Id            string            `json:"id,omitempty"` // Tells a new strategy apart from the one it replaces
+	Name          StrategyName      `json:"name"`
+	Currency      SupportedCurrency `json:"currency"`
+	OpenOffers    []Offer           `json:"open_offers"`
+	ClosedOffers  []Offer           `json:"closed_offers"`
+	DCA           *DCAPlan          `json:"dca,omitempty"`            // Schedule and progress of a DCA strategy
+	Rebalance     *RebalancePlan    `json:"rebalance,omitempty"`      // Targets and trades of a REBALANCE strategy
+	Grid          *GridPlan         `json:"grid,omitempty"`           // Price levels of a GRID strategy
+	Protection    *Protection       `json:"protection,omitempty"`     // Stop-loss and take-profit on the strategy currency
+	TrailingStop  *TrailingStop     `json:"trailing_stop,omitempty"`  // Trailing stop on the strategy currency
+	TWAP          *TWAPPlan         `json:"twap,omitempty"`           // Slices of a time-weighted buy
+	OrderType     OrderType         `json:"order_type,omitempty"`     // Order type used to buy
+	QuoteCurrency string            `json:"quote_currency,omitempty"` // Fiat currency the strategy trades with
+}
+
+// DCAPlan spends a fixed amount of fiat on the strategy currency every interval
+// until the budget is spent or the number of buys is reached.
+type DCAPlan struct {
+	AmountPerBuy  Decimal `json:"amount_per_buy"`           // Fiat to spend on each buy
+	Interval      string  `json:"interval"`                 // Time between buys, e.g. "24h"
+	TotalBudget   Decimal `json:"total_budget"`             // Stop once this much fiat is spent, 0 if not set
+	NumberOfBuys  int     `json:"number_of_buys,omitempty"` // Stop after this many buys
+	BuysCompleted int     `json:"buys_completed"`
+	SkippedBuys   int     `json:"skipped_buys,omitempty"` // Buys in a row that spent nothing
+	AmountSpent   Decimal `json:"amount_spent"`           // Fiat spent so far, including fees
+	NextBuyAt     string  `json:"next_buy_at"`            // RFC3339 Timestamp
+	ProductID     string  `json:"product_id"`             // Product to buy, e.g. "ETH-GBP"
+}
+
+// RebalancePlan moves a portfolio towards target weights. Assets held but not listed in
+// Targets have a target of 0, cash is whatever is left over.
+type RebalancePlan struct {
+	Targets   map[string]float64 `json:"targets"`          // Asset to percent of the portfolio, e.g. {"BTC": 60, "ETH": 30, "GBP": 10}
+	Tolerance float64            `json:"tolerance"`        // Percentage points an asset can drift from its target before it is traded
+	Preview   bool               `json:"preview"`          // If true, plan the trades without placing them
+	Trades    []RebalanceTrade   `json:"trades,omitempty"` // Planned trades, sells first
+}
+
+type RebalanceTrade struct {
+	Asset         string  `json:"asset"`
+	ProductID     string  `json:"product_id"`
+	Side          Side    `json:"side"`
+	CurrentWeight float64 `json:"current_weight"` // Percent of the portfolio
+	TargetWeight  float64 `json:"target_weight"`  // Percent of the portfolio
+	Amount        Decimal `json:"amount"`         // Fiat value to buy or sell
+	BaseSize      Decimal `json:"base_size"`
+	LimitPrice    Decimal `json:"limit_price"`
+}
+
+// GridPlan spreads Capital over evenly spaced price levels between LowerPrice and UpperPrice,
+// buying below the current price and selling above it.
+type GridPlan struct {
+	LowerPrice Decimal `json:"lower_price"`
+	UpperPrice Decimal `json:"upper_price"`
+	Levels     int     `json:"levels"`               // Number of price levels, including the lower and upper price
+	Capital    Decimal `json:"capital"`              // Fiat spread evenly over the levels
+	ProductID  string  `json:"product_id,omitempty"` // Product to trade, e.g. "ETH-GBP"
+}
+
+// LevelPrice returns the price of the given level, level 1 is the lower price.
+func (p *GridPlan) LevelPrice(level int) Decimal {
+	step := p.UpperPrice.Sub(p.LowerPrice).Div(NewDecimalFromInt(int64(p.Levels - 1)))
+
+	return p.LowerPrice.Add(step.Mul(NewDecimalFromInt(int64(level - 1))))
+}
+
+// CapitalPerLevel is the fiat each level buys with.
+func (p *GridPlan) CapitalPerLevel() Decimal {
+	return p.Capital.Div(NewDecimalFromInt(int64(p.Levels)))
+}
+
+// Protection sells the strategy's position when the price falls to the stop-loss or rises
+// to the take-profit. Each threshold is either an absolute price or a percent from the
+// position's average cost. Zero values are not set.
+type Protection struct {
+	StopLossPrice     Decimal         `json:"stop_loss_price"`
+	StopLossPercent   float64         `json:"stop_loss_percent,omitempty"`
+	TakeProfitPrice   Decimal         `json:"take_profit_price"`
+	TakeProfitPercent float64         `json:"take_profit_percent,omitempty"`
+	Triggered         ProtectionEvent `json:"triggered,omitempty"`    // Set once the position has been sold
+	TriggeredAt       string          `json:"triggered_at,omitempty"` // RFC3339 Timestamp
+	TriggerPrice      Decimal         `json:"trigger_price"`          // Best bid when the protection triggered
+}
+
+// IsEmpty reports whether neither threshold is set.
+func (p *Protection) IsEmpty() bool {
+	return p.StopLossPrice.IsZero() && p.StopLossPercent == 0 && p.TakeProfitPrice.IsZero() && p.TakeProfitPercent == 0
+}
+
+// StopLoss returns the price to sell at to limit losses, or 0 if there isn't one.
+func (p *Protection) StopLoss(averageCost Decimal) Decimal {
+	if p.StopLossPrice.Sign() > 0 {
+		return p.StopLossPrice
+	}
+
+	if p.StopLossPercent > 0 {
+		return averageCost.Mul(percentFactor(-p.StopLossPercent))
+	}
+
+	return Decimal{}
+}
+
+// TakeProfit returns the price to sell at to take profits, or 0 if there isn't one.
+func (p *Protection) TakeProfit(averageCost Decimal) Decimal {
+	if p.TakeProfitPrice.Sign() > 0 {
+		return p.TakeProfitPrice
+	}
+
+	if p.TakeProfitPercent > 0 {
+		return averageCost.Mul(percentFactor(p.TakeProfitPercent))
+	}
+
+	return Decimal{}
+}
+
+// percentFactor returns what a price is multiplied by to move it by percent, e.g. 0.9 for -10.
+func percentFactor(percent float64) Decimal {
+	return NewDecimalFromInt(1).Add(NewDecimalFromFloat(percent).Div(NewDecimalFromInt(100)))
+}
+
+// TrailingStop sells the strategy's position when the price falls Percent below the highest
+// price seen since the trailing stop started. A stop-limit sell order rests at the stop price
+// and is moved up whenever the peak rises.
+type TrailingStop struct {
+	Percent   float64 `json:"percent"`
+	PeakPrice Decimal `json:"peak_price"`
+	StopPrice Decimal `json:"stop_price"`
+	OrderId   string  `json:"order_id,omitempty"` // Resting stop-limit order
+	Triggered bool    `json:"triggered,omitempty"`
+	Disarmed  bool    `json:"disarmed,omitempty"` // Set when the stop order was cancelled outside the trailing stop
+}
+
+// Observe records the price and reports whether it is a new peak.
+func (t *TrailingStop) Observe(price Decimal) bool {
+	if !price.GreaterThan(t.PeakPrice) {
+		return false
+	}
+
+	t.PeakPrice = price
+
+	return true
+}
+
+// StopPriceForPeak returns the price the position should be sold at given the current peak.
+func (t *TrailingStop) StopPriceForPeak() Decimal {
+	return t.PeakPrice.Mul(percentFactor(-t.Percent))
+}
+
+type ProtectionEvent string
+
+const (
+	StopLoss   ProtectionEvent = "STOP_LOSS"
+	TakeProfit ProtectionEvent = "TAKE_PROFIT"
+)
+
+// TWAPPlan splits a parent order into Slices child orders spread evenly over Duration.
+// Each slice is priced at the best bid when it starts, and whatever a slice doesn't fill
+// is carried over to the next one.
+type TWAPPlan struct {
+	Slices          int     `json:"slices"`
+	Duration        string  `json:"duration"`            // Time to spread the slices over, e.g. "2h"
+	ParentId        string  `json:"parent_id,omitempty"` // Parent id of the child orders
+	TotalBaseSize   Decimal `json:"total_base_size"`     // Base size of the parent order
+	FilledBaseSize  Decimal `json:"filled_base_size"`    // Base size filled by the child orders so far
+	SlicesCompleted int     `json:"slices_completed,omitempty"`
+	ProductID       string  `json:"product_id,omitempty"` // Product to buy, e.g. "ETH-GBP"
+}
+
+// IsComplete reports whether the plan has reached its budget or number of buys.
+func (p *DCAPlan) IsComplete() bool {
+	if p.NumberOfBuys > 0 && p.BuysCompleted >= p.NumberOfBuys {
+		return true
+	}
+
+	return p.TotalBudget.Sign() > 0 && !p.AmountSpent.LessThan(p.TotalBudget)
+}
+
+// MaxSkippedBuys is the number of DCA buys in a row that can spend nothing before the plan is stopped.
+const MaxSkippedBuys = 5
+
+// IsStopped reports whether the plan was given up on after too many buys in a row spent nothing.
+func (p *DCAPlan) IsStopped() bool {
+	return p.SkippedBuys >= MaxSkippedBuys
+}
+
+// NextAmount is the fiat to spend on the next buy, capped by the remaining budget.
+func (p *DCAPlan) NextAmount() Decimal {
+	if remaining := p.TotalBudget.Sub(p.AmountSpent); p.TotalBudget.Sign() > 0 && remaining.LessThan(p.AmountPerBuy) {
+		return remaining
+	}
+
+	return p.AmountPerBuy
+}
+
+// ValidateDCAPlan checks a DCA plan before it's sent to or started by the server.
+func ValidateDCAPlan(plan *DCAPlan) error {
+	if plan == nil {
+		return fmt.Errorf("DCA strategy requires a plan")
+	}
+
+	if plan.AmountPerBuy.Sign() <= 0 {
+		return fmt.Errorf("Amount per buy must be greater than 0\nGiven: %s", plan.AmountPerBuy)
+	}
+
+	interval, err := time.ParseDuration(plan.Interval)
+
+	if err != nil || interval <= 0 {
+		return fmt.Errorf("Invalid interval, e.g. 24h\nGiven: %q", plan.Interval)
+	}
+
+	if plan.TotalBudget.Sign() <= 0 && plan.NumberOfBuys <= 0 {
+		return fmt.Errorf("DCA strategy requires a total budget or a number of buys")
+	}
+
+	return nil
+}
+
+// ValidateGridPlan checks a GRID plan before it's sent to or started by the server.
+func ValidateGridPlan(plan *GridPlan) error {
+	if plan == nil {
+		return fmt.Errorf("GRID strategy requires a plan")
+	}
+
+	if plan.LowerPrice.Sign() <= 0 || !plan.UpperPrice.GreaterThan(plan.LowerPrice) {
+		return fmt.Errorf("Upper price must be greater than lower price\nGiven: %s - %s", plan.LowerPrice, plan.UpperPrice)
+	}
+
+	if plan.Levels < 2 {
+		return fmt.Errorf("GRID strategy requires at least 2 levels\nGiven: %d", plan.Levels)
+	}
+
+	if plan.Capital.Sign() <= 0 {
+		return fmt.Errorf("Capital must be greater than 0\nGiven: %s", plan.Capital)
+	}
+
+	return nil
 }
 
 type Offer struct {
@@ -20,22 +268,155 @@ type Offer struct {
 	Config                OrderConfiguration    `json:"order_configuration"`
 	SelfTradePreventionId SelfTradePreventionID `json:"self_trade_prevention_id"`
 	RetailPortfolioId     string                `json:"retail_portfolio_id"`
+	OrderId               string                `json:"order_id,omitempty"`   // Assigned by the exchange once the order is placed
+	Status                OrderStatus           `json:"status,omitempty"`     // Last status reported by the exchange
+	FilledSize            Decimal               `json:"filled_size"`          // Amount of base currency filled
+	AverageFilledPrice    Decimal               `json:"average_filled_price"` // Average price of the fills
+	TotalFees             Decimal               `json:"total_fees"`           // Fees charged in quote currency
+	GridLevel             int                   `json:"grid_level,omitempty"` // Price level of a GRID order, 1 is the lowest
+	ParentId              string                `json:"parent_id,omitempty"`  // Set on the child orders of a TWAP order
+}
+
+// ApplyOrder copies the exchange's view of the order onto the offer and reports whether anything changed.
+func (o *Offer) ApplyOrder(order *Order) bool {
+	changed := o.Status != order.Status ||
+		!o.FilledSize.Equal(order.FilledSize) ||
+		!o.AverageFilledPrice.Equal(order.AverageFilledPrice) ||
+		!o.TotalFees.Equal(order.TotalFees)
+
+	o.Status = order.Status
+	o.FilledSize = order.FilledSize
+	o.AverageFilledPrice = order.AverageFilledPrice
+	o.TotalFees = order.TotalFees
+
+	return changed
 }
 
+// OrderConfiguration holds exactly one of the order types Coinbase supports.
 type OrderConfiguration struct {
-	LimitLimitGTD LimitLimitGTD `json:"limit_limit_gtd"`
+	MarketMarketIOC       *MarketMarketIOC       `json:"market_market_ioc,omitempty"`
+	SorLimitIOC           *SorLimitIOC           `json:"sor_limit_ioc,omitempty"`
+	LimitLimitGTC         *LimitLimitGTC         `json:"limit_limit_gtc,omitempty"`
+	LimitLimitGTD         *LimitLimitGTD         `json:"limit_limit_gtd,omitempty"`
+	StopLimitStopLimitGTC *StopLimitStopLimitGTC `json:"stop_limit_stop_limit_gtc,omitempty"`
+	StopLimitStopLimitGTD *StopLimitStopLimitGTD `json:"stop_limit_stop_limit_gtd,omitempty"`
+}
+
+// OrderType returns the type of the configured order, or an error unless exactly one is configured.
+func (c OrderConfiguration) OrderType() (OrderType, error) {
+	configured := []OrderType{}
+
+	if c.MarketMarketIOC != nil {
+		configured = append(configured, MarketOrder)
+	}
+
+	if c.SorLimitIOC != nil {
+		configured = append(configured, SorLimitOrder)
+	}
+
+	if c.LimitLimitGTC != nil {
+		configured = append(configured, LimitGTCOrder)
+	}
+
+	if c.LimitLimitGTD != nil {
+		configured = append(configured, LimitGTDOrder)
+	}
+
+	if c.StopLimitStopLimitGTC != nil {
+		configured = append(configured, StopLimitGTCOrder)
+	}
+
+	if c.StopLimitStopLimitGTD != nil {
+		configured = append(configured, StopLimitGTDOrder)
+	}
+
+	if len(configured) != 1 {
+		return "", fmt.Errorf("Expected exactly 1 order configuration, found %d: %v", len(configured), configured)
+	}
+
+	return configured[0], nil
+}
+
+// A market order fills immediately at the best available price.
+// Buys are sized in quote currency, sells in base currency.
+type MarketMarketIOC struct {
+	QuoteSize Decimal `json:"quote_size"` // Amount of quote currency to spend
+	BaseSize  Decimal `json:"base_size"`  // Amount of base currency to sell
+}
+
+// MarshalJSON leaves out the size that isn't set, Coinbase rejects market orders with both.
+func (m MarketMarketIOC) MarshalJSON() ([]byte, error) {
+	sizes := map[string]Decimal{}
+
+	if !m.QuoteSize.IsZero() {
+		sizes["quote_size"] = m.QuoteSize
+	}
+
+	if !m.BaseSize.IsZero() {
+		sizes["base_size"] = m.BaseSize
+	}
+
+	return json.Marshal(sizes)
+}
+
+// A smart order routed limit order fills what it can immediately and cancels the rest.
+type SorLimitIOC struct {
+	BaseSize   Decimal `json:"base_size"`
+	LimitPrice Decimal `json:"limit_price"`
+}
+
+// A limit order that rests on the book until it fills or is cancelled.
+type LimitLimitGTC struct {
+	BaseSize   Decimal `json:"base_size"`
+	LimitPrice Decimal `json:"limit_price"`
+	PostOnly   bool    `json:"post_only"`
 }
 
 // Base size is the quantity of the base currency to buy.
 // Base currency is on the left side of the product id.
 // Example: "ETH-GBP" the base currency is "ETH"
 type LimitLimitGTD struct {
-	BaseSize   string `json:"base_size"`   // Amount of base currency to sell/buy
-	LimitPrice string `json:"limit_price"` // Ceiling price for which the order should get filled.
-	EndTime    string `json:"end_time"`    // RFC3339 Timestamp
-	PostOnly   bool   `json:"post_only"`   // If true, order should only make liquidity - maker commission charged.
+	BaseSize   Decimal `json:"base_size"`   // Amount of base currency to sell/buy
+	LimitPrice Decimal `json:"limit_price"` // Ceiling price for which the order should get filled.
+	EndTime    string  `json:"end_time"`    // RFC3339 Timestamp
+	PostOnly   bool    `json:"post_only"`   // If true, order should only make liquidity - maker commission charged.
 }
 
+// A stop-limit order becomes a limit order once the price reaches the stop price.
+type StopLimitStopLimitGTC struct {
+	BaseSize      Decimal       `json:"base_size"`      // Amount of base currency to sell/buy
+	LimitPrice    Decimal       `json:"limit_price"`    // Price of the limit order placed once the stop triggers
+	StopPrice     Decimal       `json:"stop_price"`     // Price that triggers the limit order
+	StopDirection StopDirection `json:"stop_direction"` // Whether the stop triggers when the price falls or rises to the stop price
+}
+
+type StopLimitStopLimitGTD struct {
+	BaseSize      Decimal       `json:"base_size"`
+	LimitPrice    Decimal       `json:"limit_price"`
+	StopPrice     Decimal       `json:"stop_price"`
+	EndTime       string        `json:"end_time"` // RFC3339 Timestamp
+	StopDirection StopDirection `json:"stop_direction"`
+}
+
+type StopDirection string
+
+const (
+	StopDirectionUp   StopDirection = "STOP_DIRECTION_STOP_UP"
+	StopDirectionDown StopDirection = "STOP_DIRECTION_STOP_DOWN"
+)
+
+type OrderType string
+
+// Order types are named after their Coinbase order configuration.
+const (
+	MarketOrder       OrderType = "market_market_ioc"
+	SorLimitOrder     OrderType = "sor_limit_ioc"
+	LimitGTCOrder     OrderType = "limit_limit_gtc"
+	LimitGTDOrder     OrderType = "limit_limit_gtd"
+	StopLimitGTCOrder OrderType = "stop_limit_stop_limit_gtc"
+	StopLimitGTDOrder OrderType = "stop_limit_stop_limit_gtd"
+)
+
 type CoinbaseOrderPlacedResponse struct {
 	Success         bool                               `json:"success"`
 	FailureReason   string                             `json:"failure_reason"`
@@ -44,6 +425,14 @@ type CoinbaseOrderPlacedResponse struct {
 	ErrorResponse   CoinbaseOrderPlacedErrorResponse   `json:"error_response"`
 }
 
+func (r CoinbaseOrderPlacedResponse) GetOrderID() string {
+	if r.SuccessResponse.OrderID != "" {
+		return r.SuccessResponse.OrderID
+	}
+
+	return r.OrderID
+}
+
 type CoinbaseOrderPlacedSuccessResponse struct {
 	OrderID       string `json:"order_id"`
 	ProductID     string `json:"product_id"`
@@ -75,6 +464,43 @@ type CoinbaseOrderPreviewResponse struct {
 	Slippage         string   `json:"slippage"`
 }
 
+type OrderResponse struct {
+	Order Order `json:"order"`
+}
+
+// Order is the exchange's view of an order (https://docs.cloud.coinbase.com/advanced-trade/reference/retailbrokerageapi_gethistoricalorder)
+type Order struct {
+	OrderID              string             `json:"order_id"`
+	ProductID            string             `json:"product_id"`
+	Side                 Side               `json:"side"`
+	ClientOrderID        string             `json:"client_order_id"`
+	Status               OrderStatus        `json:"status"`
+	OrderConfiguration   OrderConfiguration `json:"order_configuration"`
+	CreatedTime          string             `json:"created_time"`
+	CompletionPercentage string             `json:"completion_percentage"`
+	FilledSize           Decimal            `json:"filled_size"`
+	AverageFilledPrice   Decimal            `json:"average_filled_price"`
+	FilledValue          Decimal            `json:"filled_value"`
+	TotalFees            Decimal            `json:"total_fees"`
+	RetailPortfolioID    string             `json:"retail_portfolio_id"`
+}
+
+type ListOrdersResponse struct {
+	Orders  []Order `json:"orders"`
+	HasNext bool    `json:"has_next"`
+	Cursor  string  `json:"cursor"`
+}
+
+type CancelOrdersResponse struct {
+	Results []CancelOrderResult `json:"results"`
+}
+
+type CancelOrderResult struct {
+	Success       bool   `json:"success"`
+	FailureReason string `json:"failure_reason"`
+	OrderID       string `json:"order_id"`
+}
+
 type Side string
 
 const (
@@ -82,18 +508,78 @@ const (
 	SELL Side = "SELL"
 )
 
-type StrategyName string
+type OrderStatus string
 
 const (
-	HODL StrategyName = "HODL"
+	OPEN      OrderStatus = "OPEN"
+	FILLED    OrderStatus = "FILLED"
+	CANCELLED OrderStatus = "CANCELLED"
+	EXPIRED   OrderStatus = "EXPIRED"
+	FAILED    OrderStatus = "FAILED"
 )
 
-type SupportedCurrency string
+// IsTerminal reports whether the exchange is done with an order in this status.
+func (s OrderStatus) IsTerminal() bool {
+	return s == FILLED || s == CANCELLED || s == EXPIRED || s == FAILED
+}
+
+// Maker commission is 0.40% and taker commission is 0.60% for orders less than $10k
+const (
+	MakerCommissionRate = 0.004
+	TakerCommissionRate = 0.006
+	DefaultOrderExpiry  = time.Minute * 5
+)
+
+// OrderSettings are the commission rates orders are sized with and how long limit orders rest for.
+// Unset values fall back to the defaults above, a rate set to 0 is kept for fee free accounts.
+type OrderSettings struct {
+	MakerCommissionRate *float64
+	TakerCommissionRate *float64
+	OrderExpiry         time.Duration // How long GTD orders rest before they expire
+}
+
+// CommissionRate returns the taker rate for orders that take liquidity and the maker rate otherwise.
+func (s OrderSettings) CommissionRate(takesLiquidity bool) float64 {
+	if takesLiquidity {
+		if s.TakerCommissionRate != nil {
+			return *s.TakerCommissionRate
+		}
+
+		return TakerCommissionRate
+	}
+
+	if s.MakerCommissionRate != nil {
+		return *s.MakerCommissionRate
+	}
+
+	return MakerCommissionRate
+}
+
+// GetEndTime returns when a GTD order placed now should expire.
+func (s OrderSettings) GetEndTime() string {
+	expiry := s.OrderExpiry
+
+	if expiry <= 0 {
+		expiry = DefaultOrderExpiry
+	}
+
+	return time.Now().Add(expiry).Format(time.RFC3339)
+}
+
+type StrategyName string
 
 const (
-	ETH SupportedCurrency = "ETH"
+	HODL      StrategyName = "HODL"
+	DCA       StrategyName = "DCA"
+	REBALANCE StrategyName = "REBALANCE"
+	GRID      StrategyName = "GRID"
 )
 
+// SupportedCurrency is the base currency a strategy trades, e.g. "ETH".
+// Any currency the exchange has a product for in the portfolio's quote currency is supported,
+// the server can limit them further with an allow-list.
+type SupportedCurrency string
+
 type SelfTradePreventionID string
 
 const (
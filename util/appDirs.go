@@ -0,0 +1,102 @@
+package util
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const appName = "investment-manager"
+
+// Directories set by flags, they take priority over the environment.
+var (
+	dirsMu          sync.RWMutex
+	dataDirFlag     string
+	configDirFlag   string
+	DataDirEnvVar   = "INVESTMENT_MANAGER_DATA_DIR"
+	ConfigDirEnvVar = "INVESTMENT_MANAGER_CONFIG_DIR"
+)
+
+// SetDataDir makes dir the data directory, e.g. from a --data-dir flag. Empty leaves it to the environment.
+func SetDataDir(dir string) {
+	dirsMu.Lock()
+	defer dirsMu.Unlock()
+
+	dataDirFlag = dir
+}
+
+// SetConfigDir makes dir the config directory, e.g. from a --config-dir flag. Empty leaves it to the environment.
+func SetConfigDir(dir string) {
+	dirsMu.Lock()
+	defer dirsMu.Unlock()
+
+	configDirFlag = dir
+}
+
+// GetDataDir returns the directory state, the journal and the paper exchange are kept in, creating it if needed.
+// In order: the --data-dir flag, $INVESTMENT_MANAGER_DATA_DIR, $XDG_DATA_HOME/investment-manager,
+// then ~/.local/share/investment-manager.
+func GetDataDir() (string, error) {
+	dirsMu.RLock()
+	dir := dataDirFlag
+	dirsMu.RUnlock()
+
+	dir, err := resolveDir(dir, DataDirEnvVar, "XDG_DATA_HOME", filepath.Join(".local", "share"))
+
+	if err != nil {
+		return "", err
+	}
+
+	err = os.MkdirAll(dir, 0755)
+
+	if err != nil {
+		return "", fmt.Errorf("Failed to create data directory %s\n%v\n", dir, err)
+	}
+
+	return dir, nil
+}
+
+// GetConfigDir returns the directory config.yaml and .env are read from.
+// In order: the --config-dir flag, $INVESTMENT_MANAGER_CONFIG_DIR, $XDG_CONFIG_HOME/investment-manager,
+// then ~/.config/investment-manager. It isn't created, the config has to be put there.
+func GetConfigDir() (string, error) {
+	dirsMu.RLock()
+	dir := configDirFlag
+	dirsMu.RUnlock()
+
+	return resolveDir(dir, ConfigDirEnvVar, "XDG_CONFIG_HOME", ".config")
+}
+
+// GetPathToDataFile returns the path to filename in the data directory.
+func GetPathToDataFile(filename string) (string, error) {
+	dir, err := GetDataDir()
+
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, filename), nil
+}
+
+func resolveDir(flagValue, envVar, xdgVar, homeDefault string) (string, error) {
+	if flagValue != "" {
+		return filepath.Abs(flagValue)
+	}
+
+	if dir := os.Getenv(envVar); dir != "" {
+		return filepath.Abs(dir)
+	}
+
+	if xdgDir := os.Getenv(xdgVar); xdgDir != "" && filepath.IsAbs(xdgDir) {
+		return filepath.Join(xdgDir, appName), nil
+	}
+
+	home, err := os.UserHomeDir()
+
+	if err != nil {
+		return "", fmt.Errorf("Could not find a home directory, set %s\n%v\n", envVar, err)
+	}
+
+	return filepath.Join(home, homeDefault, appName), nil
+}